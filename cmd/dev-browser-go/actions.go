@@ -33,14 +33,7 @@ func newActionsCmd() *cobra.Command {
 			if err := json.Unmarshal([]byte(raw), &calls); err != nil {
 				return errors.New("invalid JSON for --calls/stdin")
 			}
-			pw, browser, page, err := openNamedPage(pageName)
-			if err != nil {
-				return err
-			}
-			defer browser.Close()
-			defer pw.Stop()
-
-			res, err := devbrowser.RunActions(page, calls, devbrowser.ArtifactDir(globalOpts.profile))
+			res, err := runActionsOnPage(pageName, calls)
 			if err != nil {
 				return err
 			}
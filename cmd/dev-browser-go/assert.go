@@ -37,12 +37,12 @@ func newAssertCmd() *cobra.Command {
 				return err
 			}
 
-			pw, browser, page, err := openNamedPage(pageName)
+			attached, err := openNamedPage(pageName, "assert")
 			if err != nil {
 				return err
 			}
-			defer browser.Close()
-			defer pw.Stop()
+			defer attached.Close()
+			page := attached.page
 
 			ts := time.Now()
 			ctx := devbrowser.NewRunContext(devbrowser.RunOptions{
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newCheckRefCmd() *cobra.Command {
+	var pageName string
+	var timeout int
+
+	cmd := &cobra.Command{
+		Use:   "check-ref <ref>",
+		Short: "Check a checkbox/radio by ref",
+		Args:  requireArgs(1, "ref required"),
+		RunE: func(_ *cobra.Command, args []string) error {
+			payload := map[string]interface{}{
+				"ref":        args[0],
+				"timeout_ms": timeout,
+			}
+			return runWithPage(pageName, "check_ref", payload)
+		},
+	}
+
+	cmd.Flags().StringVar(&pageName, "page", "main", "Page name")
+	cmd.Flags().IntVar(&timeout, "timeout-ms", 15_000, "Timeout ms")
+
+	return cmd
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	devbrowser "github.com/joshp123/dev-browser-go/internal/devbrowser"
 	"github.com/spf13/cobra"
 )
 
@@ -160,3 +163,178 @@ func TestConsolePositionalArgFails(t *testing.T) {
 		t.Fatalf("expected error message to reference js-eval, got: %v", err)
 	}
 }
+
+// --- ref action tests --------------------------------------------------------
+
+func TestSelectRefRequiresOption(t *testing.T) {
+	root := newTestRoot()
+	root.AddCommand(withNoopRunE(newSelectRefCmd()))
+	root.SetArgs([]string{"select-ref", "e3"})
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "at least one option") {
+		t.Fatalf("expected missing option error, got: %v", err)
+	}
+}
+
+func TestDragRefRequiresTarget(t *testing.T) {
+	root := newTestRoot()
+	root.AddCommand(withNoopRunE(newDragRefCmd()))
+	root.SetArgs([]string{"drag-ref", "e3"})
+	if err := root.Execute(); err == nil {
+		t.Fatal("expected error when drag-ref has no target ref")
+	}
+}
+
+func TestUploadRefRequiresFile(t *testing.T) {
+	root := newTestRoot()
+	root.AddCommand(withNoopRunE(newUploadRefCmd()))
+	root.SetArgs([]string{"upload-ref", "e3"})
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "at least one file") {
+		t.Fatalf("expected missing file error, got: %v", err)
+	}
+}
+
+// --- downloads tests ---------------------------------------------------------
+
+func TestDownloadsActionValidation(t *testing.T) {
+	for _, args := range [][]string{
+		{"downloads"},
+		{"downloads", "open"},
+		{"downloads", "list", "1"},
+		{"downloads", "save"},
+		{"downloads", "save", "abc"},
+		{"downloads", "save", "0"},
+	} {
+		root := newTestRoot()
+		root.AddCommand(withNoopRunE(newDownloadsCmd()))
+		root.SetArgs(args)
+		if err := root.Execute(); err == nil {
+			t.Fatalf("expected error for %v", args)
+		}
+	}
+
+	root := newTestRoot()
+	root.AddCommand(withNoopRunE(newDownloadsCmd()))
+	root.SetArgs([]string{"downloads", "save", "2", "--path", "exports/report.csv"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("expected valid save args, got: %v", err)
+	}
+}
+
+// --- events tests ------------------------------------------------------------
+
+func TestEventsRejectsUnknownKind(t *testing.T) {
+	root := newTestRoot()
+	root.AddCommand(withNoopRunE(newEventsCmd()))
+	root.SetArgs([]string{"events", "--kinds", "console,requests"})
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "requests") {
+		t.Fatalf("expected unknown kind error, got: %v", err)
+	}
+}
+
+func TestFormatEventLine(t *testing.T) {
+	line := formatEventLine(map[string]any{
+		"kind": "network",
+		"data": map[string]any{"method": "GET", "status": float64(404), "url": "http://x/api", "duration_ms": float64(12)},
+	})
+	if !strings.Contains(line, "GET 404 http://x/api (12ms)") {
+		t.Fatalf("unexpected network line %q", line)
+	}
+	line = formatEventLine(map[string]any{"kind": "console", "data": map[string]any{"type": "error", "text": "boom"}})
+	if !strings.Contains(line, "[error] boom") {
+		t.Fatalf("unexpected console line %q", line)
+	}
+}
+
+// --- profiles tests ----------------------------------------------------------
+
+func TestProfilesActionValidation(t *testing.T) {
+	for _, args := range [][]string{
+		{"profiles"},
+		{"profiles", "clean"},
+		{"profiles", "list", "x"},
+		{"profiles", "rm"},
+		{"profiles", "rm", "../etc"},
+		{"profiles", "rm", "a", "b"},
+	} {
+		root := newTestRoot()
+		root.AddCommand(withNoopRunE(newProfilesCmd()))
+		root.SetArgs(args)
+		if err := root.Execute(); err == nil {
+			t.Fatalf("expected error for %v", args)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"profiles", "list"},
+		{"profiles", "prune", "--user-data", "--artifacts", "--dry-run"},
+		{"profiles", "rm", "old", "--force"},
+	} {
+		root := newTestRoot()
+		root.AddCommand(withNoopRunE(newProfilesCmd()))
+		root.SetArgs(args)
+		if err := root.Execute(); err != nil {
+			t.Fatalf("expected valid args %v, got: %v", args, err)
+		}
+	}
+}
+
+func TestProfileSummary(t *testing.T) {
+	line := profileSummary(devbrowser.ProfileInfo{
+		Name:          "old",
+		StaleState:    true,
+		Context:       &devbrowser.BrowserContextSettings{Browser: devbrowser.BrowserChromium, Headless: true},
+		UserDataBytes: 3 * 1024 * 1024,
+		ArtifactBytes: 512,
+	})
+	want := "old stopped stale-state headless=true user-data=3.0MB artifacts=512B"
+	if line != want {
+		t.Fatalf("expected %q, got %q", want, line)
+	}
+}
+
+// --- snapshot tests ----------------------------------------------------------
+
+func TestSnapshotTokenBudgetMinimum(t *testing.T) {
+	root := newTestRoot()
+	root.AddCommand(withNoopRunE(newSnapshotCmd()))
+	root.SetArgs([]string{"snapshot", "--token-budget", "30"})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "at least 60") {
+		t.Fatalf("expected a minimum budget error, got: %v", err)
+	}
+
+	root = newTestRoot()
+	root.AddCommand(withNoopRunE(newSnapshotCmd()))
+	root.SetArgs([]string{"snapshot", "--token-budget", "60"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("expected the minimum budget to pass, got: %v", err)
+	}
+}
+
+// --- route tests -------------------------------------------------------------
+
+func TestBuildRoutePayloadSendsAbsoluteBodyFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "fixtures"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "fixtures", "x.json"), []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	payload, err := buildRoutePayload(newRouteCmd(), routeFlags{url: "**/api/**", action: devbrowser.RouteActionFulfill, bodyFile: "fixtures/x.json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := filepath.Join(dir, "fixtures", "x.json")
+	if got := payload["body_path"]; got != want {
+		t.Fatalf("body_path = %v, want %s", got, want)
+	}
+
+	if _, err := buildRoutePayload(newRouteCmd(), routeFlags{url: "**/api/**", action: devbrowser.RouteActionFulfill, bodyFile: "fixtures/missing.json"}); err == nil {
+		t.Fatal("expected an error for a missing body file")
+	}
+}
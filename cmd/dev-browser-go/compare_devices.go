@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	devbrowser "github.com/joshp123/dev-browser-go/internal/devbrowser"
+	"github.com/spf13/cobra"
+)
+
+func newCompareDevicesCmd() *cobra.Command {
+	var pageName string
+	var targetURL string
+	var devices string
+	var waitState string
+	var timeoutMs int
+	var minWaitMs int
+	var snapshotEngine string
+	var fullPage bool
+	var columns int
+	var shareState bool
+	var artifactDir string
+
+	cmd := &cobra.Command{
+		Use:   "compare-devices",
+		Short: "Load a URL on several emulated devices and build a screenshot grid with per-device summaries",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if strings.TrimSpace(targetURL) == "" {
+				return errors.New("--url is required")
+			}
+			if shareState && globalOpts.contextName != "" {
+				return errors.New("--share-state copies the default context; drop --context")
+			}
+			list := devbrowser.ParseDeviceList(devices)
+			if len(list) == 0 {
+				return errors.New("--devices is required (comma-separated device names or WIDTHxHEIGHT)")
+			}
+
+			attached, err := openNamedPage(pageName, "compare_devices")
+			if err != nil {
+				return err
+			}
+			defer attached.Close()
+			page := attached.page
+
+			ctx := devbrowser.NewRunContext(devbrowser.RunOptions{
+				Profile:   globalOpts.profile,
+				Timestamp: time.Now(),
+			})
+			runDir, err := ctx.ResolveRunDir(artifactDir)
+			if err != nil {
+				return err
+			}
+
+			report, err := devbrowser.CompareDevices(attached.pw, page, devbrowser.CompareDevicesOptions{
+				URL:            targetURL,
+				Devices:        list,
+				WaitState:      waitState,
+				TimeoutMs:      timeoutMs,
+				MinWaitMs:      minWaitMs,
+				SnapshotEngine: snapshotEngine,
+				FullPage:       fullPage,
+				Columns:        columns,
+				ShareState:     shareState,
+				ArtifactDir:    runDir,
+			})
+			if err != nil {
+				return err
+			}
+			return printOutput(report)
+		},
+	}
+
+	cmd.Flags().StringVar(&pageName, "page", "main", "Page whose context supplies --share-state")
+	cmd.Flags().StringVar(&targetURL, "url", "", "URL to load on every device (required)")
+	cmd.Flags().StringVar(&devices, "devices", "", "Comma-separated devices, e.g. \"iPhone 15,Pixel 7,Desktop 1440x900\" (required)")
+	cmd.Flags().StringVar(&waitState, "wait", "networkidle", "Wait state (load|domcontentloaded|networkidle|commit)")
+	cmd.Flags().IntVar(&timeoutMs, "timeout-ms", 45_000, "Timeout in ms per device")
+	cmd.Flags().IntVar(&minWaitMs, "min-wait-ms", 250, "Minimum wait time in ms")
+	cmd.Flags().StringVar(&snapshotEngine, "snapshot-engine", "simple", "Snapshot engine (simple|aria)")
+	cmd.Flags().BoolVar(&fullPage, "full-page", false, "Capture full-page screenshots instead of the viewport")
+	cmd.Flags().IntVar(&columns, "columns", 0, "Grid columns (0 = one row)")
+	cmd.Flags().BoolVar(&shareState, "share-state", false, "Copy cookies/localStorage from the persistent context into each device")
+	cmd.Flags().StringVar(&artifactDir, "artifact-dir", "", "Artifact directory (relative to artifact root unless absolute). Default: per-run dir")
+
+	return cmd
+}
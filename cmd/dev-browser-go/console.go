@@ -38,7 +38,6 @@ func newConsoleCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			endpoint := fmt.Sprintf("%s/pages/%s/console", base, url.PathEscape(pageName))
 			query := url.Values{}
 			if cmd.Flags().Changed("limit") {
 				query.Set("limit", strconv.Itoa(limit))
@@ -49,9 +48,7 @@ func newConsoleCmd() *cobra.Command {
 			if since > 0 {
 				query.Set("since", strconv.FormatInt(since, 10))
 			}
-			if encoded := query.Encode(); encoded != "" {
-				endpoint += "?" + encoded
-			}
+			endpoint := pageEndpoint(base, pageName, "console", query)
 			data, err := devbrowser.HTTPJSON("GET", endpoint, nil, 5*time.Second)
 			if err != nil {
 				return err
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newContextsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "contexts <list|close> [name]",
+		Short: "List or close named browser contexts (create one by passing --context <name> to any command)",
+		Args: func(_ *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("contexts action required (list|close)")
+			}
+			switch args[0] {
+			case "list":
+				if len(args) != 1 {
+					return errors.New("contexts list takes no arguments")
+				}
+			case "close":
+				if len(args) != 2 {
+					return errors.New("contexts close requires <name>")
+				}
+			default:
+				return fmt.Errorf("unknown contexts action %q (expected list|close)", args[0])
+			}
+			return nil
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			if args[0] == "list" {
+				data, err := daemonCall("contexts list", http.MethodGet, "/contexts", nil, 5*time.Second)
+				if err != nil {
+					return err
+				}
+				return printOutput(map[string]any{"contexts": data["contexts"]})
+			}
+			data, err := daemonCall("contexts close", http.MethodDelete, "/contexts/"+url.PathEscape(args[1]), nil, 15*time.Second)
+			if err != nil {
+				return err
+			}
+			return printOutput(map[string]any{"context": data["closed"], "closed": true})
+		},
+	}
+	return cmd
+}
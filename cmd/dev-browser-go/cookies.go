@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newCookiesCmd() *cobra.Command {
+	var pageName string
+	var domain string
+	var name string
+	var path string
+	var urls []string
+	var expires float64
+	var httpOnly bool
+	var secure bool
+	var sameSite string
+
+	cmd := &cobra.Command{
+		Use:   "cookies <list|get|set|delete|clear> [name] [value]",
+		Short: "Manage cookies in the persistent context (includes HttpOnly; filter by domain/name)",
+		Args: func(_ *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("cookies action required (list|get|set|delete|clear)")
+			}
+			want := map[string]int{"list": 1, "clear": 1, "get": 2, "delete": 2, "set": 3}
+			n, ok := want[args[0]]
+			if !ok {
+				return fmt.Errorf("unknown cookies action %q (expected list|get|set|delete|clear)", args[0])
+			}
+			if len(args) != n {
+				switch args[0] {
+				case "get", "delete":
+					return fmt.Errorf("cookies %s requires <name>", args[0])
+				case "set":
+					return errors.New("cookies set requires <name> <value>")
+				default:
+					return fmt.Errorf("cookies %s takes no arguments (use --name/--domain)", args[0])
+				}
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if globalOpts.contextName != "" {
+				return errors.New("cookies acts on the default context; use state save/load --context for named contexts")
+			}
+			action := args[0]
+			payload := map[string]interface{}{}
+			if strings.TrimSpace(domain) != "" {
+				payload["domain"] = domain
+			}
+			if strings.TrimSpace(path) != "" {
+				payload["path"] = path
+			}
+			if strings.TrimSpace(name) != "" {
+				payload["name"] = name
+			}
+			if len(args) > 1 {
+				payload["name"] = args[1]
+			}
+
+			if action == "set" {
+				payload["value"] = args[2]
+				if len(urls) > 1 {
+					return errors.New("cookies set accepts a single --url")
+				}
+				if len(urls) == 1 {
+					payload["url"] = urls[0]
+				}
+				if cmd.Flags().Changed("expires") {
+					payload["expires"] = expires
+				}
+				if cmd.Flags().Changed("http-only") {
+					payload["http_only"] = httpOnly
+				}
+				if cmd.Flags().Changed("secure") {
+					payload["secure"] = secure
+				}
+				if strings.TrimSpace(sameSite) != "" {
+					payload["same_site"] = sameSite
+				}
+			} else if len(urls) > 0 {
+				payload["url"] = urls
+			}
+
+			return runWithPage(pageName, "cookies_"+action, payload)
+		},
+	}
+
+	cmd.Flags().StringVar(&pageName, "page", "main", "Page name")
+	cmd.Flags().StringVar(&domain, "domain", "", "Cookie domain (filters match subdomains; set: cookie domain)")
+	cmd.Flags().StringVar(&name, "name", "", "Filter: cookie name (list/clear)")
+	cmd.Flags().StringVar(&path, "path", "", "Cookie path (filter; set: defaults to /)")
+	cmd.Flags().StringArrayVar(&urls, "url", nil, "Only cookies visible to URL (repeatable; set: cookie URL instead of --domain)")
+	cmd.Flags().Float64Var(&expires, "expires", 0, "set: expiry as unix seconds (default session cookie)")
+	cmd.Flags().BoolVar(&httpOnly, "http-only", false, "set: HttpOnly cookie")
+	cmd.Flags().BoolVar(&secure, "secure", false, "set: Secure cookie")
+	cmd.Flags().StringVar(&sameSite, "same-site", "", "set: SameSite (Strict|Lax|None)")
+
+	return cmd
+}
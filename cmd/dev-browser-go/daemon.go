@@ -3,16 +3,22 @@ package main
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	devbrowser "github.com/joshp123/dev-browser-go/internal/devbrowser"
 	"github.com/spf13/cobra"
 )
 
 type daemonOptions struct {
-	host      string
-	port      int
-	cdpPort   int
-	stateFile string
+	host        string
+	port        int
+	socket      bool
+	cdpPort     int
+	stateFile   string
+	idleTimeout time.Duration
+	maxPages    int
+	maxMemoryMB int
 }
 
 func newDaemonCmd() *cobra.Command {
@@ -27,22 +33,69 @@ func newDaemonCmd() *cobra.Command {
 	}
 	cmd.Flags().StringVar(&opts.host, "host", getenvDefault("DEV_BROWSER_HOST", "127.0.0.1"), "Listen host")
 	cmd.Flags().IntVar(&opts.port, "port", getenvInt("DEV_BROWSER_PORT", 0), "Listen port")
+	cmd.Flags().BoolVar(&opts.socket, "socket", envTruthy(devbrowser.EnvSocket), "Listen on a Unix socket in the profile state dir instead of TCP")
 	cmd.Flags().IntVar(&opts.cdpPort, "cdp-port", getenvInt("DEV_BROWSER_CDP_PORT", 0), "CDP port")
 	cmd.Flags().StringVar(&opts.stateFile, "state-file", getenvDefault("DEV_BROWSER_STATE_FILE", ""), "State file")
+	addDaemonLimitFlags(cmd, opts)
 	return cmd
 }
 
 func runDaemon(opts *daemonOptions) error {
 	logger := log.New(os.Stderr, "", log.LstdFlags)
+	socket := ""
+	if opts.socket {
+		socket = devbrowser.DaemonSocketPath(globalOpts.profile)
+	}
 	return devbrowser.ServeDaemon(devbrowser.DaemonOptions{
 		Profile:   globalOpts.profile,
+		Browser:   globalOpts.browser,
 		Host:      opts.host,
 		Port:      opts.port,
+		Socket:    socket,
 		CDPPort:   opts.cdpPort,
 		Headless:  globalOpts.headless,
 		Window:    globalOpts.window,
 		Device:    globalOpts.device,
 		StateFile: opts.stateFile,
 		Logger:    logger,
+
+		CDPEndpoint: globalOpts.cdpEndpoint,
+		IdleTimeout: opts.idleTimeout,
+		Limits: devbrowser.HostLimits{
+			MaxPages:    opts.maxPages,
+			MaxMemoryMB: opts.maxMemoryMB,
+		},
 	})
 }
+
+// addDaemonLimitFlags registers the idle timeout and resource caps. The
+// defaults come from the environment, which is also how `start` hands them to
+// the daemon process it spawns.
+func addDaemonLimitFlags(cmd *cobra.Command, opts *daemonOptions) {
+	cmd.Flags().DurationVar(&opts.idleTimeout, "idle-timeout", getenvDuration(devbrowser.EnvIdleTimeout, 0), "Shut the daemon down after this long without requests (e.g. 30m; 0 = never)")
+	cmd.Flags().IntVar(&opts.maxPages, "max-pages", getenvInt(devbrowser.EnvMaxPages, 0), "Refuse new pages beyond this many open pages (0 = unlimited)")
+	cmd.Flags().IntVar(&opts.maxMemoryMB, "max-memory-mb", getenvInt(devbrowser.EnvMaxMemoryMB, 0), "Refuse new pages once the browser uses this much memory in MB (0 = unlimited)")
+}
+
+// exportDaemonLimits passes limit flags set on this command to a daemon
+// spawned from it.
+func exportDaemonLimits(cmd *cobra.Command, opts *daemonOptions) {
+	if cmd.Flags().Changed("idle-timeout") {
+		_ = os.Setenv(devbrowser.EnvIdleTimeout, opts.idleTimeout.String())
+	}
+	if cmd.Flags().Changed("max-pages") {
+		_ = os.Setenv(devbrowser.EnvMaxPages, strconv.Itoa(opts.maxPages))
+	}
+	if cmd.Flags().Changed("max-memory-mb") {
+		_ = os.Setenv(devbrowser.EnvMaxMemoryMB, strconv.Itoa(opts.maxMemoryMB))
+	}
+}
+
+func daemonLimitsChanged(cmd *cobra.Command) bool {
+	for _, name := range []string{"idle-timeout", "max-pages", "max-memory-mb"} {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newDblclickRefCmd() *cobra.Command {
+	var pageName string
+	var timeout int
+
+	cmd := &cobra.Command{
+		Use:   "dblclick-ref <ref>",
+		Short: "Double-click element by ref",
+		Args:  requireArgs(1, "ref required"),
+		RunE: func(_ *cobra.Command, args []string) error {
+			payload := map[string]interface{}{
+				"ref":        args[0],
+				"timeout_ms": timeout,
+			}
+			return runWithPage(pageName, "dblclick_ref", payload)
+		},
+	}
+
+	cmd.Flags().StringVar(&pageName, "page", "main", "Page name")
+	cmd.Flags().IntVar(&timeout, "timeout-ms", 15_000, "Timeout ms")
+
+	return cmd
+}
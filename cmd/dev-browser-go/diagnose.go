@@ -24,6 +24,8 @@ func newDiagnoseCmd() *cobra.Command {
 	var perfTopN int
 	var artifactMode string
 	var artifactDir string
+	var replayHAR string
+	var harNotFound string
 
 	cmd := &cobra.Command{
 		Use:   "diagnose",
@@ -38,13 +40,10 @@ func newDiagnoseCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-
-			pw, browser, page, err := openNamedPage(pageName)
+			replayPath, err := applyHARReplay(replayHAR, harNotFound)
 			if err != nil {
 				return err
 			}
-			defer browser.Close()
-			defer pw.Stop()
 
 			ts := time.Now()
 			ctx := devbrowser.NewRunContext(devbrowser.RunOptions{
@@ -61,7 +60,7 @@ func newDiagnoseCmd() *cobra.Command {
 				_ = ctx.EnsureDir(runDir)
 			}
 
-			report, err := devbrowser.Diagnose(page, devbrowser.DiagnoseOptions{
+			opts := devbrowser.DiagnoseOptions{
 				URL:             targetURL,
 				WaitState:       waitState,
 				TimeoutMs:       timeoutMs,
@@ -77,16 +76,38 @@ func newDiagnoseCmd() *cobra.Command {
 				NetMaxBodyBytes: netMaxBodyBytes,
 				PerfSampleMs:    perfSampleMs,
 				PerfTopN:        perfTopN,
-			})
-			if err != nil {
-				return err
 			}
+			var report *devbrowser.DiagnoseReport
+			if globalOpts.attach && devbrowser.BrowserSupportsCDP(daemonBrowser()) {
+				attached, err := openNamedPage(pageName, "diagnose")
+				if err != nil {
+					return err
+				}
+				defer attached.Close()
+				page := attached.page
+				report, err = devbrowser.Diagnose(page, opts)
+				if err != nil {
+					return err
+				}
+			} else {
+				report, err = diagnoseInDaemon(opts)
+				if err != nil {
+					return err
+				}
+			}
+			report.Meta.ReplayHAR = replayPath
 
 			// Populate console entries from daemon (stable, cross-tool behavior).
 			consoleEntries, err := readConsoleEntries(base, pageName, 200)
 			if err == nil {
 				report.SetConsole(consoleEntries)
 			}
+			if routes, err := readRoutes(base); err == nil {
+				report.SetRoutes(routes)
+			}
+			if health, err := devbrowser.ReadDaemonHealth(globalOpts.profile); err == nil && health != nil {
+				report.SetCrashes(health.Crashes, globalOpts.contextName)
+			}
 
 			// Write artifacts (best-effort).
 			_ = devbrowser.WriteDiagnoseArtifacts(report, mode)
@@ -111,17 +132,17 @@ func newDiagnoseCmd() *cobra.Command {
 	cmd.Flags().IntVar(&perfSampleMs, "perf-sample-ms", 1200, "Perf metrics sample ms")
 	cmd.Flags().IntVar(&perfTopN, "perf-top-n", 20, "Perf metrics top-N resources")
 	cmd.Flags().StringVar(&artifactMode, "artifact-mode", string(devbrowser.ArtifactModeMinimal), "Artifacts: none|minimal|full")
+	addHARReplayFlags(cmd, &replayHAR, &harNotFound)
 	cmd.Flags().StringVar(&artifactDir, "artifact-dir", "", "Artifact directory (relative to artifact root unless absolute). Default: per-run dir")
 
 	return cmd
 }
 
 func readConsoleEntries(base, pageName string, limit int) ([]devbrowser.ConsoleEntry, error) {
-	endpoint := fmt.Sprintf("%s/pages/%s/console", base, url.PathEscape(pageName))
 	q := url.Values{}
 	q.Set("limit", strconv.Itoa(limit))
 	q.Set("levels", "all")
-	endpoint += "?" + q.Encode()
+	endpoint := pageEndpoint(base, pageName, "console", q)
 	data, err := devbrowser.HTTPJSON("GET", endpoint, nil, 5*time.Second)
 	if err != nil {
 		return nil, err
@@ -138,3 +159,31 @@ func readConsoleEntries(base, pageName string, limit int) ([]devbrowser.ConsoleE
 	_ = json.Unmarshal(b, &entries)
 	return entries, nil
 }
+
+// diagnoseInDaemon runs the diagnose tool on the daemon's page handle.
+func diagnoseInDaemon(opts devbrowser.DiagnoseOptions) (*devbrowser.DiagnoseReport, error) {
+	res, err := runToolOnPage(opts.PageName, "diagnose", map[string]interface{}{
+		"url":                opts.URL,
+		"page":               opts.PageName,
+		"profile":            opts.Profile,
+		"wait":               opts.WaitState,
+		"timeout_ms":         opts.TimeoutMs,
+		"min_wait_ms":        opts.MinWaitMs,
+		"snapshot_engine":    opts.SnapshotEngine,
+		"net_bodies":         opts.NetBodies,
+		"net_max_body_bytes": opts.NetMaxBodyBytes,
+		"perf_sample_ms":     opts.PerfSampleMs,
+		"perf_top_n":         opts.PerfTopN,
+		"run_id":             opts.RunID,
+		"artifact_mode":      string(opts.Artifacts),
+		"artifact_dir":       opts.ArtifactDir,
+	})
+	if err != nil {
+		return nil, err
+	}
+	report := &devbrowser.DiagnoseReport{}
+	if err := remarshal(res["report"], report); err != nil {
+		return nil, fmt.Errorf("decode diagnose report: %w", err)
+	}
+	return report, nil
+}
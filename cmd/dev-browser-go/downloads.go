@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	devbrowser "github.com/joshp123/dev-browser-go/internal/devbrowser"
+	"github.com/spf13/cobra"
+)
+
+func newDownloadsCmd() *cobra.Command {
+	var pageName string
+	var path string
+
+	cmd := &cobra.Command{
+		Use:   "downloads <list|save> [id]",
+		Short: "List downloads the daemon captured for a page, or save one under the artifact dir",
+		Args: func(_ *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("downloads action required (list|save)")
+			}
+			switch args[0] {
+			case "list":
+				if len(args) != 1 {
+					return errors.New("downloads list takes no arguments")
+				}
+			case "save":
+				if len(args) != 2 {
+					return errors.New("downloads save requires <id>")
+				}
+				if id, err := strconv.ParseInt(args[1], 10, 64); err != nil || id <= 0 {
+					return fmt.Errorf("invalid download id %q", args[1])
+				}
+			default:
+				return fmt.Errorf("unknown downloads action %q (expected list|save)", args[0])
+			}
+			return nil
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			base, err := startDaemonIfNeeded()
+			if err != nil {
+				return err
+			}
+			if args[0] == "list" {
+				data, err := devbrowser.HTTPJSON(http.MethodGet, pageEndpoint(base, pageName, "downloads", nil), nil, 5*time.Second)
+				if err != nil {
+					return err
+				}
+				if ok, _ := data["ok"].(bool); !ok {
+					return fmt.Errorf("downloads list failed: %v", data["error"])
+				}
+				return printOutput(map[string]any{"page": pageName, "downloads": data["downloads"]})
+			}
+
+			// Saving waits for an in-progress download to finish.
+			endpoint := pageEndpoint(base, pageName, "downloads/"+args[1]+"/save", nil)
+			data, err := devbrowser.HTTPJSON(http.MethodPost, endpoint, map[string]any{"path": path}, 2*time.Minute)
+			if err != nil {
+				return err
+			}
+			if ok, _ := data["ok"].(bool); !ok {
+				return fmt.Errorf("downloads save failed: %v", data["error"])
+			}
+			return printOutput(map[string]any{"path": data["path"], "download": data["download"]})
+		},
+	}
+
+	cmd.Flags().StringVar(&pageName, "page", "main", "Page name")
+	cmd.Flags().StringVar(&path, "path", "", "Save path (relative to artifact dir; default: suggested filename)")
+
+	return cmd
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newDragRefCmd() *cobra.Command {
+	var pageName string
+	var timeout int
+
+	cmd := &cobra.Command{
+		Use:   "drag-ref <source-ref> <target-ref>",
+		Short: "Drag one element onto another by ref",
+		Args:  requireArgs(2, "source and target refs required"),
+		RunE: func(_ *cobra.Command, args []string) error {
+			payload := map[string]interface{}{
+				"ref":        args[0],
+				"target":     args[1],
+				"timeout_ms": timeout,
+			}
+			return runWithPage(pageName, "drag_ref", payload)
+		},
+	}
+
+	cmd.Flags().StringVar(&pageName, "page", "main", "Page name")
+	cmd.Flags().IntVar(&timeout, "timeout-ms", 15_000, "Timeout ms")
+
+	return cmd
+}
@@ -233,3 +233,124 @@ func startInteractiveRefsFixtureServer(t *testing.T) string {
 	t.Cleanup(server.Close)
 	return server.URL
 }
+
+func startRerenderRefsFixtureServer(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<!doctype html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>rerender refs fixture</title>
+</head>
+<body>
+  <h1>Rerender fixture</h1>
+  <div id="toolbar"></div>
+  <div id="footer"></div>
+  <p id="result">idle</p>
+  <script>
+    (() => {
+      const toolbar = document.getElementById('toolbar');
+      const result = document.getElementById('result');
+      document.addEventListener('click', (event) => {
+        const button = event.target.closest('button');
+        if (button) result.textContent = button.textContent.trim() + ' clicked';
+      });
+      // render swaps in new nodes the way a framework re-render does.
+      window.render = () => {
+        toolbar.innerHTML = '<button type="button">Save</button><button type="button">Delete</button>';
+      };
+      window.moveSave = () => {
+        toolbar.querySelector('button').remove();
+        document.getElementById('footer').innerHTML = '<section><button type="button">Save</button></section>';
+      };
+      window.removeDelete = () => {
+        toolbar.querySelectorAll('button').forEach((button) => button.remove());
+      };
+      window.render();
+    })();
+  </script>
+</body>
+</html>`))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// startScopedSnapshotFixtureServer serves a dialog whose last button sits
+// under two plain wrapper divs, so DOM depth and ARIA depth differ.
+func startScopedSnapshotFixtureServer(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<!doctype html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>scoped snapshot fixture</title>
+</head>
+<body>
+  <main>
+    <button type="button">Outside</button>
+    <section id="panel" role="dialog" aria-label="Settings">
+      <button type="button">Apply</button>
+      <div><div><button type="button">Deep</button></div></div>
+    </section>
+  </main>
+</body>
+</html>`))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// startFramesFixtureServer serves a page with a same-origin iframe, an iframe
+// from a second server (another origin) and an open shadow root.
+func startFramesFixtureServer(t *testing.T) string {
+	t.Helper()
+	cross := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<!doctype html>
+<html><body>
+  <button type="button" onclick="this.textContent = 'Paid'">Pay now</button>
+</body></html>`))
+	}))
+	t.Cleanup(cross.Close)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/frame", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<!doctype html>
+<html><body>
+  <input type="text" aria-label="Card number">
+</body></html>`))
+	})
+	mux.HandleFunc("/frame-next", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<!doctype html><html><body><button type="button">Next step</button></body></html>`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = fmt.Fprintf(w, `<!doctype html>
+<html>
+<head><meta charset="utf-8"><title>frames fixture</title></head>
+<body>
+  <h1>Frames fixture</h1>
+  <div id="host"></div>
+  <iframe id="same" src="/frame" width="400" height="120"></iframe>
+  <iframe id="cross" src="%s/pay" width="400" height="120"></iframe>
+  <script>
+    const root = document.getElementById('host').attachShadow({ mode: 'open' });
+    root.innerHTML = '<button type="button">Shadow action</button><p id="out">idle</p>';
+    root.querySelector('button').addEventListener('click', () => {
+      root.getElementById('out').textContent = 'shadow clicked';
+    });
+  </script>
+</body>
+</html>`, cross.URL)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server.URL
+}
@@ -175,3 +175,280 @@ func TestCLIInteractionWorkflowWithRefs(t *testing.T) {
 		t.Fatalf("click interaction result = %q, want %q", got, "Galaxy S25 via click")
 	}
 }
+
+func TestCLIRefsSurviveRerenderAndReportStale(t *testing.T) {
+	profile := "e2e-stale-ref"
+	env := newE2EEnv(t)
+	bin := buildCLIForE2E(t)
+	pageURL := startRerenderRefsFixtureServer(t)
+
+	t.Cleanup(func() {
+		_, _ = runCLICommand(t, env, 15*time.Second, bin, "--profile", profile, "stop")
+	})
+
+	runCLIJSON(t, env, 45*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"goto", pageURL,
+	)
+
+	snapshotRes := runCLIJSON(t, env, 20*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"snapshot", "--format", "list", "--max-chars", "4000",
+	)
+	items := snapshotItems(snapshotRes["items"])
+	saveRef := findSnapshotRef(items, "button", "Save")
+	deleteRef := findSnapshotRef(items, "button", "Delete")
+	if saveRef == "" || deleteRef == "" {
+		t.Fatalf("snapshot missing expected refs: %#v", snapshotRes)
+	}
+
+	// A node re-rendered in place keeps its ref.
+	runCLIJSON(t, env, 15*time.Second, bin, "--profile", profile, "--output", "json", "js-eval", "window.render()")
+	clickRes := runCLIJSON(t, env, 20*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"click-ref", saveRef,
+	)
+	if clicked, ok := clickRes["clicked"].(bool); !ok || !clicked {
+		t.Fatalf("click-ref after re-render failed: %#v", clickRes)
+	}
+	result := runCLIJSON(t, env, 15*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"js-eval", `document.getElementById("result").textContent.trim()`,
+	)
+	if got := strings.TrimSpace(asString(result["result"])); got != "Save clicked" {
+		t.Fatalf("click after re-render result = %q, want %q", got, "Save clicked")
+	}
+
+	// A node moved elsewhere is stale; the error describes the likely
+	// replacement without giving it a ref, so the next snapshot's refs are
+	// unchanged by the lookup.
+	runCLIJSON(t, env, 15*time.Second, bin, "--profile", profile, "--output", "json", "js-eval", "window.moveSave()")
+	stdout, stderr, code := runCLICommandAllowExit(t, env, 20*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"click-ref", saveRef,
+	)
+	if code == 0 {
+		t.Fatalf("click-ref on a moved node should fail\nstdout=%s", stdout)
+	}
+	if !strings.Contains(stderr, "stale ref "+saveRef) || !strings.Contains(stderr, `likely replacement: button "Save" elsewhere on the page`) {
+		t.Fatalf("stale ref error should suggest a replacement\nstderr=%s", stderr)
+	}
+
+	// A removed node with nothing like it left is stale with no suggestion.
+	runCLIJSON(t, env, 15*time.Second, bin, "--profile", profile, "--output", "json", "js-eval", "window.removeDelete()")
+	stdout, stderr, code = runCLICommandAllowExit(t, env, 20*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"click-ref", deleteRef,
+	)
+	if code == 0 {
+		t.Fatalf("click-ref on a removed node should fail\nstdout=%s", stdout)
+	}
+	if !strings.Contains(stderr, "stale ref "+deleteRef) || strings.Contains(stderr, "likely replacement") {
+		t.Fatalf("stale ref error for a removed node\nstderr=%s", stderr)
+	}
+}
+
+func TestCLIFrameAndShadowRefs(t *testing.T) {
+	profile := "e2e-frame-refs"
+	env := newE2EEnv(t)
+	bin := buildCLIForE2E(t)
+	pageURL := startFramesFixtureServer(t)
+
+	t.Cleanup(func() {
+		_, _ = runCLICommand(t, env, 15*time.Second, bin, "--profile", profile, "stop")
+	})
+
+	runCLIJSON(t, env, 45*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"goto", pageURL,
+	)
+	runCLIJSON(t, env, 20*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"wait", "--strategy", "perf", "--state", "load", "--min-wait-ms", "250",
+	)
+
+	snapshotRes := runCLIJSON(t, env, 20*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"snapshot", "--format", "list", "--max-chars", "4000",
+	)
+	items := snapshotItems(snapshotRes["items"])
+	cardRef := findSnapshotRef(items, "textbox", "Card number")
+	payRef := findSnapshotRef(items, "button", "Pay now")
+	shadowRef := findSnapshotRef(items, "button", "Shadow action")
+	if !strings.Contains(cardRef, ":") || !strings.Contains(payRef, ":") || shadowRef == "" || strings.Contains(shadowRef, ":") {
+		t.Fatalf("snapshot missing frame or shadow refs (card=%q pay=%q shadow=%q): %#v", cardRef, payRef, shadowRef, snapshotRes)
+	}
+	if !strings.Contains(asString(snapshotRes["snapshot"]), "- iframe") {
+		t.Fatalf("snapshot missing iframe sections:\n%s", asString(snapshotRes["snapshot"]))
+	}
+
+	// Same-origin frame: fill and inspect.
+	fillRes := runCLIJSON(t, env, 20*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"fill-ref", cardRef, "4242",
+	)
+	if filled, ok := fillRes["filled"].(bool); !ok || !filled {
+		t.Fatalf("fill-ref in same-origin frame failed: %#v", fillRes)
+	}
+	value := runCLIJSON(t, env, 15*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"js-eval", `document.getElementById("same").contentDocument.querySelector("input").value`,
+	)
+	if got := asString(value["result"]); got != "4242" {
+		t.Fatalf("same-origin frame input = %q, want %q", got, "4242")
+	}
+	inspectRes := runCLIJSON(t, env, 20*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"inspect-ref", "--ref", cardRef,
+	)
+	if got := strings.TrimSpace(asString(inspectRes["role"])); got != "textbox" {
+		t.Fatalf("inspect-ref role in frame = %q, want textbox: %#v", got, inspectRes)
+	}
+
+	// Cross-origin frame: click, then check the frame's own snapshot.
+	clickRes := runCLIJSON(t, env, 20*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"click-ref", payRef,
+	)
+	if clicked, ok := clickRes["clicked"].(bool); !ok || !clicked {
+		t.Fatalf("click-ref in cross-origin frame failed: %#v", clickRes)
+	}
+	payFrame := strings.SplitN(payRef, ":", 2)[0]
+	frameSnap := runCLIJSON(t, env, 20*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"snapshot", "--within", payFrame,
+	)
+	if findSnapshotRef(snapshotItems(frameSnap["items"]), "button", "Paid") == "" {
+		t.Fatalf("cross-origin click had no effect: %#v", frameSnap)
+	}
+
+	// Open shadow root in the top document.
+	runCLIJSON(t, env, 20*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"click-ref", shadowRef,
+	)
+	out := runCLIJSON(t, env, 15*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"js-eval", `document.getElementById("host").shadowRoot.getElementById("out").textContent`,
+	)
+	if got := asString(out["result"]); got != "shadow clicked" {
+		t.Fatalf("shadow root click result = %q, want %q", got, "shadow clicked")
+	}
+
+	// A frame that navigates drops its id, so old refs fail instead of
+	// hitting the new document.
+	runCLIJSON(t, env, 15*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"js-eval", `new Promise((resolve) => { const f = document.getElementById("same"); f.onload = () => resolve(true); f.src = "/frame-next"; })`,
+	)
+	cardFrame := strings.SplitN(cardRef, ":", 2)[0]
+	stdout, stderr, code := runCLICommandAllowExit(t, env, 20*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"click-ref", cardRef,
+	)
+	if code == 0 {
+		t.Fatalf("click-ref into a navigated frame should fail\nstdout=%s", stdout)
+	}
+	if !strings.Contains(stderr, "frame "+cardFrame+" not found") {
+		t.Fatalf("navigated frame error\nstderr=%s", stderr)
+	}
+}
+
+func TestCLIScopedSnapshots(t *testing.T) {
+	profile := "e2e-scoped-snapshot"
+	env := newE2EEnv(t)
+	bin := buildCLIForE2E(t)
+	pageURL := startScopedSnapshotFixtureServer(t)
+
+	t.Cleanup(func() {
+		_, _ = runCLICommand(t, env, 15*time.Second, bin, "--profile", profile, "stop")
+	})
+
+	runCLIJSON(t, env, 45*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"goto", pageURL,
+	)
+
+	fullRes := runCLIJSON(t, env, 20*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"snapshot", "--no-interactive-only",
+	)
+	dialogRef := findSnapshotRef(snapshotItems(fullRes["items"]), "dialog", "Settings")
+	if dialogRef == "" {
+		t.Fatalf("snapshot missing dialog ref: %#v", fullRes)
+	}
+
+	// A ref scope lists the dialog's buttons and nothing outside it.
+	refRes := runCLIJSON(t, env, 20*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"snapshot", "--within", dialogRef,
+	)
+	items := snapshotItems(refRes["items"])
+	if findSnapshotRef(items, "button", "Apply") == "" || findSnapshotRef(items, "button", "Deep") == "" {
+		t.Fatalf("--within %s missing dialog buttons: %#v", dialogRef, refRes)
+	}
+	if findSnapshotRef(items, "button", "Outside") != "" {
+		t.Fatalf("--within %s listed a button outside the dialog: %#v", dialogRef, refRes)
+	}
+
+	// With simple, each wrapper div is a level: Deep sits three below the
+	// dialog and is cut at --max-depth 2.
+	selectorRes := runCLIJSON(t, env, 20*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"snapshot", "--within", "#panel", "--max-depth", "2",
+	)
+	items = snapshotItems(selectorRes["items"])
+	if findSnapshotRef(items, "button", "Apply") == "" {
+		t.Fatalf("--within #panel --max-depth 2 missing Apply: %#v", selectorRes)
+	}
+	if findSnapshotRef(items, "button", "Deep") != "" || findSnapshotRef(items, "button", "Outside") != "" {
+		t.Fatalf("--within #panel --max-depth 2 listed too much: %#v", selectorRes)
+	}
+	if !strings.Contains(asString(selectorRes["snapshot"]), "deeper elements omitted (max_depth=2)") {
+		t.Fatalf("--max-depth cut should be reported: %#v", selectorRes)
+	}
+
+	// aria folds the wrappers away, so Deep is one level below the dialog.
+	ariaRes := runCLIJSON(t, env, 20*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"snapshot", "--engine", "aria", "--within", "#panel", "--max-depth", "1",
+	)
+	if findSnapshotRef(snapshotItems(ariaRes["items"]), "button", "Deep") == "" {
+		t.Fatalf("aria --max-depth 1 should reach Deep: %#v", ariaRes)
+	}
+
+	stdout, stderr, code := runCLICommandAllowExit(t, env, 20*time.Second, bin,
+		"--profile", profile,
+		"--output", "json",
+		"snapshot", "--within", "#missing",
+	)
+	if code == 0 {
+		t.Fatalf("--within a missing selector should fail\nstdout=%s", stdout)
+	}
+	if !strings.Contains(stderr, `within: "#missing" matched no element`) {
+		t.Fatalf("missing selector error\nstderr=%s", stderr)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	devbrowser "github.com/joshp123/dev-browser-go/internal/devbrowser"
+	"github.com/spf13/cobra"
+)
+
+func newEventsCmd() *cobra.Command {
+	var pageName string
+	var kinds string
+	var follow bool
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Show a page's console, page error, navigation, network and overlay events (--follow to tail live)",
+		Args:  cobra.NoArgs,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if _, err := devbrowser.ParseEventKinds(kinds); err != nil {
+				return err
+			}
+			if limit < 0 {
+				return errors.New("--limit must be >= 0")
+			}
+			if follow && globalOpts.output != "summary" && globalOpts.output != "json" {
+				return errors.New("--follow supports --output summary or json")
+			}
+			return nil
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			base, err := startDaemonIfNeeded()
+			if err != nil {
+				return err
+			}
+			query := url.Values{}
+			if strings.TrimSpace(kinds) != "" {
+				query.Set("kinds", kinds)
+			}
+			if !follow {
+				query.Set("limit", strconv.Itoa(limit))
+				data, err := devbrowser.HTTPJSON("GET", pageEndpoint(base, pageName, "events", query), nil, 5*time.Second)
+				if err != nil {
+					return err
+				}
+				if ok, _ := data["ok"].(bool); !ok {
+					return fmt.Errorf("events failed: %v", data["error"])
+				}
+				return printOutput(map[string]any{"page": pageName, "events": data["events"]})
+			}
+
+			query.Set("follow", "1")
+			return devbrowser.StreamJSON(pageEndpoint(base, pageName, "events", query), func(ev map[string]any) error {
+				if globalOpts.output == "json" {
+					line, err := json.Marshal(ev)
+					if err != nil {
+						return err
+					}
+					fmt.Println(string(line))
+				} else {
+					fmt.Println(formatEventLine(ev))
+				}
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&pageName, "page", "main", "Page name")
+	cmd.Flags().StringVar(&kinds, "kinds", "", "Comma-separated kinds: console,pageerror,navigation,network,overlay (default all)")
+	cmd.Flags().BoolVar(&follow, "follow", false, "Stream live events until the page closes (Ctrl-C to stop)")
+	cmd.Flags().IntVar(&limit, "limit", 200, "Max buffered events without --follow")
+
+	return cmd
+}
+
+// formatEventLine renders one streamed event for --output summary.
+func formatEventLine(ev map[string]any) string {
+	kind, _ := ev["kind"].(string)
+	data, _ := ev["data"].(map[string]any)
+	str := func(key string) string {
+		v, _ := data[key].(string)
+		return v
+	}
+	stamp := ""
+	if ms, ok := ev["time_ms"].(float64); ok && ms > 0 {
+		stamp = time.UnixMilli(int64(ms)).Format("15:04:05.000") + " "
+	}
+
+	var detail string
+	switch kind {
+	case devbrowser.EventConsole:
+		detail = fmt.Sprintf("[%s] %s", str("type"), str("text"))
+	case devbrowser.EventPageError:
+		detail = str("text")
+	case devbrowser.EventNavigation:
+		detail = str("url")
+	case devbrowser.EventNetwork:
+		status := fmt.Sprint(data["status"])
+		if e := str("error"); e != "" {
+			status = e
+		}
+		dur, _ := data["duration_ms"].(float64)
+		detail = fmt.Sprintf("%s %s %s (%.0fms)", str("method"), status, str("url"), dur)
+	case devbrowser.EventOverlay:
+		text := strings.TrimSpace(str("text"))
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			text = text[:i]
+		}
+		detail = strings.TrimSpace(str("type") + " " + text)
+	case devbrowser.EventCrash:
+		detail = fmt.Sprintf("%s crashed %s", str("crash"), str("url"))
+	case "dropped":
+		detail = fmt.Sprintf("%v events dropped (reader too slow)", data["count"])
+	case "closed":
+		detail = "page closed"
+	default:
+		enc, _ := json.Marshal(data)
+		detail = string(enc)
+	}
+	return fmt.Sprintf("%s%-10s %s", stamp, kind, detail)
+}
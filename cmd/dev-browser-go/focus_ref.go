@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newFocusRefCmd() *cobra.Command {
+	var pageName string
+
+	cmd := &cobra.Command{
+		Use:   "focus-ref <ref>",
+		Short: "Focus element by ref",
+		Args:  requireArgs(1, "ref required"),
+		RunE: func(_ *cobra.Command, args []string) error {
+			payload := map[string]interface{}{"ref": args[0]}
+			return runWithPage(pageName, "focus_ref", payload)
+		},
+	}
+
+	cmd.Flags().StringVar(&pageName, "page", "main", "Page name")
+
+	return cmd
+}
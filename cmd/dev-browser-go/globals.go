@@ -2,9 +2,11 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	devbrowser "github.com/joshp123/dev-browser-go/internal/devbrowser"
 	"github.com/spf13/cobra"
@@ -23,6 +25,16 @@ type globalOptions struct {
 	device      string
 	windowSet   bool
 	deviceSet   bool
+	context     string
+	contextName string
+	browser     string
+	browserSet  bool
+	attach      bool
+
+	cdpEndpoint    string
+	cdpEndpointSet bool
+	waitTimeout    time.Duration
+	noAutoRestart  bool
 }
 
 var globalOpts = &globalOptions{}
@@ -34,6 +46,12 @@ func bindGlobalFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringVar(&globalOpts.windowSize, "window-size", getenvDefault("DEV_BROWSER_WINDOW_SIZE", ""), "Viewport WxH")
 	cmd.PersistentFlags().Float64Var(&globalOpts.windowScale, "window-scale", 1.0, "Viewport scale (1, 0.75, 0.5)")
 	cmd.PersistentFlags().StringVar(&globalOpts.device, "device", "", "Device profile name (Playwright)")
+	cmd.PersistentFlags().StringVar(&globalOpts.browser, "browser", getenvDefault("DEV_BROWSER_BROWSER", ""), "Browser engine (chromium|firefox|webkit; default chromium)")
+	cmd.PersistentFlags().StringVar(&globalOpts.context, "context", getenvDefault("DEV_BROWSER_CONTEXT", ""), "Named isolated browser context inside the profile (default: persistent context)")
+	cmd.PersistentFlags().StringVar(&globalOpts.cdpEndpoint, "cdp-endpoint", getenvDefault("DEV_BROWSER_CDP_ENDPOINT", ""), "Drive an existing Chromium (ws://host:9222/devtools/browser/... or http://host:9222) instead of launching one; \"\" to launch again")
+	cmd.PersistentFlags().BoolVar(&globalOpts.attach, "attach", envTruthy("DEV_BROWSER_ATTACH"), "Run tools in the CLI over CDP instead of inside the daemon (chromium only; slower)")
+	cmd.PersistentFlags().DurationVar(&globalOpts.waitTimeout, "wait-timeout", 30*time.Second, "How long a page command waits while another command holds the page (e.g. 10s; 0 = fail at once; env "+devbrowser.EnvWaitTimeout+")")
+	cmd.PersistentFlags().BoolVar(&globalOpts.noAutoRestart, "no-auto-restart", envTruthy(devbrowser.EnvNoAutoRestart), "Keep using a daemon started by another build instead of restarting it")
 	cmd.PersistentFlags().StringVar(&globalOpts.output, "output", "summary", "Output format (summary|json|html|path)")
 	cmd.PersistentFlags().StringVar(&globalOpts.outPath, "out", "", "Output path when --output=path")
 }
@@ -48,12 +66,47 @@ func applyGlobalOptions(cmd *cobra.Command) error {
 	if err := resolveWindow(cmd); err != nil {
 		return err
 	}
+	if err := resolveBrowser(cmd); err != nil {
+		return err
+	}
+	if err := resolveCDPEndpoint(cmd); err != nil {
+		return err
+	}
+	contextName, err := devbrowser.NormalizeContextName(globalOpts.context)
+	if err != nil {
+		return err
+	}
+	globalOpts.contextName = contextName
+	if err := resolveWaitTimeout(cmd); err != nil {
+		return err
+	}
+	if flagChanged(cmd, "no-auto-restart") {
+		// EnsureDaemon reads the setting from the environment.
+		_ = os.Setenv(devbrowser.EnvNoAutoRestart, strconv.FormatBool(globalOpts.noAutoRestart))
+	}
 	if globalOpts.output != "summary" && globalOpts.output != "json" && globalOpts.output != "html" && globalOpts.output != "path" {
 		return errors.New("--output must be summary|json|html|path")
 	}
 	return nil
 }
 
+// resolveWaitTimeout applies DEV_BROWSER_WAIT_TIMEOUT unless --wait-timeout
+// was given. Unlike the idle timeout, a bare number is rejected: "10" would
+// read as minutes there and as seconds to most people here.
+func resolveWaitTimeout(cmd *cobra.Command) error {
+	if v := strings.TrimSpace(os.Getenv(devbrowser.EnvWaitTimeout)); v != "" && !flagChanged(cmd, "wait-timeout") {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("%s=%q: want a duration like 10s or 2m", devbrowser.EnvWaitTimeout, v)
+		}
+		globalOpts.waitTimeout = d
+	}
+	if globalOpts.waitTimeout < 0 {
+		return errors.New("--wait-timeout must be >= 0")
+	}
+	return nil
+}
+
 func resolveHeadless(cmd *cobra.Command) error {
 	headlessChanged := flagChanged(cmd, "headless")
 	headedChanged := flagChanged(cmd, "headed")
@@ -97,6 +150,26 @@ func resolveWindow(cmd *cobra.Command) error {
 	return nil
 }
 
+func resolveBrowser(cmd *cobra.Command) error {
+	globalOpts.browserSet = flagChanged(cmd, "browser") || strings.TrimSpace(os.Getenv("DEV_BROWSER_BROWSER")) != ""
+	browser, err := devbrowser.NormalizeBrowserEngine(globalOpts.browser)
+	if err != nil {
+		return err
+	}
+	globalOpts.browser = browser
+	return nil
+}
+
+func resolveCDPEndpoint(cmd *cobra.Command) error {
+	globalOpts.cdpEndpointSet = flagChanged(cmd, "cdp-endpoint") || strings.TrimSpace(os.Getenv("DEV_BROWSER_CDP_ENDPOINT")) != ""
+	endpoint, err := devbrowser.NormalizeCDPEndpoint(globalOpts.cdpEndpoint)
+	if err != nil {
+		return err
+	}
+	globalOpts.cdpEndpoint = endpoint
+	return nil
+}
+
 func resolveDevice(cmd *cobra.Command) error {
 	globalOpts.device = strings.TrimSpace(globalOpts.device)
 	globalOpts.deviceSet = flagChanged(cmd, "device")
@@ -151,6 +224,23 @@ func getenvInt(name string, def int) int {
 	return n
 }
 
+// getenvDuration reads a Go duration ("30m", "1h30m"); a bare number means
+// minutes.
+func getenvDuration(name string, def time.Duration) time.Duration {
+	v := strings.TrimSpace(os.Getenv(name))
+	if v == "" {
+		return def
+	}
+	if n, err := strconv.Atoi(v); err == nil {
+		return time.Duration(n) * time.Minute
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return def
+	}
+	return d
+}
+
 func requireArgs(count int, errMsg string) cobra.PositionalArgs {
 	return func(_ *cobra.Command, args []string) error {
 		if len(args) != count {
@@ -160,6 +250,15 @@ func requireArgs(count int, errMsg string) cobra.PositionalArgs {
 	}
 }
 
+func minArgs(min int, errMsg string) cobra.PositionalArgs {
+	return func(_ *cobra.Command, args []string) error {
+		if len(args) < min {
+			return errors.New(errMsg)
+		}
+		return nil
+	}
+}
+
 func maxArgs(max int, errMsg string) cobra.PositionalArgs {
 	return func(_ *cobra.Command, args []string) error {
 		if len(args) > max {
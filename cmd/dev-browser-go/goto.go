@@ -8,12 +8,17 @@ func newGotoCmd() *cobra.Command {
 	var pageName string
 	var waitUntil string
 	var timeout int
+	var replayHAR string
+	var harNotFound string
 
 	cmd := &cobra.Command{
 		Use:   "goto <url>",
 		Short: "Navigate to URL",
 		Args:  requireArgs(1, "url required"),
 		RunE: func(_ *cobra.Command, args []string) error {
+			if _, err := applyHARReplay(replayHAR, harNotFound); err != nil {
+				return err
+			}
 			payload := map[string]interface{}{
 				"url":        args[0],
 				"wait_until": waitUntil,
@@ -26,6 +31,7 @@ func newGotoCmd() *cobra.Command {
 	cmd.Flags().StringVar(&pageName, "page", "main", "Page name")
 	cmd.Flags().StringVar(&waitUntil, "wait-until", "domcontentloaded", "Wait strategy")
 	cmd.Flags().IntVar(&timeout, "timeout-ms", 45_000, "Timeout ms")
+	addHARReplayFlags(cmd, &replayHAR, &harNotFound)
 
 	return cmd
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	devbrowser "github.com/joshp123/dev-browser-go/internal/devbrowser"
+	"github.com/spf13/cobra"
+)
+
+func newHARCmd() *cobra.Command {
+	var path string
+
+	cmd := &cobra.Command{
+		Use:   "har <record|stop|status|clear-replay>",
+		Short: "Record profile network traffic to a HAR 1.2 file (replay via --replay-har on goto/diagnose)",
+		Args: func(_ *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("har action required (record|stop|status|clear-replay)")
+			}
+			switch args[0] {
+			case "record", "stop", "status", "clear-replay":
+				return nil
+			default:
+				return fmt.Errorf("unknown har action %q (expected record|stop|status|clear-replay)", args[0])
+			}
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			switch args[0] {
+			case "record":
+				resolved, err := devbrowser.SafeArtifactPath(devbrowser.ArtifactDir(globalOpts.profile), path, fmt.Sprintf("session-%d.har", devbrowser.NowMS()))
+				if err != nil {
+					return err
+				}
+				data, err := daemonCall("har record", http.MethodPost, "/har/record", map[string]any{"path": resolved}, 5*time.Second)
+				if err != nil {
+					return err
+				}
+				return printOutput(map[string]any{"har": data["har"]})
+			case "stop":
+				data, err := daemonCall("har stop", http.MethodPost, "/har/stop", nil, 30*time.Second)
+				if err != nil {
+					return err
+				}
+				return printOutput(map[string]any{"har": data["har"]})
+			case "clear-replay":
+				data, err := daemonCall("har clear-replay", http.MethodDelete, "/har/replay", nil, 10*time.Second)
+				if err != nil {
+					return err
+				}
+				return printOutput(map[string]any{"cleared": data["cleared"]})
+			default:
+				data, err := daemonCall("har status", http.MethodGet, "/har", nil, 5*time.Second)
+				if err != nil {
+					return err
+				}
+				return printOutput(map[string]any{"har": data["har"]})
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&path, "path", "", "record: output HAR path (relative to artifact dir; default session-<ts>.har)")
+
+	return cmd
+}
+
+// applyHARReplay makes the daemon serve responses from a HAR file for the
+// whole profile until `har clear-replay`.
+func applyHARReplay(pathArg, notFound string) (string, error) {
+	if strings.TrimSpace(pathArg) == "" {
+		return "", nil
+	}
+	switch notFound {
+	case devbrowser.HARNotFoundAbort, devbrowser.HARNotFoundFallback:
+	default:
+		return "", fmt.Errorf("--har-not-found must be %s|%s", devbrowser.HARNotFoundAbort, devbrowser.HARNotFoundFallback)
+	}
+	resolved, err := devbrowser.ResolveArtifactReadPath(globalOpts.profile, pathArg)
+	if err != nil {
+		return "", err
+	}
+	if _, err := daemonCall("har replay", http.MethodPost, "/har/replay", map[string]any{"path": resolved, "not_found": notFound}, 15*time.Second); err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
+func addHARReplayFlags(cmd *cobra.Command, path, notFound *string) {
+	cmd.Flags().StringVar(path, "replay-har", "", "Serve network responses from a HAR file (persists until `har clear-replay`)")
+	cmd.Flags().StringVar(notFound, "har-not-found", devbrowser.HARNotFoundAbort, "Unmatched requests during HAR replay: abort (strict) or fallback (network)")
+}
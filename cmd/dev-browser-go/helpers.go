@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
@@ -13,18 +14,131 @@ import (
 )
 
 func runWithPage(pageName, tool string, args map[string]interface{}) error {
-	pw, browser, page, err := openNamedPage(pageName)
+	res, err := runToolOnPage(pageName, tool, args)
 	if err != nil {
 		return err
 	}
-	defer browser.Close()
-	defer pw.Stop()
+	return printOutput(res)
+}
 
-	res, err := devbrowser.RunCall(page, tool, args, devbrowser.ArtifactDir(globalOpts.profile))
+func runToolOnPage(pageName, tool string, args map[string]interface{}) (devbrowser.RunResult, error) {
+	body := map[string]any{"tool": tool, "args": args}
+	if !globalOpts.attach {
+		if _, err := startDaemonIfNeeded(); err != nil {
+			return nil, err
+		}
+		return toolResultFromCall(pageCall(pageName, body))
+	}
+	sessionInfo, err := ensurePageInfoForCommand(pageName)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if !devbrowser.BrowserSupportsCDP(sessionInfo.Browser) {
+		return toolResultFromCall(pageCall(pageName, body))
+	}
+	if health, err := devbrowser.ReadDaemonHealth(globalOpts.profile); err == nil && health != nil {
+		if err := devbrowser.CheckExternalContext(health.Context, globalOpts.contextName, tool); err != nil {
+			return nil, err
+		}
+	}
+	attached, err := attachPage(pageName, tool, sessionInfo)
+	if err != nil {
+		return nil, err
+	}
+	defer attached.Close()
+
+	return devbrowser.RunCall(attached.page, tool, args, devbrowser.ArtifactDir(globalOpts.profile))
+}
+
+func runActionsOnPage(pageName string, calls []map[string]interface{}) (devbrowser.ActionsResult, error) {
+	if calls == nil {
+		calls = []map[string]interface{}{}
+	}
+	body := map[string]any{"actions": calls}
+	if !globalOpts.attach {
+		if _, err := startDaemonIfNeeded(); err != nil {
+			return devbrowser.ActionsResult{}, err
+		}
+		return actionsResultFromCall(pageCall(pageName, body))
 	}
-	out, err := devbrowser.WriteOutput(globalOpts.profile, globalOpts.output, res, globalOpts.outPath)
+	sessionInfo, err := ensurePageInfoForCommand(pageName)
+	if err != nil {
+		return devbrowser.ActionsResult{}, err
+	}
+	if !devbrowser.BrowserSupportsCDP(sessionInfo.Browser) {
+		return actionsResultFromCall(pageCall(pageName, body))
+	}
+	if health, err := devbrowser.ReadDaemonHealth(globalOpts.profile); err == nil && health != nil {
+		for _, call := range calls {
+			tool, _ := call["name"].(string)
+			if err := devbrowser.CheckExternalContext(health.Context, globalOpts.contextName, tool); err != nil {
+				return devbrowser.ActionsResult{}, err
+			}
+		}
+	}
+	attached, err := attachPage(pageName, "actions", sessionInfo)
+	if err != nil {
+		return devbrowser.ActionsResult{}, err
+	}
+	defer attached.Close()
+
+	return devbrowser.RunActions(attached.page, calls, devbrowser.ArtifactDir(globalOpts.profile))
+}
+
+// pageCall runs a tool or batch on the daemon's own page handle, creating the
+// page if needed. This is the default path; --attach re-attaches over CDP
+// instead, and engines without CDP (firefox, webkit) always use it. The daemon
+// runs calls on one page one at a time; --wait-timeout bounds the wait.
+func pageCall(pageName string, body map[string]any) (map[string]any, error) {
+	base := devbrowser.DaemonBaseURL(globalOpts.profile)
+	if base == "" {
+		return nil, errors.New("daemon state missing after start")
+	}
+	body["wait_timeout_ms"] = globalOpts.waitTimeout.Milliseconds()
+	data, err := devbrowser.HTTPJSON("POST", pageEndpoint(base, pageName, "call", nil), body, 10*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if ok, _ := data["ok"].(bool); !ok {
+		return nil, fmt.Errorf("%v", data["error"])
+	}
+	return data, nil
+}
+
+func toolResultFromCall(data map[string]any, err error) (devbrowser.RunResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	res := devbrowser.RunResult{}
+	if raw, ok := data["result"].(map[string]any); ok {
+		res = devbrowser.RunResult(raw)
+	}
+	return res, nil
+}
+
+func actionsResultFromCall(data map[string]any, err error) (devbrowser.ActionsResult, error) {
+	if err != nil {
+		return devbrowser.ActionsResult{}, err
+	}
+	res := devbrowser.ActionsResult{}
+	if err := remarshal(data["results"], &res.Results); err != nil {
+		return devbrowser.ActionsResult{}, err
+	}
+	res.Snapshot, _ = data["snapshot"].(string)
+	return res, nil
+}
+
+// daemonBrowser reports the running daemon's engine (chromium when unknown).
+func daemonBrowser() string {
+	health, err := devbrowser.ReadDaemonHealth(globalOpts.profile)
+	if err != nil || health == nil || strings.TrimSpace(health.Context.Browser) == "" {
+		return devbrowser.BrowserChromium
+	}
+	return health.Context.Browser
+}
+
+func printOutput(result any) error {
+	out, err := devbrowser.WriteOutput(globalOpts.profile, globalOpts.output, result, globalOpts.outPath)
 	if err != nil {
 		return err
 	}
@@ -32,21 +146,73 @@ func runWithPage(pageName, tool string, args map[string]interface{}) error {
 	return nil
 }
 
-func openNamedPage(pageName string) (*playwright.Playwright, playwright.Browser, playwright.Page, error) {
+// daemonCall starts the daemon if needed and issues a JSON request against it,
+// turning `ok: false` responses into errors prefixed with label.
+func daemonCall(label, method, path string, body map[string]any, timeout time.Duration) (map[string]any, error) {
+	base, err := startDaemonIfNeeded()
+	if err != nil {
+		return nil, err
+	}
+	data, err := devbrowser.HTTPJSON(method, base+path, body, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if ok, _ := data["ok"].(bool); !ok {
+		return nil, fmt.Errorf("%s failed: %v", label, data["error"])
+	}
+	return data, nil
+}
+
+// openNamedPage attaches to the daemon's page over CDP for commands that drive
+// it directly; op names the command in the page queue.
+func openNamedPage(pageName, op string) (*attachedPage, error) {
 	sessionInfo, err := ensurePageInfoForCommand(pageName)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
+	}
+	if !devbrowser.BrowserSupportsCDP(sessionInfo.Browser) {
+		return nil, fmt.Errorf("this command attaches to the page over CDP, which --browser %s does not provide; use chromium, or drive the page with call/actions", sessionInfo.Browser)
+	}
+	return attachPage(pageName, op, sessionInfo)
+}
+
+// attachedPage is a daemon page attached over CDP. It holds the page's turn
+// in the daemon's page queue until Close, so daemon calls on the same page
+// wait for it and it waits for them.
+type attachedPage struct {
+	pw      *playwright.Playwright
+	browser playwright.Browser
+	page    playwright.Page
+	release func()
+}
+
+func (a *attachedPage) Close() {
+	_ = a.browser.Close()
+	_ = a.pw.Stop()
+	a.release()
+}
+
+func attachPage(pageName, op string, sessionInfo devbrowser.PageSessionInfo) (*attachedPage, error) {
+	base := devbrowser.DaemonBaseURL(globalOpts.profile)
+	if base == "" {
+		return nil, errors.New("daemon state missing after start")
+	}
+	release, err := devbrowser.HoldPage(pageEndpoint(base, pageName, "hold", nil), op, globalOpts.waitTimeout)
+	if err != nil {
+		return nil, err
 	}
 	pw, browser, page, err := devbrowser.OpenPage(sessionInfo.WSEndpoint, sessionInfo.TargetID)
 	if err != nil {
-		return nil, nil, nil, err
+		release()
+		return nil, err
 	}
 	if err := verifyReopenedPage(pageName, sessionInfo, page); err != nil {
 		_ = browser.Close()
 		_ = pw.Stop()
-		return nil, nil, nil, err
+		release()
+		return nil, err
 	}
-	return pw, browser, page, nil
+	return &attachedPage{pw: pw, browser: browser, page: page, release: release}, nil
 }
 
 func verifyReopenedPage(pageName string, expected devbrowser.PageSessionInfo, page playwright.Page) error {
@@ -75,20 +241,28 @@ func ensurePageInfoForCommand(pageName string) (devbrowser.PageSessionInfo, erro
 	if base == "" {
 		return devbrowser.PageSessionInfo{}, errors.New("daemon state missing after start")
 	}
-	data, err := devbrowser.HTTPJSON("POST", base+"/pages", map[string]any{"name": pageName}, 10*time.Second)
+	body := map[string]any{"name": pageName}
+	if globalOpts.contextName != "" {
+		body["context"] = globalOpts.contextName
+	}
+	data, err := devbrowser.HTTPJSON("POST", base+"/pages", body, 10*time.Second)
 	if err != nil {
 		return devbrowser.PageSessionInfo{}, err
 	}
 	ws, _ := data["wsEndpoint"].(string)
 	tid, _ := data["targetId"].(string)
-	if strings.TrimSpace(ws) == "" {
-		return devbrowser.PageSessionInfo{}, errors.New("daemon did not return wsEndpoint")
-	}
-	if strings.TrimSpace(tid) == "" {
-		return devbrowser.PageSessionInfo{}, errors.New("daemon did not return targetId")
+	engine, _ := data["browser"].(string)
+	if devbrowser.BrowserSupportsCDP(engine) {
+		if strings.TrimSpace(ws) == "" {
+			return devbrowser.PageSessionInfo{}, errors.New("daemon did not return wsEndpoint")
+		}
+		if strings.TrimSpace(tid) == "" {
+			return devbrowser.PageSessionInfo{}, errors.New("daemon did not return targetId")
+		}
 	}
 	info := devbrowser.PageSessionInfo{
 		WSEndpoint: ws,
+		Browser:    engine,
 		PageIdentity: devbrowser.PageIdentity{
 			TargetID: tid,
 		},
@@ -131,47 +305,133 @@ func startDaemonIfNeeded() (string, error) {
 }
 
 func ensureDaemonForCommand() (devbrowser.DaemonStartResult, error) {
-	headless, window, device, err := desiredDaemonSettings()
+	want, err := desiredDaemonSettings()
 	if err != nil {
 		return devbrowser.DaemonStartResult{}, err
 	}
-	result, err := devbrowser.EnsureDaemon(globalOpts.profile, headless, window, device)
+	result, err := devbrowser.EnsureDaemon(globalOpts.profile, want.Browser, want.Headless, want.Window, want.Device, want.CDPEndpoint)
 	if err != nil {
 		return devbrowser.DaemonStartResult{}, err
 	}
 	announceDaemonAction(result)
+	if err := ensureNamedContext(result.BaseURL); err != nil {
+		return devbrowser.DaemonStartResult{}, err
+	}
 	return result, nil
 }
 
-func desiredDaemonSettings() (bool, *devbrowser.WindowSize, string, error) {
+// ensureNamedContext creates the --context context on first use. --device and
+// --window-size/--window-scale then configure that context instead of the
+// profile; without them an existing context is reused as-is.
+func ensureNamedContext(base string) error {
+	name := globalOpts.contextName
+	if name == "" {
+		return nil
+	}
+	if strings.TrimSpace(base) == "" {
+		base = devbrowser.DaemonBaseURL(globalOpts.profile)
+	}
+	apply := globalOpts.deviceSet || globalOpts.windowSet
+	var window *devbrowser.WindowSize
+	if globalOpts.windowSet {
+		window = cloneCLIWindow(globalOpts.window)
+	}
+	result, err := devbrowser.EnsureContext(base, name, window, globalOpts.device, apply)
+	if err != nil {
+		return err
+	}
+	switch result.Action {
+	case devbrowser.DaemonActionStarted:
+		fmt.Fprintf(os.Stderr, "context %s started with %s\n", name, contextSummary(result.Context.Settings))
+	case devbrowser.DaemonActionReconfigured:
+		fmt.Fprintf(os.Stderr, "context %s recreated to apply %s\n", name, result.Reason)
+	}
+	return nil
+}
+
+// contextQuery returns "?context=<name>" for a named --context, else "".
+func contextQuery() string {
+	if globalOpts.contextName == "" {
+		return ""
+	}
+	return "?" + url.Values{"context": {globalOpts.contextName}}.Encode()
+}
+
+// pageEndpoint builds a /pages/{name}[/sub] URL scoped to --context.
+func pageEndpoint(base, pageName, sub string, query url.Values) string {
+	endpoint := base + "/pages/" + url.PathEscape(pageName)
+	if sub != "" {
+		endpoint += "/" + sub
+	}
+	if query == nil {
+		query = url.Values{}
+	}
+	if globalOpts.contextName != "" {
+		query.Set("context", globalOpts.contextName)
+	}
+	if encoded := query.Encode(); encoded != "" {
+		endpoint += "?" + encoded
+	}
+	return endpoint
+}
+
+// desiredDaemonSettings merges the flags given on this invocation with the
+// running daemon's settings, so unset flags keep what the daemon already uses.
+func desiredDaemonSettings() (devbrowser.BrowserContextSettings, error) {
+	browser := globalOpts.browser
 	headless := globalOpts.headless
 	window := cloneCLIWindow(globalOpts.window)
 	device := strings.TrimSpace(globalOpts.device)
+	endpoint := globalOpts.cdpEndpoint
+	deviceSet, windowSet := globalOpts.deviceSet, globalOpts.windowSet
+	if globalOpts.contextName != "" {
+		// Emulation flags belong to the named context; leave the profile alone.
+		window, device = nil, ""
+		deviceSet, windowSet = false, false
+	}
 
 	health, err := devbrowser.ReadDaemonHealth(globalOpts.profile)
 	if err != nil {
-		return false, nil, "", err
-	}
-	if health == nil {
-		return headless, window, device, nil
-	}
-
-	if !globalOpts.headlessSet {
-		headless = health.Context.Headless
+		return devbrowser.BrowserContextSettings{}, err
 	}
-	if !globalOpts.deviceSet && !globalOpts.windowSet {
-		device = strings.TrimSpace(health.Context.Device)
-		if device != "" {
-			window = nil
-		} else {
-			window = cloneCLIWindow(health.Context.Window)
+	if health != nil {
+		if !globalOpts.browserSet && strings.TrimSpace(health.Context.Browser) != "" {
+			browser = health.Context.Browser
+		}
+		if !globalOpts.headlessSet {
+			headless = health.Context.Headless
+		}
+		if !deviceSet && !windowSet {
+			device = strings.TrimSpace(health.Context.Device)
+			if device != "" {
+				window = nil
+			} else {
+				window = cloneCLIWindow(health.Context.Window)
+			}
+		}
+		if !globalOpts.cdpEndpointSet {
+			endpoint = health.Context.CDPEndpoint
 		}
 	}
 
 	if window != nil && device != "" {
-		return false, nil, "", fmt.Errorf("use either --window-size/--window-scale or --device")
+		return devbrowser.BrowserContextSettings{}, fmt.Errorf("use either --window-size/--window-scale or --device")
 	}
-	return headless, window, device, nil
+	if endpoint != "" {
+		if browser != devbrowser.BrowserChromium {
+			return devbrowser.BrowserContextSettings{}, errors.New("--cdp-endpoint requires --browser chromium")
+		}
+		if deviceSet || windowSet {
+			return devbrowser.BrowserContextSettings{}, errors.New("--device/--window-size do not apply to --cdp-endpoint; use --context for emulation")
+		}
+	}
+	return devbrowser.BrowserContextSettings{
+		Browser:     browser,
+		Headless:    headless,
+		Device:      device,
+		Window:      window,
+		CDPEndpoint: endpoint,
+	}, nil
 }
 
 func announceDaemonAction(result devbrowser.DaemonStartResult) {
@@ -187,10 +447,20 @@ func announceDaemonAction(result devbrowser.DaemonStartResult) {
 		}
 		fmt.Fprintf(os.Stderr, "profile %s restarted to apply %s\n", globalOpts.profile, reason)
 	}
+	if result.Warning != "" {
+		fmt.Fprintf(os.Stderr, "warning: profile %s: %s\n", globalOpts.profile, result.Warning)
+	}
 }
 
 func contextSummary(settings devbrowser.BrowserContextSettings) string {
-	parts := []string{fmt.Sprintf("headless=%t", settings.Headless)}
+	if settings.CDPEndpoint != "" {
+		return "cdp-endpoint=" + settings.CDPEndpoint
+	}
+	parts := []string{}
+	if browser := strings.TrimSpace(settings.Browser); browser != "" && browser != devbrowser.BrowserChromium {
+		parts = append(parts, fmt.Sprintf("browser=%s", browser))
+	}
+	parts = append(parts, fmt.Sprintf("headless=%t", settings.Headless))
 	if device := strings.TrimSpace(settings.Device); device != "" {
 		parts = append(parts, fmt.Sprintf("device=%s", device))
 	}
@@ -216,8 +486,7 @@ func deletePage(name string) error {
 	if err != nil {
 		return err
 	}
-	encoded := url.PathEscape(name)
-	data, err := devbrowser.HTTPJSON("DELETE", base+"/pages/"+encoded, nil, 5*time.Second)
+	data, err := devbrowser.HTTPJSON("DELETE", pageEndpoint(base, name, "", nil), nil, 5*time.Second)
 	if err != nil {
 		return err
 	}
@@ -231,3 +500,12 @@ func deletePage(name string) error {
 	fmt.Println(out)
 	return nil
 }
+
+// remarshal converts between loosely typed daemon JSON and typed structs.
+func remarshal(in any, out any) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
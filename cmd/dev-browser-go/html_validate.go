@@ -21,12 +21,12 @@ func newHTMLValidateCmd() *cobra.Command {
 		Short: "Lite HTML validation (report-only)",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			pw, browser, page, err := openNamedPage(pageName)
+			attached, err := openNamedPage(pageName, "html_validate")
 			if err != nil {
 				return err
 			}
-			defer browser.Close()
-			defer pw.Stop()
+			defer attached.Close()
+			page := attached.page
 
 			if targetURL != "" {
 				if _, err := devbrowser.RunCall(page, "goto", map[string]interface{}{"url": targetURL, "timeout_ms": timeoutMs}, devbrowser.ArtifactDir(globalOpts.profile)); err != nil {
@@ -2,6 +2,7 @@ package main
 
 import (
 	"errors"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -48,7 +49,12 @@ func newInjectCmd() *cobra.Command {
 				payload["style"] = style
 			}
 			if strings.TrimSpace(file) != "" {
-				payload["file"] = file
+				// The daemon reads the file, so resolve it against our cwd.
+				abs, err := filepath.Abs(file)
+				if err != nil {
+					return err
+				}
+				payload["file"] = abs
 			}
 			return runWithPage(pageName, "inject", payload)
 		},
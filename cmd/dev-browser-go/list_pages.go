@@ -24,11 +24,18 @@ func newListPagesCmd() *cobra.Command {
 			if base == "" {
 				return fmt.Errorf("daemon state missing after start")
 			}
-			data, err := devbrowser.HTTPJSON("GET", base+"/pages", nil, 3*time.Second)
+			data, err := devbrowser.HTTPJSON("GET", base+"/pages"+contextQuery(), nil, 3*time.Second)
 			if err != nil {
 				return err
 			}
-			out, err := devbrowser.WriteOutput(globalOpts.profile, globalOpts.output, map[string]any{"pages": data["pages"]}, globalOpts.outPath)
+			if _, ok := data["pages"]; !ok {
+				return fmt.Errorf("list-pages failed: %v", data["error"])
+			}
+			out, err := devbrowser.WriteOutput(globalOpts.profile, globalOpts.output, map[string]any{
+				"pages":    data["pages"],
+				"context":  data["context"],
+				"contexts": data["contexts"],
+			}, globalOpts.outPath)
 			if err != nil {
 				return err
 			}
@@ -68,12 +68,12 @@ func newLoopCmd() *cobra.Command {
 					return devbrowser.AssertResult{}, devbrowser.DiagnoseSummary{}, "", "", err
 				}
 
-				pw, browser, page, err := openNamedPage(pageName)
+				attached, err := openNamedPage(pageName, "loop")
 				if err != nil {
 					return devbrowser.AssertResult{}, devbrowser.DiagnoseSummary{}, "", "", err
 				}
-				defer browser.Close()
-				defer pw.Stop()
+				defer attached.Close()
+				page := attached.page
 
 				ts := time.Now()
 				ctx := devbrowser.NewRunContext(devbrowser.RunOptions{Profile: globalOpts.profile, Timestamp: ts})
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"os"
 	"strings"
 	"testing"
+	"time"
 
+	devbrowser "github.com/joshp123/dev-browser-go/internal/devbrowser"
 	"github.com/spf13/cobra"
 )
 
@@ -92,3 +95,191 @@ func TestApplyGlobalOptionsAllowsHTMLOutput(t *testing.T) {
 		t.Fatalf("expected output to be html, got %q", globalOpts.output)
 	}
 }
+
+func TestApplyGlobalOptionsContextName(t *testing.T) {
+	cmd := newTestCmd()
+	if err := cmd.PersistentFlags().Set("context", "alice"); err != nil {
+		t.Fatalf("set context: %v", err)
+	}
+	if err := applyGlobalOptions(cmd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if globalOpts.contextName != "alice" {
+		t.Fatalf("expected context alice, got %q", globalOpts.contextName)
+	}
+	if got := contextQuery(); got != "?context=alice" {
+		t.Fatalf("unexpected context query %q", got)
+	}
+
+	cmd = newTestCmd()
+	if err := cmd.PersistentFlags().Set("context", "no/slash"); err != nil {
+		t.Fatalf("set context: %v", err)
+	}
+	if err := applyGlobalOptions(cmd); err == nil {
+		t.Fatalf("expected error for invalid context name")
+	}
+}
+
+func TestApplyGlobalOptionsBrowser(t *testing.T) {
+	t.Setenv("DEV_BROWSER_BROWSER", "")
+	cmd := newTestCmd()
+	if err := cmd.PersistentFlags().Set("browser", "Firefox"); err != nil {
+		t.Fatalf("set browser: %v", err)
+	}
+	if err := applyGlobalOptions(cmd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if globalOpts.browser != "firefox" || !globalOpts.browserSet {
+		t.Fatalf("expected firefox to be set, got %q set=%t", globalOpts.browser, globalOpts.browserSet)
+	}
+
+	cmd = newTestCmd()
+	if err := cmd.PersistentFlags().Set("browser", "edge"); err != nil {
+		t.Fatalf("set browser: %v", err)
+	}
+	if err := applyGlobalOptions(cmd); err == nil {
+		t.Fatalf("expected error for unknown browser")
+	}
+}
+
+func TestAttachDefaultsFromEnv(t *testing.T) {
+	t.Setenv("DEV_BROWSER_ATTACH", "")
+	newTestCmd()
+	if globalOpts.attach {
+		t.Fatal("expected tools to run in the daemon by default")
+	}
+	t.Setenv("DEV_BROWSER_ATTACH", "1")
+	newTestCmd()
+	if !globalOpts.attach {
+		t.Fatal("expected DEV_BROWSER_ATTACH=1 to enable --attach")
+	}
+}
+
+func TestGetenvDuration(t *testing.T) {
+	t.Setenv("DEV_BROWSER_TEST_DURATION", "")
+	if got := getenvDuration("DEV_BROWSER_TEST_DURATION", time.Minute); got != time.Minute {
+		t.Fatalf("expected default, got %s", got)
+	}
+	t.Setenv("DEV_BROWSER_TEST_DURATION", "45")
+	if got := getenvDuration("DEV_BROWSER_TEST_DURATION", 0); got != 45*time.Minute {
+		t.Fatalf("expected bare number as minutes, got %s", got)
+	}
+	t.Setenv("DEV_BROWSER_TEST_DURATION", "1h30m")
+	if got := getenvDuration("DEV_BROWSER_TEST_DURATION", 0); got != 90*time.Minute {
+		t.Fatalf("expected 1h30m, got %s", got)
+	}
+	t.Setenv("DEV_BROWSER_TEST_DURATION", "soon")
+	if got := getenvDuration("DEV_BROWSER_TEST_DURATION", time.Second); got != time.Second {
+		t.Fatalf("expected default for invalid value, got %s", got)
+	}
+}
+
+func TestResourceSummary(t *testing.T) {
+	got := resourceSummary(devbrowser.DaemonResources{Pages: 3, MaxPages: 20, MemoryMB: 812, MaxMemoryMB: 2048, IdleMS: 120_400, IdleTimeoutMS: 1_800_000})
+	want := "resources pages=3/20 memory=812MB/2048MB idle=2m0s/30m0s"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	got = resourceSummary(devbrowser.DaemonResources{Pages: 1, MemoryMB: 300})
+	if got != "resources pages=1 memory=300MB idle=0s" {
+		t.Fatalf("unexpected unlimited summary %q", got)
+	}
+}
+
+func TestApplyGlobalOptionsCDPEndpoint(t *testing.T) {
+	t.Setenv("DEV_BROWSER_CDP_ENDPOINT", "")
+	cmd := newTestCmd()
+	if err := applyGlobalOptions(cmd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if globalOpts.cdpEndpoint != "" || globalOpts.cdpEndpointSet {
+		t.Fatalf("expected no endpoint by default, got %q set=%t", globalOpts.cdpEndpoint, globalOpts.cdpEndpointSet)
+	}
+
+	cmd = newTestCmd()
+	if err := cmd.PersistentFlags().Set("cdp-endpoint", "http://chrome:9222/"); err != nil {
+		t.Fatalf("set cdp-endpoint: %v", err)
+	}
+	if err := applyGlobalOptions(cmd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if globalOpts.cdpEndpoint != "http://chrome:9222" || !globalOpts.cdpEndpointSet {
+		t.Fatalf("unexpected endpoint %q set=%t", globalOpts.cdpEndpoint, globalOpts.cdpEndpointSet)
+	}
+
+	cmd = newTestCmd()
+	if err := cmd.PersistentFlags().Set("cdp-endpoint", "chrome:9222"); err != nil {
+		t.Fatalf("set cdp-endpoint: %v", err)
+	}
+	if err := applyGlobalOptions(cmd); err == nil {
+		t.Fatal("expected error for endpoint without scheme")
+	}
+}
+
+func TestContextSummaryCDPEndpoint(t *testing.T) {
+	got := contextSummary(devbrowser.BrowserContextSettings{Browser: "chromium", CDPEndpoint: "ws://chrome:9222/devtools/browser/x"})
+	if got != "cdp-endpoint=ws://chrome:9222/devtools/browser/x" {
+		t.Fatalf("unexpected summary %q", got)
+	}
+}
+
+func TestQueueSummary(t *testing.T) {
+	got := queueSummary(devbrowser.PageQueueStatus{Context: "alice", Page: "main", Running: "snapshot", RunningMS: 3200, Waiting: 2})
+	if got != "busy context=alice page=main running=snapshot for=3s waiting=2" {
+		t.Fatalf("unexpected summary %q", got)
+	}
+}
+
+func TestApplyGlobalOptionsWaitTimeout(t *testing.T) {
+	t.Setenv(devbrowser.EnvWaitTimeout, "")
+	cmd := newTestCmd()
+	if err := applyGlobalOptions(cmd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if globalOpts.waitTimeout != 30*time.Second {
+		t.Fatalf("expected 30s default, got %s", globalOpts.waitTimeout)
+	}
+	cmd = newTestCmd()
+	if err := cmd.PersistentFlags().Set("wait-timeout", "-1s"); err != nil {
+		t.Fatalf("set wait-timeout: %v", err)
+	}
+	if err := applyGlobalOptions(cmd); err == nil {
+		t.Fatal("expected error for negative wait timeout")
+	}
+
+	t.Setenv(devbrowser.EnvWaitTimeout, "10s")
+	cmd = newTestCmd()
+	if err := applyGlobalOptions(cmd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if globalOpts.waitTimeout != 10*time.Second {
+		t.Fatalf("expected 10s from env, got %s", globalOpts.waitTimeout)
+	}
+	cmd = newTestCmd()
+	if err := cmd.PersistentFlags().Set("wait-timeout", "2s"); err != nil {
+		t.Fatalf("set wait-timeout: %v", err)
+	}
+	if err := applyGlobalOptions(cmd); err != nil || globalOpts.waitTimeout != 2*time.Second {
+		t.Fatalf("expected the flag to win over env, got %s (%v)", globalOpts.waitTimeout, err)
+	}
+	for _, bad := range []string{"10", "10x"} {
+		t.Setenv(devbrowser.EnvWaitTimeout, bad)
+		if err := applyGlobalOptions(newTestCmd()); err == nil || !strings.Contains(err.Error(), devbrowser.EnvWaitTimeout) {
+			t.Fatalf("%s=%q: expected an error, got %v", devbrowser.EnvWaitTimeout, bad, err)
+		}
+	}
+}
+
+func TestApplyGlobalOptionsNoAutoRestart(t *testing.T) {
+	t.Setenv(devbrowser.EnvNoAutoRestart, "")
+	cmd := newTestCmd()
+	if err := cmd.PersistentFlags().Set("no-auto-restart", "true"); err != nil {
+		t.Fatalf("set no-auto-restart: %v", err)
+	}
+	if err := applyGlobalOptions(cmd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := os.Getenv(devbrowser.EnvNoAutoRestart); got != "true" {
+		t.Fatalf("expected %s=true for EnsureDaemon, got %q", devbrowser.EnvNoAutoRestart, got)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	devbrowser "github.com/joshp123/dev-browser-go/internal/devbrowser"
+	"github.com/spf13/cobra"
+)
+
+func newNetworkCmd() *cobra.Command {
+	var pageName string
+	var since int64
+	var limit int
+	var urlContains string
+	var method string
+	var typ string
+	var status int
+	var statusMin int
+	var statusMax int
+	var onlyFailed bool
+	var includeHeaders bool
+
+	cmd := &cobra.Command{
+		Use:   "network",
+		Short: "Read the daemon's per-page network log (use --since to see requests after an action)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if since < 0 {
+				return fmt.Errorf("--since must be >= 0")
+			}
+			if limit < 0 {
+				return fmt.Errorf("--limit must be >= 0")
+			}
+			base, err := startDaemonIfNeeded()
+			if err != nil {
+				return err
+			}
+			query := url.Values{}
+			if cmd.Flags().Changed("limit") {
+				query.Set("limit", strconv.Itoa(limit))
+			}
+			if since > 0 {
+				query.Set("since", strconv.FormatInt(since, 10))
+			}
+			if strings.TrimSpace(urlContains) != "" {
+				query.Set("url_contains", urlContains)
+			}
+			if strings.TrimSpace(method) != "" {
+				query.Set("method", method)
+			}
+			if strings.TrimSpace(typ) != "" {
+				query.Set("type", typ)
+			}
+			if status > 0 {
+				query.Set("status", strconv.Itoa(status))
+			}
+			if statusMin > 0 {
+				query.Set("status_min", strconv.Itoa(statusMin))
+			}
+			if statusMax > 0 {
+				query.Set("status_max", strconv.Itoa(statusMax))
+			}
+			if onlyFailed {
+				query.Set("failed", "1")
+			}
+			if includeHeaders {
+				query.Set("headers", "1")
+			}
+			endpoint := pageEndpoint(base, pageName, "network", query)
+			data, err := devbrowser.HTTPJSON("GET", endpoint, nil, 5*time.Second)
+			if err != nil {
+				return err
+			}
+			if ok, _ := data["ok"].(bool); !ok {
+				return fmt.Errorf("network failed: %v", data["error"])
+			}
+			return printOutput(data)
+		},
+	}
+
+	cmd.Flags().StringVar(&pageName, "page", "main", "Page name")
+	cmd.Flags().Int64Var(&since, "since", 0, "Only return entries with id > since")
+	cmd.Flags().IntVar(&limit, "limit", 500, "Max entries")
+	cmd.Flags().StringVar(&urlContains, "url-contains", "", "Filter: URL contains substring")
+	cmd.Flags().StringVar(&method, "method", "", "Filter: HTTP method equals")
+	cmd.Flags().StringVar(&typ, "type", "", "Filter: resource type equals")
+	cmd.Flags().IntVar(&status, "status", 0, "Filter: status equals")
+	cmd.Flags().IntVar(&statusMin, "status-min", 0, "Filter: status >=")
+	cmd.Flags().IntVar(&statusMax, "status-max", 0, "Filter: status <=")
+	cmd.Flags().BoolVar(&onlyFailed, "failed", false, "Only include failed (non-2xx/3xx or request failed)")
+	cmd.Flags().BoolVar(&includeHeaders, "headers", false, "Include request/response headers")
+
+	return cmd
+}
@@ -54,7 +54,17 @@ func newNetworkMonitorCmd() *cobra.Command {
 			if strings.TrimSpace(typ) != "" {
 				payload["type"] = typ
 			}
-			return runWithPage(pageName, "network_monitor", payload)
+			res, err := runToolOnPage(pageName, "network_monitor", payload)
+			if err != nil {
+				return err
+			}
+			// Surface active interception rules so mocked responses are obvious.
+			if base, err := startDaemonIfNeeded(); err == nil {
+				if routes, err := readRoutes(base); err == nil && len(routes) > 0 {
+					res["routes"] = routes
+				}
+			}
+			return printOutput(res)
 		},
 	}
 
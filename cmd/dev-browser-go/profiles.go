@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	devbrowser "github.com/joshp123/dev-browser-go/internal/devbrowser"
+	"github.com/spf13/cobra"
+)
+
+func newProfilesCmd() *cobra.Command {
+	var userData bool
+	var artifacts bool
+	var dryRun bool
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "profiles <list|stop-all|prune|rm> [name]",
+		Short: "List profiles with daemon state and disk usage; stop, prune or remove them",
+		Args: func(_ *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("profiles action required (list|stop-all|prune|rm)")
+			}
+			switch args[0] {
+			case "list", "stop-all", "prune":
+				if len(args) != 1 {
+					return fmt.Errorf("profiles %s takes no arguments", args[0])
+				}
+			case "rm":
+				if len(args) != 2 {
+					return errors.New("profiles rm requires <name>")
+				}
+				if err := devbrowser.ValidateProfileName(args[1]); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unknown profiles action %q (expected list|stop-all|prune|rm)", args[0])
+			}
+			return nil
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			switch args[0] {
+			case "list":
+				profiles, err := devbrowser.ListProfiles()
+				if err != nil {
+					return err
+				}
+				if globalOpts.output == "json" {
+					return printOutput(map[string]any{"profiles": profiles})
+				}
+				if len(profiles) == 0 {
+					fmt.Println("no profiles")
+				}
+				for _, p := range profiles {
+					fmt.Println(profileSummary(p))
+				}
+				return nil
+
+			case "stop-all":
+				profiles, err := devbrowser.ListProfiles()
+				if err != nil {
+					return err
+				}
+				stopped := []string{}
+				for _, p := range profiles {
+					if !p.Busy() {
+						continue
+					}
+					if _, err := devbrowser.StopDaemon(p.Name); err != nil {
+						return fmt.Errorf("stop profile %s: %w", p.Name, err)
+					}
+					stopped = append(stopped, p.Name)
+				}
+				return printProfilesResult(map[string]any{"stopped": stopped}, "stopped", stopped)
+
+			case "prune":
+				profiles, err := devbrowser.ListProfiles()
+				if err != nil {
+					return err
+				}
+				opts := devbrowser.PruneOptions{UserData: userData, Artifacts: artifacts, DryRun: dryRun}
+				removed := []string{}
+				skipped := []string{}
+				for _, p := range profiles {
+					if p.Busy() {
+						skipped = append(skipped, p.Name)
+						continue
+					}
+					paths, err := devbrowser.PruneProfile(p, opts)
+					removed = append(removed, paths...)
+					if err != nil {
+						return err
+					}
+				}
+				if len(skipped) > 0 && globalOpts.output != "json" {
+					fmt.Fprintf(os.Stderr, "skipped running profiles: %s\n", strings.Join(skipped, ","))
+				}
+				return printProfilesResult(map[string]any{"removed": removed, "skipped": skipped, "dry_run": dryRun}, removedLabel(dryRun), removed)
+
+			default: // rm
+				name := args[1]
+				info := devbrowser.InspectProfile(name)
+				if !pathExists(info.StateDir) && !pathExists(info.CacheDir) {
+					return fmt.Errorf("profile %q not found", name)
+				}
+				if info.Busy() {
+					if !force {
+						return fmt.Errorf("profile %s has a running daemon; stop it first or pass --force", name)
+					}
+					if !dryRun {
+						if _, err := devbrowser.StopDaemon(name); err != nil {
+							return err
+						}
+						info = devbrowser.InspectProfile(name)
+					} else {
+						info.Running, info.Unresponsive = false, false
+					}
+				}
+				removed, err := devbrowser.RemoveProfile(info, dryRun)
+				if err != nil {
+					return err
+				}
+				return printProfilesResult(map[string]any{"profile": name, "removed": removed, "dry_run": dryRun}, removedLabel(dryRun), removed)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&userData, "user-data", false, "prune: also delete browser user data of stopped profiles")
+	cmd.Flags().BoolVar(&artifacts, "artifacts", false, "prune: also delete artifacts of stopped profiles")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "prune/rm: print what would be deleted without deleting")
+	cmd.Flags().BoolVar(&force, "force", false, "rm: stop a running daemon before deleting")
+
+	return cmd
+}
+
+func printProfilesResult(result map[string]any, label string, items []string) error {
+	if globalOpts.output == "json" {
+		return printOutput(result)
+	}
+	if len(items) == 0 {
+		fmt.Printf("%s nothing\n", label)
+		return nil
+	}
+	for _, item := range items {
+		fmt.Printf("%s %s\n", label, item)
+	}
+	return nil
+}
+
+func removedLabel(dryRun bool) string {
+	if dryRun {
+		return "would remove"
+	}
+	return "removed"
+}
+
+// profileSummary prints one `profiles list` line, e.g.
+// "default running pid=42 url=http://127.0.0.1:5000 headless=true user-data=512.0MB artifacts=3.1MB".
+func profileSummary(p devbrowser.ProfileInfo) string {
+	parts := []string{p.Name}
+	switch {
+	case p.Running:
+		state := "running"
+		if p.Status != "" && p.Status != devbrowser.HealthOK {
+			state = p.Status
+		}
+		parts = append(parts, state, fmt.Sprintf("pid=%d", p.PID), "url="+p.URL)
+	case p.Unresponsive:
+		parts = append(parts, "unresponsive", fmt.Sprintf("pid=%d", p.PID))
+	case p.StaleState:
+		parts = append(parts, "stopped", "stale-state")
+	default:
+		parts = append(parts, "stopped")
+	}
+	if p.Context != nil {
+		parts = append(parts, contextSummary(*p.Context))
+	}
+	parts = append(parts, "user-data="+formatBytes(p.UserDataBytes), "artifacts="+formatBytes(p.ArtifactBytes))
+	return strings.Join(parts, " ")
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
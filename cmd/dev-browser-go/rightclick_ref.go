@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newRightclickRefCmd() *cobra.Command {
+	var pageName string
+	var timeout int
+
+	cmd := &cobra.Command{
+		Use:   "rightclick-ref <ref>",
+		Short: "Right-click element by ref (opens context menus)",
+		Args:  requireArgs(1, "ref required"),
+		RunE: func(_ *cobra.Command, args []string) error {
+			payload := map[string]interface{}{
+				"ref":        args[0],
+				"timeout_ms": timeout,
+			}
+			return runWithPage(pageName, "rightclick_ref", payload)
+		},
+	}
+
+	cmd.Flags().StringVar(&pageName, "page", "main", "Page name")
+	cmd.Flags().IntVar(&timeout, "timeout-ms", 15_000, "Timeout ms")
+
+	return cmd
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	devbrowser "github.com/joshp123/dev-browser-go/internal/devbrowser"
 	"github.com/spf13/cobra"
 )
 
@@ -22,6 +23,7 @@ func Execute() error {
 
 func init() {
 	rootCmd.Version = buildVersion()
+	devbrowser.SetBuildVersion(rootCmd.Version)
 	rootCmd.SetVersionTemplate("{{.Version}}\n")
 
 	bindGlobalFlags(rootCmd)
@@ -31,12 +33,26 @@ func init() {
 		newStatusCmd(),
 		newStartCmd(),
 		newStopCmd(),
+		newProfilesCmd(),
 		newListPagesCmd(),
+		newContextsCmd(),
 		newDevicesCmd(),
+		newCompareDevicesCmd(),
 		newGotoCmd(),
 		newSnapshotCmd(),
 		newClickRefCmd(),
 		newFillRefCmd(),
+		newHoverRefCmd(),
+		newDblclickRefCmd(),
+		newRightclickRefCmd(),
+		newCheckRefCmd(),
+		newUncheckRefCmd(),
+		newSelectRefCmd(),
+		newFocusRefCmd(),
+		newScrollIntoViewRefCmd(),
+		newDragRefCmd(),
+		newTypeRefCmd(),
+		newUploadRefCmd(),
 		newPressCmd(),
 		newScreenshotCmd(),
 		newStyleCaptureCmd(),
@@ -46,6 +62,13 @@ func init() {
 		newSaveDomBaselineCmd(),
 		newDomDiffCmd(),
 		newNetworkMonitorCmd(),
+		newNetworkCmd(),
+		newDownloadsCmd(),
+		newEventsCmd(),
+		newRouteCmd(),
+		newHARCmd(),
+		newStateCmd(),
+		newCookiesCmd(),
 		newInspectRefCmd(),
 		newTestSelectorCmd(),
 		newTestXPathCmd(),
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	devbrowser "github.com/joshp123/dev-browser-go/internal/devbrowser"
+	"github.com/spf13/cobra"
+)
+
+func newRouteCmd() *cobra.Command {
+	var urlGlob string
+	var regex string
+	var method string
+	var action string
+	var status int
+	var body string
+	var bodyFile string
+	var jsonBody string
+	var contentType string
+	var headers []string
+	var delayMs int
+	var errorCode string
+
+	cmd := &cobra.Command{
+		Use:   "route <add|list|remove|clear> [id]",
+		Short: "Manage persistent request interception rules (fulfill/abort/delay/rewrite headers)",
+		Args: func(_ *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("route action required (add|list|remove|clear)")
+			}
+			switch args[0] {
+			case "add", "list", "clear":
+				if len(args) != 1 {
+					return fmt.Errorf("route %s takes no arguments", args[0])
+				}
+			case "remove":
+				if len(args) != 2 {
+					return errors.New("route remove requires a route id")
+				}
+			default:
+				return fmt.Errorf("unknown route action %q (expected add|list|remove|clear)", args[0])
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch args[0] {
+			case "list":
+				data, err := daemonCall("route list", http.MethodGet, "/routes", nil, 5*time.Second)
+				if err != nil {
+					return err
+				}
+				return printOutput(map[string]any{"routes": data["routes"]})
+			case "clear":
+				data, err := daemonCall("route clear", http.MethodDelete, "/routes", nil, 5*time.Second)
+				if err != nil {
+					return err
+				}
+				return printOutput(map[string]any{"cleared": data["cleared"]})
+			case "remove":
+				id, err := strconv.ParseInt(args[1], 10, 64)
+				if err != nil || id <= 0 {
+					return errors.New("route id must be a positive integer")
+				}
+				data, err := daemonCall("route remove", http.MethodDelete, fmt.Sprintf("/routes/%d", id), nil, 5*time.Second)
+				if err != nil {
+					return err
+				}
+				return printOutput(map[string]any{"removed": data["removed"]})
+			}
+
+			payload, err := buildRoutePayload(cmd, routeFlags{
+				url:         urlGlob,
+				regex:       regex,
+				method:      method,
+				action:      action,
+				status:      status,
+				body:        body,
+				bodyFile:    bodyFile,
+				jsonBody:    jsonBody,
+				contentType: contentType,
+				headers:     headers,
+				delayMs:     delayMs,
+				errorCode:   errorCode,
+			})
+			if err != nil {
+				return err
+			}
+			data, err := daemonCall("route add", http.MethodPost, "/routes", payload, 5*time.Second)
+			if err != nil {
+				return err
+			}
+			return printOutput(map[string]any{"route": data["route"]})
+		},
+	}
+
+	cmd.Flags().StringVar(&urlGlob, "url", "", "URL glob to match (e.g. **/api/**)")
+	cmd.Flags().StringVar(&regex, "regex", "", "URL regex to match (instead of --url)")
+	cmd.Flags().StringVar(&method, "method", "", "HTTP method to match (default: any)")
+	cmd.Flags().StringVar(&action, "action", devbrowser.RouteActionFulfill, "Action (fulfill|abort|continue)")
+	cmd.Flags().IntVar(&status, "status", 0, "Fulfill: response status (default 200)")
+	cmd.Flags().StringVar(&body, "body", "", "Fulfill: response body")
+	cmd.Flags().StringVar(&bodyFile, "body-file", "", "Fulfill: serve response body from file")
+	cmd.Flags().StringVar(&jsonBody, "json", "", "Fulfill: JSON response body (sets content type)")
+	cmd.Flags().StringVar(&contentType, "content-type", "", "Fulfill: response content type")
+	cmd.Flags().StringArrayVar(&headers, "header", nil, "Header 'Name: value' (repeatable; response for fulfill, request override for continue)")
+	cmd.Flags().IntVar(&delayMs, "delay-ms", 0, "Delay before applying the action")
+	cmd.Flags().StringVar(&errorCode, "error-code", "", "Abort: error code (default failed)")
+
+	return cmd
+}
+
+type routeFlags struct {
+	url         string
+	regex       string
+	method      string
+	action      string
+	status      int
+	body        string
+	bodyFile    string
+	jsonBody    string
+	contentType string
+	headers     []string
+	delayMs     int
+	errorCode   string
+}
+
+func buildRoutePayload(cmd *cobra.Command, f routeFlags) (map[string]any, error) {
+	if strings.TrimSpace(f.url) == "" && strings.TrimSpace(f.regex) == "" {
+		return nil, errors.New("--url or --regex is required")
+	}
+	if f.delayMs < 0 {
+		return nil, errors.New("--delay-ms must be >= 0")
+	}
+	bodySources := 0
+	for _, name := range []string{"body", "body-file", "json"} {
+		if cmd.Flags().Changed(name) {
+			bodySources++
+		}
+	}
+	if bodySources > 1 {
+		return nil, errors.New("use only one of --body, --body-file, --json")
+	}
+
+	payload := map[string]any{
+		"action":   f.action,
+		"delay_ms": f.delayMs,
+	}
+	if v := strings.TrimSpace(f.url); v != "" {
+		payload["url"] = v
+	}
+	if v := strings.TrimSpace(f.regex); v != "" {
+		payload["regex"] = v
+	}
+	if v := strings.TrimSpace(f.method); v != "" {
+		payload["method"] = v
+	}
+	if f.status != 0 {
+		payload["status"] = f.status
+	}
+	if cmd.Flags().Changed("body") {
+		payload["body"] = f.body
+	}
+	if v := strings.TrimSpace(f.bodyFile); v != "" {
+		// The daemon reads the file, so resolve it against our working
+		// directory, not its.
+		abs, err := filepath.Abs(v)
+		if err != nil {
+			return nil, fmt.Errorf("--body-file: %w", err)
+		}
+		if _, err := os.Stat(abs); err != nil {
+			return nil, fmt.Errorf("--body-file: %w", err)
+		}
+		payload["body_path"] = abs
+	}
+	contentType := strings.TrimSpace(f.contentType)
+	if cmd.Flags().Changed("json") {
+		if !json.Valid([]byte(f.jsonBody)) {
+			return nil, errors.New("--json must be valid JSON")
+		}
+		payload["body"] = f.jsonBody
+		if contentType == "" {
+			contentType = "application/json"
+		}
+	}
+	if contentType != "" {
+		payload["content_type"] = contentType
+	}
+	if v := strings.TrimSpace(f.errorCode); v != "" {
+		payload["error_code"] = v
+	}
+	if len(f.headers) > 0 {
+		parsed, err := parseHeaderFlags(f.headers)
+		if err != nil {
+			return nil, err
+		}
+		payload["headers"] = parsed
+	}
+	return payload, nil
+}
+
+func parseHeaderFlags(values []string) (map[string]string, error) {
+	out := make(map[string]string, len(values))
+	for _, raw := range values {
+		name, value, ok := strings.Cut(raw, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --header %q (expected 'Name: value')", raw)
+		}
+		out[name] = strings.TrimSpace(value)
+	}
+	return out, nil
+}
+
+func readRoutes(base string) ([]devbrowser.RouteRule, error) {
+	data, err := devbrowser.HTTPJSON(http.MethodGet, base+"/routes", nil, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if ok, _ := data["ok"].(bool); !ok {
+		return nil, fmt.Errorf("route list failed: %v", data["error"])
+	}
+	b, _ := json.Marshal(data["routes"])
+	var routes []devbrowser.RouteRule
+	_ = json.Unmarshal(b, &routes)
+	return routes, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+func newSelectRefCmd() *cobra.Command {
+	var pageName string
+	var timeout int
+	var by string
+
+	cmd := &cobra.Command{
+		Use:   "select-ref <ref> <option...>",
+		Short: "Select option(s) of a <select> by ref (match by value or label)",
+		Args: func(_ *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return errors.New("ref and at least one option required")
+			}
+			return nil
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			payload := map[string]interface{}{
+				"ref":        args[0],
+				"values":     args[1:],
+				"by":         by,
+				"timeout_ms": timeout,
+			}
+			return runWithPage(pageName, "select_ref", payload)
+		},
+	}
+
+	cmd.Flags().StringVar(&pageName, "page", "main", "Page name")
+	cmd.Flags().IntVar(&timeout, "timeout-ms", 15_000, "Timeout ms")
+	cmd.Flags().StringVar(&by, "by", "auto", "Match options by value|label|auto")
+
+	return cmd
+}
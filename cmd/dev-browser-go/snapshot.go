@@ -2,7 +2,10 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
+	devbrowser "github.com/joshp123/dev-browser-go/internal/devbrowser"
 	"github.com/spf13/cobra"
 )
 
@@ -14,6 +17,10 @@ func newSnapshotCmd() *cobra.Command {
 	var includeHeadings bool
 	var maxItems int
 	var maxChars int
+	var diff bool
+	var within string
+	var maxDepth int
+	var tokenBudget int
 
 	cmd := &cobra.Command{
 		Use:   "snapshot",
@@ -28,6 +35,18 @@ func newSnapshotCmd() *cobra.Command {
 				"max_items":        maxItems,
 				"max_chars":        maxChars,
 			}
+			if diff {
+				payload["diff"] = true
+			}
+			if within != "" {
+				payload["within"] = within
+			}
+			if maxDepth > 0 {
+				payload["max_depth"] = maxDepth
+			}
+			if tokenBudget > 0 {
+				payload["token_budget"] = tokenBudget
+			}
 			return runWithPage(pageName, "snapshot", payload)
 		},
 	}
@@ -39,6 +58,10 @@ func newSnapshotCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&includeHeadings, "include-headings", true, "Include headings")
 	cmd.Flags().IntVar(&maxItems, "max-items", 80, "Max items")
 	cmd.Flags().IntVar(&maxChars, "max-chars", 8000, "Max chars")
+	cmd.Flags().StringVar(&within, "within", "", "Start at this element (ref like e12 or f1:e3, frame like f1, or CSS selector) instead of the document")
+	cmd.Flags().IntVar(&maxDepth, "max-depth", 0, "Levels to descend below the start: DOM elements with --engine simple, accessibility-tree nodes with aria (0 = unlimited)")
+	cmd.Flags().BoolVar(&diff, "diff", false, "Only refs added, removed or changed since the page's last snapshot")
+	cmd.Flags().IntVar(&tokenBudget, "token-budget", 0, "Fit the snapshot in about N tokens, keeping focused, changed and on-screen elements first (0 = off; replaces --max-items/--max-chars)")
 
 	cmd.Flags().Bool("no-interactive-only", false, "Include non-interactive elements")
 	cmd.Flags().Bool("no-include-headings", false, "Exclude headings")
@@ -56,6 +79,19 @@ func newSnapshotCmd() *cobra.Command {
 		if maxChars < 0 {
 			return errors.New("--max-chars must be >= 0")
 		}
+		if maxDepth < 0 {
+			return errors.New("--max-depth must be >= 0")
+		}
+		if tokenBudget < 0 || (tokenBudget > 0 && tokenBudget < devbrowser.MinTokenBudget) {
+			return fmt.Errorf("--token-budget must be 0 (off) or at least %d", devbrowser.MinTokenBudget)
+		}
+		if diff && tokenBudget > 0 {
+			return errors.New("--diff and --token-budget cannot be combined")
+		}
+		within = strings.TrimSpace(within)
+		if diff && globalOpts.attach {
+			return errors.New("--diff compares against snapshots kept by the daemon; drop --attach")
+		}
 		return nil
 	}
 
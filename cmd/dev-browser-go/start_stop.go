@@ -2,24 +2,33 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	devbrowser "github.com/joshp123/dev-browser-go/internal/devbrowser"
 	"github.com/spf13/cobra"
 )
 
 func newStartCmd() *cobra.Command {
-	return &cobra.Command{
+	limits := &daemonOptions{}
+	cmd := &cobra.Command{
 		Use:   "start",
 		Short: "Start daemon",
-		RunE: func(_ *cobra.Command, _ []string) error {
-			headless, window, device, err := desiredDaemonSettings()
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			want, err := desiredDaemonSettings()
 			if err != nil {
 				return err
 			}
-			result, err := devbrowser.EnsureDaemon(globalOpts.profile, headless, window, device)
+			exportDaemonLimits(cmd, limits)
+			result, err := devbrowser.EnsureDaemon(globalOpts.profile, want.Browser, want.Headless, want.Window, want.Device, want.CDPEndpoint)
 			if err != nil {
 				return err
 			}
+			if result.Warning != "" {
+				fmt.Fprintf(os.Stderr, "warning: profile=%s %s\n", globalOpts.profile, result.Warning)
+			}
+			if result.Action != devbrowser.DaemonActionStarted && daemonLimitsChanged(cmd) {
+				fmt.Fprintf(os.Stderr, "warning: profile=%s was already running; limits apply when the daemon starts (stop it first)\n", globalOpts.profile)
+			}
 			switch result.Action {
 			case devbrowser.DaemonActionStarted:
 				fmt.Printf("started profile=%s url=%s %s\n", globalOpts.profile, result.BaseURL, contextSummary(result.Context))
@@ -31,6 +40,8 @@ func newStartCmd() *cobra.Command {
 			return nil
 		},
 	}
+	addDaemonLimitFlags(cmd, limits)
+	return cmd
 }
 
 func newStopCmd() *cobra.Command {
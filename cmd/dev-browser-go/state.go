@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	devbrowser "github.com/joshp123/dev-browser-go/internal/devbrowser"
+	"github.com/spf13/cobra"
+)
+
+func newStateCmd() *cobra.Command {
+	var path string
+
+	cmd := &cobra.Command{
+		Use:   "state <save|load>",
+		Short: "Save or load cookies, localStorage and sessionStorage (share a logged-in session)",
+		Args: func(_ *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("state action required (save|load)")
+			}
+			switch args[0] {
+			case "save", "load":
+				return nil
+			default:
+				return fmt.Errorf("unknown state action %q (expected save|load)", args[0])
+			}
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			if args[0] == "save" {
+				resolved, err := devbrowser.SafeArtifactPath(devbrowser.ArtifactDir(globalOpts.profile), path, fmt.Sprintf("state-%d.json", devbrowser.NowMS()))
+				if err != nil {
+					return err
+				}
+				data, err := daemonCall("state save", http.MethodGet, "/state"+contextQuery(), nil, 30*time.Second)
+				if err != nil {
+					return err
+				}
+				var state devbrowser.StorageStateFile
+				if err := remarshal(data["state"], &state); err != nil {
+					return err
+				}
+				if err := devbrowser.WriteStorageStateFile(resolved, state); err != nil {
+					return err
+				}
+				summary := state.Summary()
+				summary.Path = resolved
+				return printOutput(summary)
+			}
+
+			if path == "" {
+				return errors.New("--path required for state load")
+			}
+			resolved, err := devbrowser.ResolveArtifactReadPath(globalOpts.profile, path)
+			if err != nil {
+				return err
+			}
+			state, err := devbrowser.ReadStorageStateFile(resolved)
+			if err != nil {
+				return err
+			}
+			var body map[string]any
+			if err := remarshal(state, &body); err != nil {
+				return err
+			}
+			data, err := daemonCall("state load", http.MethodPost, "/state"+contextQuery(), body, 60*time.Second)
+			if err != nil {
+				return err
+			}
+			var summary devbrowser.StorageStateSummary
+			if err := remarshal(data["loaded"], &summary); err != nil {
+				return err
+			}
+			summary.Path = resolved
+			return printOutput(summary)
+		},
+	}
+
+	cmd.Flags().StringVar(&path, "path", "", "State file (save: relative to artifact dir, default state-<ts>.json; load: required)")
+
+	return cmd
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	devbrowser "github.com/joshp123/dev-browser-go/internal/devbrowser"
 	"github.com/spf13/cobra"
@@ -22,7 +24,37 @@ func newStatusCmd() *cobra.Command {
 				if pageURL == "" {
 					pageURL = "about:blank"
 				}
-				fmt.Printf("ok profile=%s url=%s %s page=%s\n", globalOpts.profile, fmt.Sprintf("http://%s:%d", health.Host, health.Port), contextSummary(health.Context), pageURL)
+				addr := fmt.Sprintf("http://%s:%d", health.Host, health.Port)
+				if health.Socket != "" {
+					addr = "unix:" + health.Socket
+				}
+				status := health.Status
+				if status == "" {
+					status = devbrowser.HealthOK
+				}
+				fmt.Printf("%s profile=%s url=%s %s page=%s\n", status, globalOpts.profile, addr, contextSummary(health.Context), pageURL)
+				if health.BuildVersion != "" && health.BuildVersion != buildVersion() {
+					fmt.Printf("version daemon=%s cli=%s (restarted by the next command unless --no-auto-restart)\n", health.BuildVersion, buildVersion())
+				}
+				if health.Resources != nil {
+					fmt.Println(resourceSummary(*health.Resources))
+				}
+				if n := len(health.Crashes); n > 0 {
+					last := health.Crashes[n-1]
+					fmt.Printf("crashes=%d last=%s page=%s recovered=%t\n", n, last.Kind, last.Page, last.Recovered)
+				}
+				for _, reason := range health.Degraded {
+					fmt.Printf("degraded %s\n", reason)
+				}
+				for _, q := range health.Queues {
+					fmt.Println(queueSummary(q))
+				}
+				for _, ctx := range health.Contexts {
+					if ctx.Name == devbrowser.DefaultContextName {
+						continue
+					}
+					fmt.Printf("context=%s %s pages=%s\n", ctx.Name, contextSummary(ctx.Settings), strings.Join(ctx.Pages, ","))
+				}
 				return nil
 			}
 			fmt.Printf("not running profile=%s\n", globalOpts.profile)
@@ -30,3 +62,36 @@ func newStatusCmd() *cobra.Command {
 		},
 	}
 }
+
+// queueSummary prints one busy page, e.g.
+// "busy page=main running=snapshot for=3s waiting=2".
+func queueSummary(q devbrowser.PageQueueStatus) string {
+	parts := []string{"busy"}
+	if q.Context != "" {
+		parts = append(parts, "context="+q.Context)
+	}
+	parts = append(parts, "page="+q.Page)
+	if q.Running != "" {
+		parts = append(parts, "running="+q.Running, "for="+(time.Duration(q.RunningMS)*time.Millisecond).Round(time.Second).String())
+	}
+	parts = append(parts, "waiting="+strconv.Itoa(q.Waiting))
+	return strings.Join(parts, " ")
+}
+
+// resourceSummary prints usage against limits, e.g.
+// "resources pages=3/20 memory=812MB/2048MB idle=2m0s/30m0s".
+func resourceSummary(r devbrowser.DaemonResources) string {
+	pages := strconv.Itoa(r.Pages)
+	if r.MaxPages > 0 {
+		pages += "/" + strconv.Itoa(r.MaxPages)
+	}
+	memory := fmt.Sprintf("%dMB", r.MemoryMB)
+	if r.MaxMemoryMB > 0 {
+		memory += fmt.Sprintf("/%dMB", r.MaxMemoryMB)
+	}
+	idle := (time.Duration(r.IdleMS) * time.Millisecond).Round(time.Second).String()
+	if r.IdleTimeoutMS > 0 {
+		idle += "/" + (time.Duration(r.IdleTimeoutMS) * time.Millisecond).String()
+	}
+	return fmt.Sprintf("resources pages=%s memory=%s idle=%s", pages, memory, idle)
+}
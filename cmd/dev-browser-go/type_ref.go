@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+func newTypeRefCmd() *cobra.Command {
+	var pageName string
+	var timeout int
+	var delay int
+
+	cmd := &cobra.Command{
+		Use:   "type-ref <ref> <text>",
+		Short: "Type text into element by ref, one key event per character",
+		Args:  requireArgs(2, "ref and text required"),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if delay < 0 {
+				return errors.New("--delay-ms must be >= 0")
+			}
+			payload := map[string]interface{}{
+				"ref":        args[0],
+				"text":       args[1],
+				"delay_ms":   delay,
+				"timeout_ms": timeout,
+			}
+			return runWithPage(pageName, "type_ref", payload)
+		},
+	}
+
+	cmd.Flags().StringVar(&pageName, "page", "main", "Page name")
+	cmd.Flags().IntVar(&timeout, "timeout-ms", 15_000, "Timeout ms")
+	cmd.Flags().IntVar(&delay, "delay-ms", 0, "Delay between key presses in ms")
+
+	return cmd
+}
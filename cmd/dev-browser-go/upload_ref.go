@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+func newUploadRefCmd() *cobra.Command {
+	var pageName string
+	var timeout int
+
+	cmd := &cobra.Command{
+		Use:   "upload-ref <ref> <file...>",
+		Short: "Set the files of an <input type=file> by ref",
+		Args:  minArgs(2, "ref and at least one file required"),
+		RunE: func(_ *cobra.Command, args []string) error {
+			// The tool runs inside the daemon by default, so send absolute paths.
+			files := make([]string, 0, len(args)-1)
+			for _, f := range args[1:] {
+				abs, err := filepath.Abs(f)
+				if err != nil {
+					return fmt.Errorf("upload file %s: %w", f, err)
+				}
+				files = append(files, abs)
+			}
+			payload := map[string]interface{}{
+				"ref":        args[0],
+				"files":      files,
+				"timeout_ms": timeout,
+			}
+			return runWithPage(pageName, "upload_ref", payload)
+		},
+	}
+
+	cmd.Flags().StringVar(&pageName, "page", "main", "Page name")
+	cmd.Flags().IntVar(&timeout, "timeout-ms", 15_000, "Timeout ms")
+
+	return cmd
+}
@@ -0,0 +1,74 @@
+package devbrowser
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Browser engines a profile can run. Chromium is the only one exposing CDP,
+// so only Chromium pages can be re-attached from the CLI process; page tools
+// on every engine normally run through the daemon's /pages/{name}/call.
+const (
+	BrowserChromium = "chromium"
+	BrowserFirefox  = "firefox"
+	BrowserWebKit   = "webkit"
+)
+
+// NormalizeBrowserEngine validates a --browser value; "" means chromium.
+func NormalizeBrowserEngine(raw string) (string, error) {
+	switch name := strings.ToLower(strings.TrimSpace(raw)); name {
+	case "", BrowserChromium, "chrome":
+		return BrowserChromium, nil
+	case BrowserFirefox:
+		return BrowserFirefox, nil
+	case BrowserWebKit, "safari":
+		return BrowserWebKit, nil
+	default:
+		return "", fmt.Errorf("unknown browser %q (use chromium|firefox|webkit)", raw)
+	}
+}
+
+// BrowserSupportsCDP reports whether pages of this engine can be addressed by
+// CDP target id.
+func BrowserSupportsCDP(engine string) bool {
+	return browserEngineOrDefault(engine) == BrowserChromium
+}
+
+func browserEngineOrDefault(engine string) string {
+	if normalized, err := NormalizeBrowserEngine(engine); err == nil {
+		return normalized
+	}
+	return strings.ToLower(strings.TrimSpace(engine))
+}
+
+func browserTypeFor(pw *playwright.Playwright, engine string) playwright.BrowserType {
+	switch browserEngineOrDefault(engine) {
+	case BrowserFirefox:
+		return pw.Firefox
+	case BrowserWebKit:
+		return pw.WebKit
+	default:
+		return pw.Chromium
+	}
+}
+
+// userDataDir keeps one profile directory per engine; the formats are not
+// interchangeable.
+func userDataDir(profile, engine string) string {
+	return filepath.Join(StateDir(profile), browserEngineOrDefault(engine)+"-profile")
+}
+
+// adaptLaunchOptionsForEngine drops options the engine rejects: Chromium
+// flags outside Chromium, and isMobile on Firefox.
+func adaptLaunchOptionsForEngine(engine string, opts *playwright.BrowserTypeLaunchPersistentContextOptions) {
+	switch browserEngineOrDefault(engine) {
+	case BrowserChromium:
+		return
+	case BrowserFirefox:
+		opts.IsMobile = nil
+	}
+	opts.Args = nil
+}
@@ -0,0 +1,81 @@
+package devbrowser
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+func TestNormalizeBrowserEngine(t *testing.T) {
+	cases := map[string]string{
+		"":          BrowserChromium,
+		" Chromium": BrowserChromium,
+		"chrome":    BrowserChromium,
+		"firefox":   BrowserFirefox,
+		"WebKit":    BrowserWebKit,
+		"safari":    BrowserWebKit,
+	}
+	for raw, want := range cases {
+		got, err := NormalizeBrowserEngine(raw)
+		if err != nil || got != want {
+			t.Fatalf("NormalizeBrowserEngine(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	if _, err := NormalizeBrowserEngine("edge"); err == nil {
+		t.Fatalf("expected error for unknown engine")
+	}
+	if !BrowserSupportsCDP("") || BrowserSupportsCDP(BrowserFirefox) || BrowserSupportsCDP(BrowserWebKit) {
+		t.Fatalf("only chromium should report CDP support")
+	}
+}
+
+func TestEffectiveContextMatchesBrowser(t *testing.T) {
+	current := normalizeContextRequest("", true, &WindowSize{Width: 1280, Height: 800}, "")
+	requested := normalizeContextRequest(BrowserFirefox, true, &WindowSize{Width: 1280, Height: 800}, "")
+	if effectiveContextMatches(current, requested) {
+		t.Fatalf("expected engine change to require a restart")
+	}
+	if got := describeContextDiff(current, requested); got != "browser=firefox" {
+		t.Fatalf("unexpected diff %q", got)
+	}
+	legacy := current
+	legacy.Browser = ""
+	if !effectiveContextMatches(legacy, current) {
+		t.Fatalf("expected settings without a browser to mean chromium")
+	}
+}
+
+func TestAdaptLaunchOptionsForEngine(t *testing.T) {
+	newOpts := func() playwright.BrowserTypeLaunchPersistentContextOptions {
+		return playwright.BrowserTypeLaunchPersistentContextOptions{
+			Args:     []string{"--remote-debugging-port=9222"},
+			IsMobile: playwright.Bool(true),
+			HasTouch: playwright.Bool(true),
+		}
+	}
+	opts := newOpts()
+	adaptLaunchOptionsForEngine(BrowserChromium, &opts)
+	if len(opts.Args) != 1 || opts.IsMobile == nil {
+		t.Fatalf("chromium options should be untouched: %+v", opts)
+	}
+	opts = newOpts()
+	adaptLaunchOptionsForEngine(BrowserFirefox, &opts)
+	if opts.Args != nil || opts.IsMobile != nil || opts.HasTouch == nil {
+		t.Fatalf("unexpected firefox options: %+v", opts)
+	}
+	opts = newOpts()
+	adaptLaunchOptionsForEngine(BrowserWebKit, &opts)
+	if opts.Args != nil || opts.IsMobile == nil {
+		t.Fatalf("unexpected webkit options: %+v", opts)
+	}
+}
+
+func TestUserDataDirPerEngine(t *testing.T) {
+	if got := filepath.Base(userDataDir("p", "")); got != "chromium-profile" {
+		t.Fatalf("expected chromium-profile, got %q", got)
+	}
+	if got := filepath.Base(userDataDir("p", BrowserWebKit)); got != "webkit-profile" {
+		t.Fatalf("expected webkit-profile, got %q", got)
+	}
+}
@@ -0,0 +1,134 @@
+package devbrowser
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// NormalizeCDPEndpoint validates a --cdp-endpoint value: a browser websocket
+// URL (ws://host:9222/devtools/browser/<id>) or the HTTP debugging address
+// (http://host:9222). Empty means launch a browser as usual.
+func NormalizeCDPEndpoint(raw string) (string, error) {
+	endpoint := strings.TrimSpace(raw)
+	if endpoint == "" {
+		return "", nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid CDP endpoint %q (expected ws://host:port/... or http://host:port)", raw)
+	}
+	switch u.Scheme {
+	case "ws", "wss":
+		return endpoint, nil
+	case "http", "https":
+		return strings.TrimRight(endpoint, "/"), nil
+	default:
+		return "", fmt.Errorf("invalid CDP endpoint %q (scheme must be ws, wss, http or https)", raw)
+	}
+}
+
+// SetCDPEndpoint makes the host connect to an existing Chromium instead of
+// launching one. It takes effect on the next start.
+func (b *BrowserHost) SetCDPEndpoint(endpoint string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cdpEndpoint = endpoint
+	b.settings.CDPEndpoint = endpoint
+	if endpoint != "" {
+		b.browser = BrowserChromium
+		b.settings.Browser = BrowserChromium
+	}
+}
+
+// resolveCDPEndpoint returns the browser websocket URL for an endpoint,
+// asking /json/version when given the HTTP debugging address. Chromium
+// builds that URL from the request's Host header, so it stays reachable from
+// here even when the browser runs in another container.
+func resolveCDPEndpoint(endpoint string, timeout time.Duration) (string, error) {
+	if strings.HasPrefix(endpoint, "ws://") || strings.HasPrefix(endpoint, "wss://") {
+		return endpoint, nil
+	}
+	return waitForWSEndpointURL(endpoint+"/json/version", timeout)
+}
+
+// connectLocked is startLocked for a CDP endpoint. The browser and its
+// default context (extensions, logins) belong to the user: the host opens its
+// own tab for "main", hooks routes and HAR into its own tabs only and, on
+// stop, closes only the tabs it opened and disconnects.
+func (b *BrowserHost) connectLocked() error {
+	if err := playwright.Install(&playwright.RunOptions{SkipInstallBrowsers: true}); err != nil {
+		return fmt.Errorf("install playwright: %w", err)
+	}
+	ws, err := resolveCDPEndpoint(b.cdpEndpoint, 10*time.Second)
+	if err != nil {
+		return err
+	}
+
+	pw, err := playwright.Run()
+	if err != nil {
+		return fmt.Errorf("start playwright: %w", err)
+	}
+	browser, err := pw.Chromium.ConnectOverCDP(ws)
+	if err != nil {
+		pw.Stop()
+		return fmt.Errorf("connect over CDP to %s: %w", b.cdpEndpoint, err)
+	}
+	contexts := browser.Contexts()
+	if len(contexts) == 0 {
+		_ = browser.Close()
+		pw.Stop()
+		return errors.New("CDP endpoint has no default browser context")
+	}
+	context := contexts[0]
+
+	mainPage, err := context.NewPage()
+	if err != nil {
+		_ = browser.Close()
+		pw.Stop()
+		return err
+	}
+	identity, err := b.describePage(context, mainPage)
+	if err != nil {
+		_ = mainPage.Close()
+		_ = browser.Close()
+		pw.Stop()
+		return err
+	}
+
+	b.pw = pw
+	b.external = browser
+	b.context = context
+	b.ws = ws
+	b.browser = BrowserChromium
+	b.settings = BrowserContextSettings{Browser: BrowserChromium, CDPEndpoint: b.cdpEndpoint}
+	b.registry["main"] = pageHolder{page: mainPage, targetID: identity.TargetID}
+	b.attachConsoleLocked("", "main", mainPage)
+	b.hookContextCloseLocked(context)
+	b.crashes.healthy("")
+	if err := b.hookInterceptionLocked(mainPage); err != nil {
+		return err
+	}
+	b.routeInstalled = b.routes.len() > 0
+	return nil
+}
+
+// CheckExternalContext rejects tools that change a whole browser context
+// when that context is the user's own, reached through --cdp-endpoint.
+// Named contexts are created by the host and stay allowed.
+func CheckExternalContext(settings BrowserContextSettings, contextName, tool string) error {
+	if settings.CDPEndpoint == "" || contextName != "" {
+		return nil
+	}
+	switch tool {
+	case "cookies_clear":
+		return fmt.Errorf("cookies clear would delete cookies of your own browser at %s (--cdp-endpoint); use a named --context", settings.CDPEndpoint)
+	case "state_load":
+		return fmt.Errorf("state load would overwrite storage of your own browser at %s (--cdp-endpoint); use a named --context", settings.CDPEndpoint)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package devbrowser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeCDPEndpoint(t *testing.T) {
+	cases := map[string]string{
+		"":                                    "",
+		" http://127.0.0.1:9222/ ":            "http://127.0.0.1:9222",
+		"ws://chrome:9222/devtools/browser/x": "ws://chrome:9222/devtools/browser/x",
+		"wss://remote.example/devtools/b":     "wss://remote.example/devtools/b",
+	}
+	for raw, want := range cases {
+		got, err := NormalizeCDPEndpoint(raw)
+		if err != nil || got != want {
+			t.Fatalf("NormalizeCDPEndpoint(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"127.0.0.1:9222", "ftp://host:9222", "http://"} {
+		if _, err := NormalizeCDPEndpoint(raw); err == nil {
+			t.Fatalf("expected error for %q", raw)
+		}
+	}
+}
+
+func TestResolveCDPEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/json/version" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"webSocketDebuggerUrl":"ws://` + r.Host + `/devtools/browser/abc"}`))
+	}))
+	defer srv.Close()
+
+	got, err := resolveCDPEndpoint(srv.URL, time.Second)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if want := "ws://" + srv.Listener.Addr().String() + "/devtools/browser/abc"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if got, _ := resolveCDPEndpoint("ws://chrome:9222/devtools/browser/x", time.Second); got != "ws://chrome:9222/devtools/browser/x" {
+		t.Fatalf("expected ws endpoint to pass through, got %q", got)
+	}
+}
+
+func TestEffectiveContextMatchesCDPEndpoint(t *testing.T) {
+	launched := normalizeContextRequest("", true, nil, "")
+	requested := normalizeContextRequest("", true, nil, "")
+	requested.CDPEndpoint = "http://127.0.0.1:9222"
+	if effectiveContextMatches(launched, requested) {
+		t.Fatal("expected switching to a CDP endpoint to reconfigure")
+	}
+	if got := describeContextDiff(launched, requested); got != "cdp-endpoint=http://127.0.0.1:9222" {
+		t.Fatalf("unexpected diff %q", got)
+	}
+
+	// Launch options (window, headless) do not apply to a connected browser.
+	connected := BrowserContextSettings{Browser: BrowserChromium, CDPEndpoint: "http://127.0.0.1:9222"}
+	if !effectiveContextMatches(connected, requested) {
+		t.Fatal("expected same endpoint to match")
+	}
+	if got := describeContextDiff(connected, launched); got != "cdp-endpoint=none" {
+		t.Fatalf("unexpected diff %q", got)
+	}
+}
+
+func TestSetCDPEndpoint(t *testing.T) {
+	b := NewBrowserHost("test", BrowserFirefox, true, 0, nil, "")
+	b.SetCDPEndpoint("http://127.0.0.1:9222")
+	settings := b.ContextSettings()
+	if settings.CDPEndpoint != "http://127.0.0.1:9222" || settings.Browser != BrowserChromium {
+		t.Fatalf("unexpected settings: %+v", settings)
+	}
+}
+
+func TestDecodeHealthCDPEndpoint(t *testing.T) {
+	health := decodeDaemonHealthMap(map[string]any{
+		"ok":      true,
+		"context": map[string]any{"browser": "chromium", "cdpEndpoint": "http://127.0.0.1:9222"},
+	})
+	if health.Context.CDPEndpoint != "http://127.0.0.1:9222" {
+		t.Fatalf("expected endpoint to round-trip, got %+v", health.Context)
+	}
+}
+
+func TestCheckExternalContext(t *testing.T) {
+	external := BrowserContextSettings{Browser: BrowserChromium, CDPEndpoint: "http://127.0.0.1:9222"}
+	for _, tool := range []string{"cookies_clear", "state_load"} {
+		if err := CheckExternalContext(external, "", tool); err == nil || !strings.Contains(err.Error(), "--cdp-endpoint") {
+			t.Fatalf("%s on the user's context should fail, got %v", tool, err)
+		}
+		if err := CheckExternalContext(external, "ci", tool); err != nil {
+			t.Fatalf("%s in a named context: %v", tool, err)
+		}
+		if err := CheckExternalContext(BrowserContextSettings{Browser: BrowserChromium}, "", tool); err != nil {
+			t.Fatalf("%s on a launched browser: %v", tool, err)
+		}
+	}
+	if err := CheckExternalContext(external, "", "cookies_list"); err != nil {
+		t.Fatalf("cookies_list: %v", err)
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
@@ -24,10 +26,21 @@ type DaemonState struct {
 	Version    string                 `json:"version"`
 	Context    BrowserContextSettings `json:"context"`
 	PageURL    string                 `json:"pageURL,omitempty"`
+	// BuildVersion and AssetHash identify the binary that started the daemon.
+	BuildVersion string `json:"buildVersion,omitempty"`
+	AssetHash    string `json:"assetHash,omitempty"`
+	// Socket is set instead of Host/Port when the daemon listens on a Unix
+	// socket.
+	Socket string `json:"socket,omitempty"`
+	// Token authenticates requests to the daemon; the file is mode 0600.
+	Token string `json:"token,omitempty"`
 }
 
 type PageSessionInfo struct {
 	WSEndpoint string
+	// Browser is the daemon's engine; without CDP WSEndpoint and TargetID are
+	// empty and the page is driven through the daemon.
+	Browser string
 	PageIdentity
 }
 
@@ -44,6 +57,7 @@ func ReadState(profile string) (*DaemonState, error) {
 	if err := json.Unmarshal(data, &state); err != nil {
 		return nil, err
 	}
+	rememberDaemon(&state)
 	return &state, nil
 }
 
@@ -52,10 +66,7 @@ func DaemonBaseURL(profile string) string {
 	if err != nil || state == nil {
 		return ""
 	}
-	if state.Host == "" || state.Port == 0 {
-		return ""
-	}
-	return fmt.Sprintf("http://%s:%d", state.Host, state.Port)
+	return daemonBaseURL(state.Host, state.Port, state.Socket)
 }
 
 func HTTPJSON(method string, url string, body map[string]any, timeout time.Duration) (map[string]any, error) {
@@ -75,8 +86,12 @@ func HTTPJSON(method string, url string, body map[string]any, timeout time.Durat
 	if payload != nil {
 		req.Header.Set("Content-Type", "application/json; charset=utf-8")
 	}
+	ep := lookupDaemon(url)
+	if ep.token != "" {
+		req.Header.Set("Authorization", "Bearer "+ep.token)
+	}
 
-	client := &http.Client{Timeout: timeout}
+	client := daemonHTTPClient(ep, timeout)
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -90,20 +105,124 @@ func HTTPJSON(method string, url string, body map[string]any, timeout time.Durat
 	return data, nil
 }
 
+// StreamJSON issues a GET against a daemon streaming endpoint and calls fn for
+// each NDJSON object until the stream ends or fn returns an error. There is
+// no overall timeout.
+func StreamJSON(url string, fn func(map[string]any) error) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	ep := lookupDaemon(url)
+	if ep.token != "" {
+		req.Header.Set("Authorization", "Bearer "+ep.token)
+	}
+	resp, err := daemonHTTPClient(ep, 0).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		var data map[string]any
+		if err := dec.Decode(&data); err == nil && data["error"] != nil {
+			return fmt.Errorf("%v", data["error"])
+		}
+		return fmt.Errorf("stream failed: %s", resp.Status)
+	}
+	for {
+		var data map[string]any
+		if err := dec.Decode(&data); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := fn(data); err != nil {
+			return err
+		}
+	}
+}
+
+// HoldPage takes a page's turn in the daemon's page queue through a
+// /pages/{name}/hold URL, waiting up to wait for it. The page stays held until
+// the returned func is called (or the process exits).
+func HoldPage(url, op string, wait time.Duration) (func(), error) {
+	payload, err := json.Marshal(map[string]any{"op": op, "wait_timeout_ms": wait.Milliseconds()})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	ep := lookupDaemon(url)
+	if ep.token != "" {
+		req.Header.Set("Authorization", "Bearer "+ep.token)
+	}
+	resp, err := daemonHTTPClient(ep, 0).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if ok, _ := data["ok"].(bool); !ok {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%v", data["error"])
+	}
+	var once sync.Once
+	return func() { once.Do(func() { resp.Body.Close() }) }, nil
+}
+
 func IsDaemonHealthy(profile string) bool {
 	health, err := ReadDaemonHealth(profile)
 	return err == nil && health != nil && health.OK
 }
 
-func EnsureDaemon(profile string, headless bool, window *WindowSize, device string) (DaemonStartResult, error) {
+// EnsureDaemon starts the profile's daemon or brings a running one to the
+// requested settings. A non-empty cdpEndpoint connects to that Chromium
+// instead of launching one.
+func EnsureDaemon(profile, browser string, headless bool, window *WindowSize, device, cdpEndpoint string) (DaemonStartResult, error) {
 	if window != nil && strings.TrimSpace(device) != "" {
 		return DaemonStartResult{}, errors.New("use either --window-size/--window-scale or --device")
 	}
 
-	requested := normalizeContextRequest(headless, window, device)
+	browser, err := NormalizeBrowserEngine(browser)
+	if err != nil {
+		return DaemonStartResult{}, err
+	}
+	cdpEndpoint, err = NormalizeCDPEndpoint(cdpEndpoint)
+	if err != nil {
+		return DaemonStartResult{}, err
+	}
+	if cdpEndpoint != "" && browser != BrowserChromium {
+		return DaemonStartResult{}, errors.New("--cdp-endpoint requires --browser chromium")
+	}
+	requested := normalizeContextRequest(browser, headless, window, device)
+	requested.CDPEndpoint = cdpEndpoint
+	// upgrade is set when a daemon of another build is being replaced; its
+	// pages are carried over to the new one.
+	upgrade := ""
+	var upgradeArgs []string
+	var snapshot daemonSnapshot
+	var snapshotErr error
 	if health, err := ReadDaemonHealth(profile); err == nil && health != nil && health.OK {
-		baseURL := fmt.Sprintf("http://%s:%d", health.Host, health.Port)
-		if strings.TrimSpace(health.Version) == "" || strings.TrimSpace(health.Version) != DaemonVersion() {
+		baseURL := daemonBaseURL(health.Host, health.Port, health.Socket)
+		mismatch := daemonVersionMismatch(*health)
+		warning := ""
+		if mismatch != "" && envTruthy(EnvNoAutoRestart) {
+			warning = fmt.Sprintf("daemon was started by another build (%s); restart it with `stop` to upgrade", mismatch)
+			mismatch = ""
+		}
+		if mismatch != "" {
+			upgrade = mismatch
+			upgradeArgs = upgradeDaemonArgs(*health)
+			snapshot, snapshotErr = captureDaemonPages(baseURL)
 			if _, err := StopDaemon(profile); err != nil {
 				return DaemonStartResult{}, fmt.Errorf("failed to stop existing dev-browser daemon (profile=%s): %w", profile, err)
 			}
@@ -115,12 +234,15 @@ func EnsureDaemon(profile string, headless bool, window *WindowSize, device stri
 				Profile:    profile,
 				PageURL:    health.PageURL,
 				WSEndpoint: health.WSEndpoint,
+				Warning:    warning,
 			}, nil
 		} else {
 			data, err := HTTPJSON(http.MethodPost, baseURL+"/reconfigure", map[string]any{
-				"headless": requested.Headless,
-				"window":   requested.Window,
-				"device":   requested.Device,
+				"browser":     requested.Browser,
+				"headless":    requested.Headless,
+				"window":      requested.Window,
+				"device":      requested.Device,
+				"cdpEndpoint": requested.CDPEndpoint,
 			}, 90*time.Second)
 			if err != nil {
 				return DaemonStartResult{}, err
@@ -137,12 +259,13 @@ func EnsureDaemon(profile string, headless bool, window *WindowSize, device stri
 				Profile:    profile,
 				PageURL:    updated.PageURL,
 				WSEndpoint: updated.WSEndpoint,
+				Warning:    warning,
 			}, nil
 		}
 	}
 
-	dir := StateDir(profile)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
+	dir, err := EnsureStateDir(profile)
+	if err != nil {
 		return DaemonStartResult{}, err
 	}
 	logPath := filepath.Join(dir, "daemon.log")
@@ -152,7 +275,7 @@ func EnsureDaemon(profile string, headless bool, window *WindowSize, device stri
 		return DaemonStartResult{}, err
 	}
 
-	args := []string{"--daemon", "--profile", profile}
+	args := []string{"--daemon", "--profile", profile, "--browser", requested.Browser}
 	if requested.Headless {
 		args = append(args, "--headless")
 	}
@@ -162,6 +285,10 @@ func EnsureDaemon(profile string, headless bool, window *WindowSize, device stri
 	if strings.TrimSpace(requested.Device) != "" {
 		args = append(args, "--device", requested.Device)
 	}
+	// Always passed so DEV_BROWSER_CDP_ENDPOINT cannot override a request to
+	// launch.
+	args = append(args, "--cdp-endpoint", requested.CDPEndpoint)
+	args = append(args, upgradeArgs...)
 
 	cmd := exec.Command(exe, args...)
 	configureDaemonProcess(cmd)
@@ -180,22 +307,65 @@ func EnsureDaemon(profile string, headless bool, window *WindowSize, device stri
 	deadline := time.Now().Add(30 * time.Second)
 	for time.Now().Before(deadline) {
 		if health, err := ReadDaemonHealth(profile); err == nil && health != nil && health.OK {
-			return DaemonStartResult{
+			result := DaemonStartResult{
 				Action:     DaemonActionStarted,
 				Context:    health.Context,
-				BaseURL:    fmt.Sprintf("http://%s:%d", health.Host, health.Port),
+				BaseURL:    daemonBaseURL(health.Host, health.Port, health.Socket),
 				Profile:    profile,
 				PageURL:    health.PageURL,
 				WSEndpoint: health.WSEndpoint,
-			}, nil
+			}
+			if upgrade != "" {
+				result.Action = DaemonActionReconfigured
+				result.Reason = upgrade
+				if snapshotErr == nil {
+					snapshotErr = restoreDaemonPages(result.BaseURL, snapshot)
+				}
+				warnings := []string{}
+				if snapshotErr != nil {
+					warnings = append(warnings, fmt.Sprintf("pages not fully restored after upgrade: %v", snapshotErr))
+				}
+				if len(snapshot.Dropped) > 0 {
+					warnings = append(warnings, "not carried over by the upgrade: "+strings.Join(snapshot.Dropped, ", "))
+				}
+				result.Warning = strings.Join(warnings, "; ")
+				if restored, err := ReadDaemonHealth(profile); err == nil && restored != nil {
+					result.PageURL = restored.PageURL
+				}
+			}
+			return result, nil
 		}
 		time.Sleep(200 * time.Millisecond)
 	}
 	return DaemonStartResult{}, fmt.Errorf("timed out waiting for dev-browser daemon (profile=%s). See %s", profile, logPath)
 }
 
-func StartDaemon(profile string, headless bool, window *WindowSize, device string) error {
-	_, err := EnsureDaemon(profile, headless, window, device)
+// EnsureContext creates a named context in a running daemon. With apply set,
+// an existing context is recreated when window/device differ.
+func EnsureContext(baseURL, name string, window *WindowSize, device string, apply bool) (ContextStartResult, error) {
+	data, err := HTTPJSON(http.MethodPost, baseURL+"/contexts", map[string]any{
+		"name":   name,
+		"window": window,
+		"device": strings.TrimSpace(device),
+		"apply":  apply,
+	}, 90*time.Second)
+	if err != nil {
+		return ContextStartResult{}, err
+	}
+	if ok, _ := data["ok"].(bool); !ok {
+		return ContextStartResult{}, fmt.Errorf("context %s: %v", name, data["error"])
+	}
+	result := ContextStartResult{}
+	result.Action = DaemonAction(fmt.Sprint(data["action"]))
+	result.Reason, _ = data["reason"].(string)
+	if b, err := json.Marshal(data["context"]); err == nil {
+		_ = json.Unmarshal(b, &result.Context)
+	}
+	return result, nil
+}
+
+func StartDaemon(profile, browser string, headless bool, window *WindowSize, device string) error {
+	_, err := EnsureDaemon(profile, browser, headless, window, device, "")
 	return err
 }
 
@@ -225,16 +395,16 @@ func StopDaemon(profile string) (bool, error) {
 	return true, nil
 }
 
-func EnsurePage(profile string, headless bool, page string, window *WindowSize, device string) (string, string, error) {
-	info, err := EnsurePageInfo(profile, headless, page, window, device)
+func EnsurePage(profile, browser string, headless bool, page string, window *WindowSize, device string) (string, string, error) {
+	info, err := EnsurePageInfo(profile, browser, headless, page, window, device)
 	if err != nil {
 		return "", "", err
 	}
 	return info.WSEndpoint, info.TargetID, nil
 }
 
-func EnsurePageInfo(profile string, headless bool, page string, window *WindowSize, device string) (PageSessionInfo, error) {
-	result, err := EnsureDaemon(profile, headless, window, device)
+func EnsurePageInfo(profile, browser string, headless bool, page string, window *WindowSize, device string) (PageSessionInfo, error) {
+	result, err := EnsureDaemon(profile, browser, headless, window, device, "")
 	if err != nil {
 		return PageSessionInfo{}, err
 	}
@@ -293,22 +463,55 @@ func decodeDaemonHealthMap(data map[string]any) DaemonHealth {
 	if ok, _ := data["ok"].(bool); ok {
 		health.OK = true
 	}
+	health.Status, _ = data["status"].(string)
+	if raw, ok := data["crashes"]; ok {
+		if b, err := json.Marshal(raw); err == nil {
+			_ = json.Unmarshal(b, &health.Crashes)
+		}
+	}
+	if raw, ok := data["degraded"].([]any); ok {
+		for _, v := range raw {
+			if reason, ok := v.(string); ok {
+				health.Degraded = append(health.Degraded, reason)
+			}
+		}
+	}
 	health.PID = intValue(data["pid"])
 	health.Host, _ = data["host"].(string)
 	health.Port = intValue(data["port"])
+	health.Socket, _ = data["socket"].(string)
 	health.Profile, _ = data["profile"].(string)
 	health.CDPPort = intValue(data["cdpPort"])
 	health.WSEndpoint, _ = data["wsEndpoint"].(string)
 	health.Version, _ = data["version"].(string)
+	health.BuildVersion, _ = data["buildVersion"].(string)
+	health.AssetHash, _ = data["assetHash"].(string)
 	health.PageURL, _ = data["pageURL"].(string)
 	if raw, ok := data["context"].(map[string]any); ok {
 		health.Context = decodeBrowserContextSettings(raw)
 	}
+	if raw, ok := data["contexts"]; ok {
+		if b, err := json.Marshal(raw); err == nil {
+			_ = json.Unmarshal(b, &health.Contexts)
+		}
+	}
+	if raw, ok := data["queues"]; ok {
+		if b, err := json.Marshal(raw); err == nil {
+			_ = json.Unmarshal(b, &health.Queues)
+		}
+	}
+	if raw, ok := data["resources"].(map[string]any); ok {
+		if b, err := json.Marshal(raw); err == nil {
+			health.Resources = &DaemonResources{}
+			_ = json.Unmarshal(b, health.Resources)
+		}
+	}
 	return health
 }
 
 func decodeBrowserContextSettings(data map[string]any) BrowserContextSettings {
 	settings := BrowserContextSettings{}
+	settings.Browser, _ = data["browser"].(string)
 	if headless, ok := data["headless"].(bool); ok {
 		settings.Headless = headless
 	}
@@ -335,6 +538,7 @@ func decodeBrowserContextSettings(data map[string]any) BrowserContextSettings {
 		settings.HasTouch = touch
 	}
 	settings.UserAgent, _ = data["userAgent"].(string)
+	settings.CDPEndpoint, _ = data["cdpEndpoint"].(string)
 	return settings
 }
 
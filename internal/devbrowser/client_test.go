@@ -10,7 +10,7 @@ func TestStartDaemonRejectsDeviceAndWindow(t *testing.T) {
 	_, _ = StopDaemon(profile)
 
 	window := &WindowSize{Width: 100, Height: 200}
-	err := StartDaemon(profile, true, window, "Pixel 5")
+	err := StartDaemon(profile, "", true, window, "Pixel 5")
 	if err == nil {
 		t.Fatalf("expected error for device + window")
 	}
@@ -45,12 +45,12 @@ func TestEffectiveContextMatchesRequested(t *testing.T) {
 		Device:   "iPhone 13",
 		Window:   &WindowSize{Width: 390, Height: 844},
 	}
-	requested := normalizeContextRequest(true, nil, "iphone 13")
+	requested := normalizeContextRequest("", true, nil, "iphone 13")
 	if !effectiveContextMatches(current, requested) {
 		t.Fatalf("expected contexts to match: current=%+v requested=%+v", current, requested)
 	}
 
-	requested = normalizeContextRequest(true, &WindowSize{Width: 1280, Height: 800}, "")
+	requested = normalizeContextRequest("", true, &WindowSize{Width: 1280, Height: 800}, "")
 	if effectiveContextMatches(current, requested) {
 		t.Fatalf("expected window mismatch to fail: current=%+v requested=%+v", current, requested)
 	}
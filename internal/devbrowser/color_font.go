@@ -11,11 +11,15 @@ type ColorInfoOptions struct {
 }
 
 func ColorInfo(page playwright.Page, ref string, engine string, opts ColorInfoOptions) (map[string]interface{}, error) {
-	if err := ensureInjected(page, engine); err != nil {
+	target, local, frame, err := resolveRefTarget(page, ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureInjected(target, engine); err != nil {
 		return nil, err
 	}
-	payload := map[string]interface{}{"ref": ref, "opts": map[string]interface{}{"includeTransparent": opts.IncludeTransparent}}
-	res, err := page.Evaluate(`(p) => globalThis.__devBrowser_colorInfo(p.ref, p.opts)`, payload)
+	payload := map[string]interface{}{"ref": local, "opts": map[string]interface{}{"includeTransparent": opts.IncludeTransparent}}
+	res, err := target.Evaluate(`(p) => globalThis.__devBrowser_colorInfo(p.ref, p.opts)`, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -23,14 +27,21 @@ func ColorInfo(page playwright.Page, ref string, engine string, opts ColorInfoOp
 	if !ok {
 		return nil, fmt.Errorf("unexpected colorInfo result")
 	}
+	if frame != "" {
+		m["ref"] = ref
+	}
 	return m, nil
 }
 
 func FontInfo(page playwright.Page, ref string, engine string) (map[string]interface{}, error) {
-	if err := ensureInjected(page, engine); err != nil {
+	target, local, frame, err := resolveRefTarget(page, ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureInjected(target, engine); err != nil {
 		return nil, err
 	}
-	res, err := page.Evaluate(`(ref) => globalThis.__devBrowser_fontInfo(ref)`, ref)
+	res, err := target.Evaluate(`(ref) => globalThis.__devBrowser_fontInfo(ref)`, local)
 	if err != nil {
 		return nil, err
 	}
@@ -38,5 +49,8 @@ func FontInfo(page playwright.Page, ref string, engine string) (map[string]inter
 	if !ok {
 		return nil, fmt.Errorf("unexpected fontInfo result")
 	}
+	if frame != "" {
+		m["ref"] = ref
+	}
 	return m, nil
 }
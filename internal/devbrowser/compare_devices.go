@@ -0,0 +1,443 @@
+package devbrowser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const (
+	compareGridGap    = 16
+	compareMaxErrors  = 20
+	compareGridFile   = "grid.png"
+	compareReportFile = "compare.json"
+)
+
+// CompareDevicesOptions configures a compare-devices run. Each device gets a
+// throwaway browser context next to the persistent one, so the daemon's pages
+// and emulation settings are left untouched.
+type CompareDevicesOptions struct {
+	URL     string
+	Devices []string
+
+	WaitState      string
+	TimeoutMs      int
+	MinWaitMs      int
+	SnapshotEngine string
+	FullPage       bool
+	Columns        int
+
+	// ShareState seeds each device context with the cookies and localStorage
+	// of the source page's context.
+	ShareState bool
+
+	ArtifactDir string
+}
+
+type CompareDeviceSnapshot struct {
+	Engine string `json:"engine"`
+	Items  int    `json:"items"`
+	Path   string `json:"path,omitempty"`
+}
+
+type CompareDeviceConsole struct {
+	Counts DiagnoseConsoleCounts `json:"counts"`
+	Errors []ConsoleEntry        `json:"errors"`
+}
+
+type CompareDeviceNetwork struct {
+	Requests   int64 `json:"requests"`
+	Failed     int64 `json:"failed"`
+	HTTPErrors int64 `json:"http_errors"`
+}
+
+type CompareDeviceResult struct {
+	Label             string                `json:"label"`
+	Device            string                `json:"device,omitempty"`
+	Viewport          WindowSize            `json:"viewport"`
+	DeviceScaleFactor float64               `json:"device_scale_factor"`
+	IsMobile          bool                  `json:"is_mobile"`
+	URL               string                `json:"url"`
+	Title             string                `json:"title"`
+	Screenshot        string                `json:"screenshot,omitempty"`
+	Tile              *GridTile             `json:"tile,omitempty"`
+	Snapshot          CompareDeviceSnapshot `json:"snapshot"`
+	Console           CompareDeviceConsole  `json:"console"`
+	Network           CompareDeviceNetwork  `json:"network"`
+	Error             string                `json:"error,omitempty"`
+}
+
+// GridTile is the position of one screenshot inside the composite grid.
+type GridTile struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type CompareDevicesReport struct {
+	URL         string                `json:"url"`
+	TS          string                `json:"ts"`
+	ArtifactDir string                `json:"artifact_dir"`
+	Grid        string                `json:"grid,omitempty"`
+	GridSize    *WindowSize           `json:"grid_size,omitempty"`
+	Report      string                `json:"report,omitempty"`
+	Devices     []CompareDeviceResult `json:"devices"`
+}
+
+type compareDevice struct {
+	label string
+	name  string
+	desc  *playwright.DeviceDescriptor
+}
+
+// ParseDeviceList splits a comma-separated --devices value, trimming entries
+// and dropping empties and case-insensitive duplicates.
+func ParseDeviceList(raw string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, part := range strings.Split(raw, ",") {
+		label := strings.TrimSpace(part)
+		key := strings.ToLower(label)
+		if label == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, label)
+	}
+	return out
+}
+
+// resolveCompareDevice maps a label to a Playwright device descriptor. Labels
+// that are not known devices but end in WIDTHxHEIGHT ("Desktop 1440x900",
+// "1280x800") become a plain desktop viewport at scale 1.
+func resolveCompareDevice(pw *playwright.Playwright, label string) (compareDevice, error) {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return compareDevice{}, errors.New("empty device name")
+	}
+	name, desc, err := resolveDeviceProfile(pw, label)
+	if err == nil && desc != nil {
+		return compareDevice{label: label, name: name, desc: desc}, nil
+	}
+	if size := trailingViewport(label); size != nil {
+		return compareDevice{label: label, desc: &playwright.DeviceDescriptor{
+			Viewport:          &playwright.Size{Width: size.Width, Height: size.Height},
+			DeviceScaleFactor: 1,
+		}}, nil
+	}
+	if err == nil {
+		err = fmt.Errorf("unknown device %q", label)
+	}
+	return compareDevice{}, err
+}
+
+func trailingViewport(label string) *WindowSize {
+	fields := strings.Fields(label)
+	if len(fields) == 0 {
+		return nil
+	}
+	size, err := ParseWindowSize(fields[len(fields)-1])
+	if err != nil {
+		return nil
+	}
+	return size
+}
+
+// newCompareContextOptions reuses the persistent-context device mapping for a
+// regular browser.NewContext call.
+func newCompareContextOptions(desc *playwright.DeviceDescriptor) playwright.BrowserNewContextOptions {
+	var launch playwright.BrowserTypeLaunchPersistentContextOptions
+	applyDeviceDescriptor(&launch, desc)
+	return newContextOptionsFromLaunch(&launch)
+}
+
+// CompareDevices loads opts.URL once per device and writes per-device
+// screenshots plus a composite grid into opts.ArtifactDir.
+func CompareDevices(pw *playwright.Playwright, source playwright.Page, opts CompareDevicesOptions) (*CompareDevicesReport, error) {
+	if strings.TrimSpace(opts.URL) == "" {
+		return nil, errors.New("url is required")
+	}
+	if len(opts.Devices) == 0 {
+		return nil, errors.New("at least one device is required")
+	}
+	if strings.TrimSpace(opts.ArtifactDir) == "" {
+		return nil, errors.New("artifact dir is required")
+	}
+	if strings.TrimSpace(opts.WaitState) == "" {
+		opts.WaitState = "networkidle"
+	}
+	if opts.TimeoutMs <= 0 {
+		opts.TimeoutMs = 45_000
+	}
+	if opts.MinWaitMs < 0 {
+		opts.MinWaitMs = 0
+	}
+	if strings.TrimSpace(opts.SnapshotEngine) == "" {
+		opts.SnapshotEngine = "simple"
+	}
+
+	devices := make([]compareDevice, 0, len(opts.Devices))
+	for _, label := range opts.Devices {
+		dev, err := resolveCompareDevice(pw, label)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, dev)
+	}
+
+	if source == nil || source.Context() == nil || source.Context().Browser() == nil {
+		return nil, errors.New("compare-devices requires a connected browser")
+	}
+	browser := source.Context().Browser()
+
+	var state *playwright.OptionalStorageState
+	if opts.ShareState {
+		st, err := source.Context().StorageState()
+		if err != nil {
+			return nil, fmt.Errorf("read storage state: %w", err)
+		}
+		state = &playwright.OptionalStorageState{Cookies: cookiesForAdd(st.Cookies), Origins: st.Origins}
+	}
+
+	if err := os.MkdirAll(opts.ArtifactDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	report := &CompareDevicesReport{
+		URL:         opts.URL,
+		TS:          time.Now().UTC().Format(time.RFC3339Nano),
+		ArtifactDir: opts.ArtifactDir,
+		Devices:     make([]CompareDeviceResult, 0, len(devices)),
+	}
+	shots := []image.Image{}
+	shotIndex := []int{}
+	for i, dev := range devices {
+		res, shot := compareOnDevice(browser, dev, state, i, opts)
+		if shot != nil {
+			shots = append(shots, shot)
+			shotIndex = append(shotIndex, i)
+		}
+		report.Devices = append(report.Devices, res)
+	}
+
+	if len(shots) > 0 {
+		grid, tiles := composeGrid(shots, opts.Columns, compareGridGap)
+		for j, idx := range shotIndex {
+			tile := tiles[j]
+			report.Devices[idx].Tile = &tile
+		}
+		gridPath := filepath.Join(opts.ArtifactDir, compareGridFile)
+		if err := writePNG(gridPath, grid); err != nil {
+			return nil, fmt.Errorf("write grid: %w", err)
+		}
+		report.Grid = gridPath
+		report.GridSize = &WindowSize{Width: grid.Bounds().Dx(), Height: grid.Bounds().Dy()}
+	}
+
+	// Report file is best-effort; the caller still gets the in-memory report.
+	report.Report = filepath.Join(opts.ArtifactDir, compareReportFile)
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err == nil {
+		err = os.WriteFile(report.Report, append(b, '\n'), 0o644)
+	}
+	if err != nil {
+		report.Report = ""
+	}
+	return report, nil
+}
+
+func compareOnDevice(browser playwright.Browser, dev compareDevice, state *playwright.OptionalStorageState, index int, opts CompareDevicesOptions) (res CompareDeviceResult, shot image.Image) {
+	res = CompareDeviceResult{
+		Label:    dev.label,
+		Device:   dev.name,
+		IsMobile: dev.desc.IsMobile,
+		Snapshot: CompareDeviceSnapshot{Engine: opts.SnapshotEngine},
+		Console:  CompareDeviceConsole{Errors: []ConsoleEntry{}},
+	}
+	if vp := deviceWindowSize(dev.desc); vp != nil {
+		res.Viewport = *vp
+	}
+	res.DeviceScaleFactor = dev.desc.DeviceScaleFactor
+	if res.DeviceScaleFactor <= 0 {
+		res.DeviceScaleFactor = 1
+	}
+
+	ctxOpts := newCompareContextOptions(dev.desc)
+	ctxOpts.StorageState = state
+	bctx, err := browser.NewContext(ctxOpts)
+	if err != nil {
+		res.Error = fmt.Sprintf("new context: %v", err)
+		return res, nil
+	}
+	defer bctx.Close()
+	page, err := bctx.NewPage()
+	if err != nil {
+		res.Error = fmt.Sprintf("new page: %v", err)
+		return res, nil
+	}
+
+	console := newConsoleStore(defaultConsoleLogMax)
+	var requests, failed, httpErrors atomic.Int64
+	page.OnConsole(func(msg playwright.ConsoleMessage) { console.append(dev.label, msg) })
+	page.OnPageError(func(err error) { console.appendPageError(dev.label, err) })
+	page.OnRequest(func(playwright.Request) { requests.Add(1) })
+	page.OnRequestFailed(func(playwright.Request) { failed.Add(1) })
+	page.OnResponse(func(resp playwright.Response) {
+		if resp.Status() >= 400 {
+			httpErrors.Add(1)
+		}
+	})
+	// Named results so summaries are filled in on every return path.
+	defer func() {
+		res.Network = CompareDeviceNetwork{Requests: requests.Load(), Failed: failed.Load(), HTTPErrors: httpErrors.Load()}
+		entries, _ := console.list(dev.label, 0, 0)
+		res.Console = summarizeCompareConsole(entries)
+	}()
+
+	slug := fmt.Sprintf("%02d-%s", index+1, compareSlug(dev.label))
+	if _, err := RunCall(page, "goto", map[string]interface{}{
+		"url":        opts.URL,
+		"wait_until": "domcontentloaded",
+		"timeout_ms": opts.TimeoutMs,
+	}, opts.ArtifactDir); err != nil {
+		res.URL = safeString(page.URL())
+		res.Error = err.Error()
+		return res, nil
+	}
+	_, _ = RunCall(page, "wait", map[string]interface{}{
+		"state":       opts.WaitState,
+		"timeout_ms":  opts.TimeoutMs,
+		"min_wait_ms": opts.MinWaitMs,
+	}, opts.ArtifactDir)
+	res.URL = safeString(page.URL())
+	res.Title = safeTitle(page)
+
+	if snap, err := GetSnapshot(page, SnapshotOptions{Engine: opts.SnapshotEngine, Format: "list", IncludeHeadings: true, MaxItems: 200, MaxChars: 120_000}); err == nil {
+		res.Snapshot.Items = len(snap.Items)
+		path := filepath.Join(opts.ArtifactDir, slug+".snapshot.yaml")
+		if err := os.WriteFile(path, []byte(snap.Yaml), 0o644); err == nil {
+			res.Snapshot.Path = path
+		}
+	}
+
+	data, err := page.Screenshot(playwright.PageScreenshotOptions{
+		FullPage: playwright.Bool(opts.FullPage),
+		Scale:    playwright.ScreenshotScaleCss,
+		Timeout:  playwright.Float(float64(opts.TimeoutMs)),
+	})
+	if err != nil {
+		res.Error = fmt.Sprintf("screenshot: %v", err)
+		return res, nil
+	}
+	shot, err = png.Decode(bytes.NewReader(data))
+	if err != nil {
+		res.Error = fmt.Sprintf("decode screenshot: %v", err)
+		return res, nil
+	}
+	path := filepath.Join(opts.ArtifactDir, slug+".png")
+	if err := os.WriteFile(path, data, 0o644); err == nil {
+		res.Screenshot = path
+	}
+	return res, shot
+}
+
+func summarizeCompareConsole(entries []ConsoleEntry) CompareDeviceConsole {
+	out := CompareDeviceConsole{Errors: []ConsoleEntry{}}
+	for _, entry := range entries {
+		switch consoleLevelForType(entry.Type) {
+		case "error":
+			out.Counts.Error++
+			if len(out.Errors) < compareMaxErrors {
+				out.Errors = append(out.Errors, entry)
+			}
+		case "warning":
+			out.Counts.Warning++
+		default:
+			out.Counts.Info++
+		}
+	}
+	return out
+}
+
+func compareSlug(label string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(label) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		return "device"
+	}
+	return slug
+}
+
+// layoutGrid places tiles left to right, wrapping after columns tiles
+// (columns <= 0 keeps everything on one row). Rows are as tall as their
+// tallest tile and tiles are top-aligned.
+func layoutGrid(sizes []image.Point, columns, gap int) ([]image.Rectangle, image.Point) {
+	if columns <= 0 || columns > len(sizes) {
+		columns = len(sizes)
+	}
+	rects := make([]image.Rectangle, len(sizes))
+	total := image.Point{}
+	y := gap
+	for start := 0; start < len(sizes); start += columns {
+		end := start + columns
+		if end > len(sizes) {
+			end = len(sizes)
+		}
+		x := gap
+		rowHeight := 0
+		for i := start; i < end; i++ {
+			rects[i] = image.Rect(x, y, x+sizes[i].X, y+sizes[i].Y)
+			x += sizes[i].X + gap
+			if sizes[i].Y > rowHeight {
+				rowHeight = sizes[i].Y
+			}
+		}
+		if x > total.X {
+			total.X = x
+		}
+		y += rowHeight + gap
+	}
+	total.Y = y
+	return rects, total
+}
+
+func composeGrid(images []image.Image, columns, gap int) (*image.RGBA, []GridTile) {
+	sizes := make([]image.Point, len(images))
+	for i, img := range images {
+		sizes[i] = img.Bounds().Size()
+	}
+	rects, total := layoutGrid(sizes, columns, gap)
+	canvas := image.NewRGBA(image.Rect(0, 0, total.X, total.Y))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{C: color.RGBA{R: 0xe5, G: 0xe5, B: 0xe5, A: 0xff}}, image.Point{}, draw.Src)
+	tiles := make([]GridTile, len(images))
+	for i, img := range images {
+		draw.Draw(canvas, rects[i], img, img.Bounds().Min, draw.Src)
+		tiles[i] = GridTile{X: rects[i].Min.X, Y: rects[i].Min.Y, Width: rects[i].Dx(), Height: rects[i].Dy()}
+	}
+	return canvas, tiles
+}
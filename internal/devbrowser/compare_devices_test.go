@@ -0,0 +1,119 @@
+package devbrowser
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+func TestParseDeviceList(t *testing.T) {
+	got := ParseDeviceList(" iPhone 15, Pixel 7,,iphone 15 ,Desktop 1440x900 ")
+	want := []string{"iPhone 15", "Pixel 7", "Desktop 1440x900"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("entry %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+	if got := ParseDeviceList(" , "); len(got) != 0 {
+		t.Fatalf("expected empty list, got %v", got)
+	}
+}
+
+func TestResolveCompareDevice_ViewportLabels(t *testing.T) {
+	dev, err := resolveCompareDevice(nil, "Desktop 1440x900")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dev.name != "" || dev.desc.Viewport.Width != 1440 || dev.desc.Viewport.Height != 900 || dev.desc.IsMobile {
+		t.Fatalf("unexpected device: %+v", dev)
+	}
+	if _, err := resolveCompareDevice(nil, "1280x800"); err != nil {
+		t.Fatalf("expected bare size to resolve: %v", err)
+	}
+	for _, bad := range []string{"", "Laptop", "Desktop 0x900"} {
+		if _, err := resolveCompareDevice(nil, bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestNewCompareContextOptions(t *testing.T) {
+	opts := newCompareContextOptions(&playwright.DeviceDescriptor{
+		UserAgent:         "phone",
+		Viewport:          &playwright.Size{Width: 393, Height: 852},
+		DeviceScaleFactor: 3,
+		IsMobile:          true,
+		HasTouch:          true,
+	})
+	if opts.UserAgent == nil || *opts.UserAgent != "phone" {
+		t.Fatalf("expected user agent, got %+v", opts.UserAgent)
+	}
+	if opts.Viewport.Width != 393 || opts.Screen.Height != 852 || *opts.DeviceScaleFactor != 3 || !*opts.IsMobile || !*opts.HasTouch {
+		t.Fatalf("unexpected context options: %+v", opts)
+	}
+}
+
+func TestLayoutGrid(t *testing.T) {
+	sizes := []image.Point{{100, 200}, {50, 80}, {300, 100}}
+	rects, total := layoutGrid(sizes, 0, 10)
+	if rects[0] != image.Rect(10, 10, 110, 210) || rects[1] != image.Rect(120, 10, 170, 90) || rects[2] != image.Rect(180, 10, 480, 110) {
+		t.Fatalf("unexpected single-row layout: %v", rects)
+	}
+	if total != (image.Point{490, 220}) {
+		t.Fatalf("unexpected single-row size: %v", total)
+	}
+
+	rects, total = layoutGrid(sizes, 2, 10)
+	if rects[2] != image.Rect(10, 220, 310, 320) {
+		t.Fatalf("expected wrap to second row, got %v", rects[2])
+	}
+	if total != (image.Point{320, 330}) {
+		t.Fatalf("unexpected wrapped size: %v", total)
+	}
+}
+
+func TestComposeGrid_DrawsTiles(t *testing.T) {
+	red := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			red.Set(x, y, color.RGBA{R: 0xff, A: 0xff})
+		}
+	}
+	grid, tiles := composeGrid([]image.Image{red, red}, 0, 2)
+	if grid.Bounds().Dx() != 14 || grid.Bounds().Dy() != 8 {
+		t.Fatalf("unexpected grid size %v", grid.Bounds())
+	}
+	if tiles[1] != (GridTile{X: 8, Y: 2, Width: 4, Height: 4}) {
+		t.Fatalf("unexpected tile: %+v", tiles[1])
+	}
+	if got := grid.RGBAAt(8, 2); got.R != 0xff || got.G != 0 {
+		t.Fatalf("expected tile pixel, got %v", got)
+	}
+	if got := grid.RGBAAt(0, 0); got.R != got.G {
+		t.Fatalf("expected neutral background, got %v", got)
+	}
+}
+
+func TestCompareSlugAndConsoleSummary(t *testing.T) {
+	if got := compareSlug("Desktop 1440x900"); got != "desktop-1440x900" {
+		t.Fatalf("unexpected slug %q", got)
+	}
+	if got := compareSlug("Galaxy S9+"); got != "galaxy-s9" {
+		t.Fatalf("unexpected slug %q", got)
+	}
+	if got := compareSlug("!!"); got != "device" {
+		t.Fatalf("unexpected slug %q", got)
+	}
+
+	summary := summarizeCompareConsole([]ConsoleEntry{
+		{Type: "log"}, {Type: "warning"}, {Type: "error", Text: "boom"}, {Type: "pageerror", Text: "uncaught"},
+	})
+	if summary.Counts != (DiagnoseConsoleCounts{Error: 2, Warning: 1, Info: 1}) || len(summary.Errors) != 2 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+}
@@ -46,7 +46,7 @@ func newConsoleStore(max int) *consoleStore {
 	}
 }
 
-func (c *consoleStore) append(name string, msg playwright.ConsoleMessage) {
+func (c *consoleStore) append(name string, msg playwright.ConsoleMessage) ConsoleEntry {
 	entry := ConsoleEntry{
 		Type: msg.Type(),
 		Text: msg.Text(),
@@ -56,20 +56,21 @@ func (c *consoleStore) append(name string, msg playwright.ConsoleMessage) {
 		entry.Line = loc.LineNumber + 1
 		entry.Column = loc.ColumnNumber + 1
 	}
-	c.appendEntry(name, entry)
+	return c.appendEntry(name, entry)
 }
 
-func (c *consoleStore) appendPageError(name string, err error) {
+func (c *consoleStore) appendPageError(name string, err error) ConsoleEntry {
 	if err == nil {
-		return
+		return ConsoleEntry{}
 	}
-	c.appendEntry(name, ConsoleEntry{
+	return c.appendEntry(name, ConsoleEntry{
 		Type: "pageerror",
 		Text: err.Error(),
 	})
 }
 
-func (c *consoleStore) appendEntry(name string, entry ConsoleEntry) {
+// appendEntry stores entry and returns it with its ID and time filled in.
+func (c *consoleStore) appendEntry(name string, entry ConsoleEntry) ConsoleEntry {
 	entry.ID = atomic.AddInt64(&c.nextID, 1)
 	if entry.TimeMS == 0 {
 		entry.TimeMS = NowMS()
@@ -83,6 +84,7 @@ func (c *consoleStore) appendEntry(name string, entry ConsoleEntry) {
 	logs = append(logs, entry)
 	c.logs[name] = logs
 	c.mu.Unlock()
+	return entry
 }
 
 func (c *consoleStore) list(name string, since int64, limit int) ([]ConsoleEntry, int64) {
@@ -8,6 +8,7 @@ import (
 )
 
 type BrowserContextSettings struct {
+	Browser           string      `json:"browser,omitempty"`
 	Headless          bool        `json:"headless"`
 	Device            string      `json:"device,omitempty"`
 	Window            *WindowSize `json:"window,omitempty"`
@@ -17,6 +18,9 @@ type BrowserContextSettings struct {
 	IsMobile          bool        `json:"isMobile,omitempty"`
 	HasTouch          bool        `json:"hasTouch,omitempty"`
 	UserAgent         string      `json:"userAgent,omitempty"`
+	// CDPEndpoint is set when the daemon drives an existing Chromium over
+	// CDP instead of launching one; launch options then do not apply.
+	CDPEndpoint string `json:"cdpEndpoint,omitempty"`
 }
 
 type DaemonAction string
@@ -35,19 +39,40 @@ type DaemonStartResult struct {
 	Profile    string                 `json:"profile,omitempty"`
 	PageURL    string                 `json:"pageURL,omitempty"`
 	WSEndpoint string                 `json:"wsEndpoint,omitempty"`
+	// Warning is set when the daemon was reused despite a problem (e.g. a
+	// version mismatch under --no-auto-restart, or pages not restored).
+	Warning string `json:"warning,omitempty"`
 }
 
 type DaemonHealth struct {
 	OK         bool                   `json:"ok"`
+	Status     string                 `json:"status,omitempty"`
+	Crashes    []CrashReport          `json:"crashes,omitempty"`
+	Degraded   []string               `json:"degraded,omitempty"`
 	PID        int                    `json:"pid"`
 	Host       string                 `json:"host,omitempty"`
 	Port       int                    `json:"port,omitempty"`
+	Socket     string                 `json:"socket,omitempty"`
 	Profile    string                 `json:"profile,omitempty"`
 	CDPPort    int                    `json:"cdpPort,omitempty"`
 	WSEndpoint string                 `json:"wsEndpoint,omitempty"`
 	Version    string                 `json:"version,omitempty"`
 	Context    BrowserContextSettings `json:"context"`
+	Contexts   []ContextInfo          `json:"contexts,omitempty"`
 	PageURL    string                 `json:"pageURL,omitempty"`
+	Resources  *DaemonResources       `json:"resources,omitempty"`
+	Queues     []PageQueueStatus      `json:"queues,omitempty"`
+	// BuildVersion and AssetHash identify the binary that runs the daemon;
+	// EnsureDaemon restarts daemons of another build.
+	BuildVersion string `json:"buildVersion,omitempty"`
+	AssetHash    string `json:"assetHash,omitempty"`
+}
+
+// ContextStartResult reports what EnsureContext did for a named context.
+type ContextStartResult struct {
+	Action  DaemonAction `json:"action"`
+	Reason  string       `json:"reason,omitempty"`
+	Context ContextInfo  `json:"context"`
 }
 
 func cloneWindowSize(src *WindowSize) *WindowSize {
@@ -58,8 +83,9 @@ func cloneWindowSize(src *WindowSize) *WindowSize {
 	return &copy
 }
 
-func normalizeContextRequest(headless bool, window *WindowSize, device string) BrowserContextSettings {
+func normalizeContextRequest(browser string, headless bool, window *WindowSize, device string) BrowserContextSettings {
 	settings := BrowserContextSettings{
+		Browser:  browserEngineOrDefault(browser),
 		Headless: headless,
 		Device:   strings.TrimSpace(device),
 		Window:   cloneWindowSize(window),
@@ -72,6 +98,15 @@ func normalizeContextRequest(headless bool, window *WindowSize, device string) B
 }
 
 func effectiveContextMatches(current BrowserContextSettings, requested BrowserContextSettings) bool {
+	if current.CDPEndpoint != requested.CDPEndpoint {
+		return false
+	}
+	if requested.CDPEndpoint != "" {
+		return true
+	}
+	if browserEngineOrDefault(current.Browser) != browserEngineOrDefault(requested.Browser) {
+		return false
+	}
 	if current.Headless != requested.Headless {
 		return false
 	}
@@ -94,7 +129,14 @@ func windowSizesEqual(a, b *WindowSize) bool {
 }
 
 func formatContextSummary(settings BrowserContextSettings) string {
-	parts := []string{fmt.Sprintf("headless=%t", settings.Headless)}
+	if settings.CDPEndpoint != "" {
+		return fmt.Sprintf("cdp-endpoint=%s", settings.CDPEndpoint)
+	}
+	parts := []string{}
+	if engine := browserEngineOrDefault(settings.Browser); engine != BrowserChromium {
+		parts = append(parts, fmt.Sprintf("browser=%s", engine))
+	}
+	parts = append(parts, fmt.Sprintf("headless=%t", settings.Headless))
 	if device := strings.TrimSpace(settings.Device); device != "" {
 		parts = append(parts, fmt.Sprintf("device=%s", device))
 	}
@@ -120,7 +162,16 @@ func formatContextSummary(settings BrowserContextSettings) string {
 }
 
 func describeContextDiff(current BrowserContextSettings, requested BrowserContextSettings) string {
-	diffs := make([]string, 0, 3)
+	if current.CDPEndpoint != requested.CDPEndpoint {
+		if requested.CDPEndpoint != "" {
+			return fmt.Sprintf("cdp-endpoint=%s", requested.CDPEndpoint)
+		}
+		return "cdp-endpoint=none"
+	}
+	diffs := make([]string, 0, 4)
+	if engine := browserEngineOrDefault(requested.Browser); browserEngineOrDefault(current.Browser) != engine {
+		diffs = append(diffs, fmt.Sprintf("browser=%s", engine))
+	}
 	if current.Headless != requested.Headless {
 		diffs = append(diffs, fmt.Sprintf("headless=%t", requested.Headless))
 	}
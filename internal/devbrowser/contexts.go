@@ -0,0 +1,347 @@
+package devbrowser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// DefaultContextName addresses the persistent profile context. The empty
+// string means the same thing internally.
+const DefaultContextName = "default"
+
+var contextNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// namedContext is an isolated, non-persistent context living next to the
+// persistent one. Its storage is discarded when it closes.
+type namedContext struct {
+	context  playwright.BrowserContext
+	settings BrowserContextSettings
+	registry map[string]pageHolder
+}
+
+// ContextInfo describes one browser context in the daemon.
+type ContextInfo struct {
+	Name     string                 `json:"name"`
+	Settings BrowserContextSettings `json:"settings"`
+	Pages    []string               `json:"pages"`
+}
+
+type contextRestoreState struct {
+	Name      string
+	Requested BrowserContextSettings
+	Storage   *playwright.OptionalStorageState
+	Pages     []pageRestoreState
+}
+
+// NormalizeContextName validates a --context value and maps the default
+// context ("" or "default") to "".
+func NormalizeContextName(raw string) (string, error) {
+	name := strings.TrimSpace(raw)
+	if name == "" || name == DefaultContextName {
+		return "", nil
+	}
+	if !contextNameRe.MatchString(name) {
+		return "", fmt.Errorf("invalid context name %q (use letters, digits, '.', '_' or '-', max 64)", raw)
+	}
+	return name, nil
+}
+
+// pageLogKey keys console/network logs so equal page names in different
+// contexts do not share a buffer. Default-context pages keep their bare name.
+func pageLogKey(contextName, page string) string {
+	if contextName == "" {
+		return page
+	}
+	return contextName + "\x00" + page
+}
+
+func openPageNames(registry map[string]pageHolder) []string {
+	names := []string{}
+	for name, holder := range registry {
+		if holder.page != nil && !holder.page.IsClosed() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func capturePages(registry map[string]pageHolder) []pageRestoreState {
+	restore := []pageRestoreState{}
+	for _, name := range openPageNames(registry) {
+		restore = append(restore, pageRestoreState{Name: name, URL: registry[name].page.URL()})
+	}
+	return restore
+}
+
+// scopeLocked resolves the Playwright context and page registry for a context
+// name ("" is the persistent context).
+func (b *BrowserHost) scopeLocked(contextName string) (playwright.BrowserContext, map[string]pageHolder, error) {
+	if contextName == "" {
+		return b.context, b.registry, nil
+	}
+	nc, ok := b.contexts[contextName]
+	if !ok {
+		return nil, nil, fmt.Errorf("context %q not found", contextName)
+	}
+	return nc.context, nc.registry, nil
+}
+
+// ListContexts reports the default context first, then named contexts by name.
+func (b *BrowserHost) ListContexts() []ContextInfo {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := []ContextInfo{{
+		Name:     DefaultContextName,
+		Settings: cloneContextSettings(b.settings),
+		Pages:    openPageNames(b.registry),
+	}}
+	names := make([]string, 0, len(b.contexts))
+	for name := range b.contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		nc := b.contexts[name]
+		out = append(out, ContextInfo{Name: name, Settings: cloneContextSettings(nc.settings), Pages: openPageNames(nc.registry)})
+	}
+	return out
+}
+
+// EnsureContext creates the named context if needed. When apply is set and
+// the context exists with different device/window settings it is recreated,
+// carrying cookies, localStorage and page URLs over.
+func (b *BrowserHost) EnsureContext(name string, window *WindowSize, device string, apply bool) (ContextInfo, DaemonAction, string, error) {
+	name, err := NormalizeContextName(name)
+	if err != nil {
+		return ContextInfo{}, "", "", err
+	}
+	if name == "" {
+		return ContextInfo{}, "", "", errors.New("the default context is configured with --device/--window-size on the profile")
+	}
+	if window != nil && strings.TrimSpace(device) != "" {
+		return ContextInfo{}, "", "", errors.New("use either window or device")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.context == nil {
+		if err := b.startLocked(); err != nil {
+			return ContextInfo{}, "", "", err
+		}
+	}
+
+	requested := normalizeContextRequest(b.browser, b.headless, window, device)
+	existing, ok := b.contexts[name]
+	if !ok {
+		if err := b.openContextLocked(name, requested, nil); err != nil {
+			return ContextInfo{}, "", "", err
+		}
+		return b.contextInfoLocked(name), DaemonActionStarted, "", nil
+	}
+	if !apply || effectiveContextMatches(existing.settings, requested) {
+		return b.contextInfoLocked(name), DaemonActionReused, "", nil
+	}
+
+	reason := describeContextDiff(existing.settings, requested)
+	restore, err := b.captureContextLocked(name)
+	if err != nil {
+		return ContextInfo{}, "", "", err
+	}
+	restore.Requested = requested
+	b.closeContextLocked(name)
+	if err := b.restoreContextLocked(restore); err != nil {
+		return ContextInfo{}, "", "", err
+	}
+	return b.contextInfoLocked(name), DaemonActionReconfigured, reason, nil
+}
+
+// CloseContext closes a named context and its pages.
+func (b *BrowserHost) CloseContext(name string) (bool, error) {
+	name, err := NormalizeContextName(name)
+	if err != nil {
+		return false, err
+	}
+	if name == "" {
+		return false, errors.New("the default context cannot be closed (use stop)")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.contexts[name]; !ok {
+		return false, nil
+	}
+	b.closeContextLocked(name)
+	return true, nil
+}
+
+func (b *BrowserHost) contextInfoLocked(name string) ContextInfo {
+	nc := b.contexts[name]
+	return ContextInfo{Name: name, Settings: cloneContextSettings(nc.settings), Pages: openPageNames(nc.registry)}
+}
+
+func (b *BrowserHost) closeContextLocked(name string) {
+	nc, ok := b.contexts[name]
+	if !ok {
+		return
+	}
+	for page, holder := range nc.registry {
+		if holder.page != nil && !holder.page.IsClosed() {
+			_ = holder.page.Close()
+		}
+		b.clearPageLogs(pageLogKey(name, page))
+	}
+	_ = nc.context.Close()
+	delete(b.contexts, name)
+}
+
+// contextBrowserLocked lazily returns a Browser for named contexts. On
+// Chromium it connects to our own browser over CDP so the contexts share the
+// process; other engines have no such endpoint and get a companion browser.
+func (b *BrowserHost) contextBrowserLocked() (playwright.Browser, error) {
+	if b.contextBrowser != nil && b.contextBrowser.IsConnected() {
+		return b.contextBrowser, nil
+	}
+	if b.pw == nil {
+		return nil, errors.New("host not started")
+	}
+	var browser playwright.Browser
+	var err error
+	if BrowserSupportsCDP(b.browser) {
+		if b.ws == "" {
+			return nil, errors.New("host not started")
+		}
+		browser, err = b.pw.Chromium.ConnectOverCDP(b.ws)
+		if err != nil {
+			return nil, fmt.Errorf("connect over CDP: %w", err)
+		}
+	} else {
+		browser, err = browserTypeFor(b.pw, b.browser).Launch(playwright.BrowserTypeLaunchOptions{
+			Headless: playwright.Bool(b.headless),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("launch %s for named contexts: %w", b.browser, err)
+		}
+	}
+	b.contextBrowser = browser
+	return browser, nil
+}
+
+func (b *BrowserHost) openContextLocked(name string, requested BrowserContextSettings, storage *playwright.OptionalStorageState) error {
+	browser, err := b.contextBrowserLocked()
+	if err != nil {
+		return err
+	}
+	deviceName, device, err := resolveDeviceProfile(b.pw, requested.Device)
+	if err != nil {
+		return err
+	}
+	window := requested.Window
+	if device != nil {
+		window = deviceWindowSize(device)
+	}
+	launch := playwright.BrowserTypeLaunchPersistentContextOptions{
+		AcceptDownloads:   playwright.Bool(true),
+		IgnoreHttpsErrors: playwright.Bool(true),
+	}
+	if device != nil {
+		applyDeviceDescriptor(&launch, device)
+	} else if window != nil {
+		launch.Viewport = &playwright.Size{Width: window.Width, Height: window.Height}
+		launch.Screen = &playwright.Size{Width: window.Width, Height: window.Height}
+	}
+	adaptLaunchOptionsForEngine(b.browser, &launch)
+	opts := newContextOptionsFromLaunch(&launch)
+	opts.StorageState = storage
+
+	ctx, err := browser.NewContext(opts)
+	if err != nil {
+		return fmt.Errorf("new context %q: %w", name, err)
+	}
+	ctx.SetDefaultTimeout(15_000)
+	if err := InstallHarnessInit(ctx); err != nil {
+		_ = ctx.Close()
+		return fmt.Errorf("install harness init: %w", err)
+	}
+	if err := b.hookInterceptionLocked(ctx); err != nil {
+		_ = ctx.Close()
+		return err
+	}
+	b.hookContextCloseLocked(ctx)
+	b.contexts[name] = &namedContext{
+		context:  ctx,
+		settings: browserContextSettingsFromLaunch(b.browser, requested.Headless, deviceName, window, &launch),
+		registry: make(map[string]pageHolder),
+	}
+	return nil
+}
+
+func (b *BrowserHost) captureContextLocked(name string) (contextRestoreState, error) {
+	nc := b.contexts[name]
+	st, err := nc.context.StorageState()
+	if err != nil {
+		return contextRestoreState{}, fmt.Errorf("context %q storage state: %w", name, err)
+	}
+	return contextRestoreState{
+		Name:      name,
+		Requested: normalizeContextRequest(nc.settings.Browser, nc.settings.Headless, nc.settings.Window, nc.settings.Device),
+		Storage:   &playwright.OptionalStorageState{Cookies: cookiesForAdd(st.Cookies), Origins: st.Origins},
+		Pages:     capturePages(nc.registry),
+	}, nil
+}
+
+// captureContextsLocked snapshots every named context so Reconfigure and
+// crash recovery can bring them back after relaunching the browser. Contexts
+// whose storage cannot be read (a dead browser) are recreated empty at their
+// last known page URLs.
+func (b *BrowserHost) captureContextsLocked() []contextRestoreState {
+	names := make([]string, 0, len(b.contexts))
+	for name := range b.contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]contextRestoreState, 0, len(names))
+	for _, name := range names {
+		state, err := b.captureContextLocked(name)
+		if err != nil {
+			nc := b.contexts[name]
+			state = contextRestoreState{
+				Name:      name,
+				Requested: normalizeContextRequest(nc.settings.Browser, nc.settings.Headless, nc.settings.Window, nc.settings.Device),
+				Pages:     captureKnownPages(nc.registry),
+			}
+		}
+		out = append(out, state)
+	}
+	return out
+}
+
+func (b *BrowserHost) restoreContextsLocked(states []contextRestoreState) error {
+	for _, state := range states {
+		state.Requested.Browser = b.browser
+		state.Requested.Headless = b.headless
+		if err := b.restoreContextLocked(state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *BrowserHost) restoreContextLocked(state contextRestoreState) error {
+	if err := b.openContextLocked(state.Name, state.Requested, state.Storage); err != nil {
+		return err
+	}
+	for _, page := range state.Pages {
+		if _, err := b.createPageLocked(state.Name, page.Name); err != nil {
+			return fmt.Errorf("restore page %q in context %q: %w", page.Name, state.Name, err)
+		}
+		if err := navigatePageForRestore(b.contexts[state.Name].registry[page.Name].page, page.URL); err != nil {
+			return fmt.Errorf("restore page %q in context %q: %w", page.Name, state.Name, err)
+		}
+	}
+	return nil
+}
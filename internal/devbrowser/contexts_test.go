@@ -0,0 +1,30 @@
+package devbrowser
+
+import "testing"
+
+func TestNormalizeContextName(t *testing.T) {
+	for _, raw := range []string{"", "  ", "default", " default "} {
+		got, err := NormalizeContextName(raw)
+		if err != nil || got != "" {
+			t.Fatalf("NormalizeContextName(%q) = %q, %v; want default", raw, got, err)
+		}
+	}
+	got, err := NormalizeContextName(" alice ")
+	if err != nil || got != "alice" {
+		t.Fatalf("expected alice, got %q, %v", got, err)
+	}
+	for _, bad := range []string{"-x", "a/b", "with space", ".hidden"} {
+		if _, err := NormalizeContextName(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestPageLogKey(t *testing.T) {
+	if got := pageLogKey("", "main"); got != "main" {
+		t.Fatalf("expected bare page name for default context, got %q", got)
+	}
+	if pageLogKey("alice", "main") == pageLogKey("bob", "main") || pageLogKey("alice", "main") == "main" {
+		t.Fatal("expected distinct log keys per context")
+	}
+}
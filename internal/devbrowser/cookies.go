@@ -0,0 +1,242 @@
+package devbrowser
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// CookieFilter selects cookies by domain (matching subdomains, ignoring a
+// leading dot), exact name, and exact path.
+type CookieFilter struct {
+	Domain string
+	Name   string
+	Path   string
+}
+
+func (f CookieFilter) matches(c playwright.Cookie) bool {
+	if f.Name != "" && c.Name != f.Name {
+		return false
+	}
+	if f.Path != "" && c.Path != f.Path {
+		return false
+	}
+	if f.Domain != "" && !cookieDomainMatches(c.Domain, f.Domain) {
+		return false
+	}
+	return true
+}
+
+func cookieDomainMatches(cookieDomain, filter string) bool {
+	cd := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(cookieDomain), "."))
+	fd := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(filter), "."))
+	if fd == "" {
+		return true
+	}
+	return cd == fd || strings.HasSuffix(cd, "."+fd)
+}
+
+func filterCookies(cookies []playwright.Cookie, filter CookieFilter) []playwright.Cookie {
+	out := make([]playwright.Cookie, 0, len(cookies))
+	for _, c := range cookies {
+		if filter.matches(c) {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Domain != b.Domain {
+			return a.Domain < b.Domain
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Path < b.Path
+	})
+	return out
+}
+
+func parseSameSite(raw string) (*playwright.SameSiteAttribute, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "":
+		return nil, nil
+	case "strict":
+		return playwright.SameSiteAttributeStrict, nil
+	case "lax":
+		return playwright.SameSiteAttributeLax, nil
+	case "none":
+		return playwright.SameSiteAttributeNone, nil
+	default:
+		return nil, fmt.Errorf("invalid same_site %q (expected Strict, Lax, None)", raw)
+	}
+}
+
+func cookieFilterArgs(args map[string]interface{}) (CookieFilter, []string, error) {
+	domain, err := optionalStringAllowEmpty(args, "domain", "")
+	if err != nil {
+		return CookieFilter{}, nil, err
+	}
+	name, err := optionalStringAllowEmpty(args, "name", "")
+	if err != nil {
+		return CookieFilter{}, nil, err
+	}
+	path, err := optionalStringAllowEmpty(args, "path", "")
+	if err != nil {
+		return CookieFilter{}, nil, err
+	}
+	urls, err := optionalStringSlice(args, "url")
+	if err != nil {
+		return CookieFilter{}, nil, err
+	}
+	return CookieFilter{Domain: strings.TrimSpace(domain), Name: name, Path: strings.TrimSpace(path)}, urls, nil
+}
+
+func runCookies(page playwright.Page, name string, args map[string]interface{}) (RunResult, error) {
+	ctx := page.Context()
+	if ctx == nil {
+		return nil, errors.New("page has no browser context")
+	}
+	filter, urls, err := cookieFilterArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "cookies_list":
+		all, err := ctx.Cookies(urls...)
+		if err != nil {
+			return nil, err
+		}
+		matched := filterCookies(all, filter)
+		return RunResult{"cookies": matched, "count": len(matched), "total": len(all)}, nil
+
+	case "cookies_get":
+		if strings.TrimSpace(filter.Name) == "" {
+			return nil, errors.New("expected non-empty string 'name'")
+		}
+		all, err := ctx.Cookies(urls...)
+		if err != nil {
+			return nil, err
+		}
+		matched := filterCookies(all, filter)
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("cookie %q not found", filter.Name)
+		}
+		return RunResult{"cookie": matched[0], "matches": len(matched)}, nil
+
+	case "cookies_set":
+		cookie, err := cookieFromArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		if err := ctx.AddCookies([]playwright.OptionalCookie{cookie}); err != nil {
+			return nil, err
+		}
+		return RunResult{"set": true, "name": cookie.Name}, nil
+
+	case "cookies_delete", "cookies_clear":
+		if name == "cookies_delete" && strings.TrimSpace(filter.Name) == "" {
+			return nil, errors.New("expected non-empty string 'name'")
+		}
+		all, err := ctx.Cookies(urls...)
+		if err != nil {
+			return nil, err
+		}
+		matched := filterCookies(all, filter)
+		if filter == (CookieFilter{}) && len(urls) == 0 {
+			if err := ctx.ClearCookies(); err != nil {
+				return nil, err
+			}
+			return RunResult{"removed": len(all)}, nil
+		}
+		// Clear exact (name, domain, path) triples so subdomain filtering matches list.
+		for _, c := range matched {
+			if err := ctx.ClearCookies(playwright.BrowserContextClearCookiesOptions{Name: c.Name, Domain: c.Domain, Path: c.Path}); err != nil {
+				return nil, err
+			}
+		}
+		return RunResult{"removed": len(matched)}, nil
+	}
+	return nil, fmt.Errorf("unknown tool %q", name)
+}
+
+func cookieFromArgs(args map[string]interface{}) (playwright.OptionalCookie, error) {
+	name, err := requireString(args, "name")
+	if err != nil {
+		return playwright.OptionalCookie{}, err
+	}
+	if _, ok := args["value"]; !ok {
+		return playwright.OptionalCookie{}, errors.New("expected string 'value'")
+	}
+	value, err := optionalStringAllowEmpty(args, "value", "")
+	if err != nil {
+		return playwright.OptionalCookie{}, err
+	}
+	rawURL, err := optionalStringAllowEmpty(args, "url", "")
+	if err != nil {
+		return playwright.OptionalCookie{}, err
+	}
+	domain, err := optionalStringAllowEmpty(args, "domain", "")
+	if err != nil {
+		return playwright.OptionalCookie{}, err
+	}
+	path, err := optionalStringAllowEmpty(args, "path", "")
+	if err != nil {
+		return playwright.OptionalCookie{}, err
+	}
+	sameSiteRaw, err := optionalStringAllowEmpty(args, "same_site", "")
+	if err != nil {
+		return playwright.OptionalCookie{}, err
+	}
+	sameSite, err := parseSameSite(sameSiteRaw)
+	if err != nil {
+		return playwright.OptionalCookie{}, err
+	}
+	expires, err := optionalFloat(args, "expires", 0)
+	if err != nil {
+		return playwright.OptionalCookie{}, err
+	}
+
+	rawURL, domain, path = strings.TrimSpace(rawURL), strings.TrimSpace(domain), strings.TrimSpace(path)
+	if rawURL == "" && domain == "" {
+		return playwright.OptionalCookie{}, errors.New("cookie requires url or domain")
+	}
+	if rawURL != "" && (domain != "" || path != "") {
+		return playwright.OptionalCookie{}, errors.New("use either url or domain/path")
+	}
+
+	cookie := playwright.OptionalCookie{Name: name, Value: value, SameSite: sameSite}
+	if rawURL != "" {
+		cookie.URL = playwright.String(rawURL)
+	} else {
+		if path == "" {
+			path = "/"
+		}
+		cookie.Domain = playwright.String(domain)
+		cookie.Path = playwright.String(path)
+	}
+	if expires > 0 {
+		cookie.Expires = playwright.Float(expires)
+	}
+	if v, ok := args["http_only"]; ok {
+		b, ok := v.(bool)
+		if !ok {
+			return playwright.OptionalCookie{}, errors.New("expected boolean 'http_only'")
+		}
+		cookie.HttpOnly = playwright.Bool(b)
+	}
+	if v, ok := args["secure"]; ok {
+		b, ok := v.(bool)
+		if !ok {
+			return playwright.OptionalCookie{}, errors.New("expected boolean 'secure'")
+		}
+		cookie.Secure = playwright.Bool(b)
+	}
+	if sameSite != nil && *sameSite == *playwright.SameSiteAttributeNone && (cookie.Secure == nil || !*cookie.Secure) {
+		return playwright.OptionalCookie{}, errors.New("same_site None requires secure")
+	}
+	return cookie, nil
+}
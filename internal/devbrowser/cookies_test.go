@@ -0,0 +1,83 @@
+package devbrowser
+
+import (
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+func TestCookieDomainMatches(t *testing.T) {
+	cases := []struct {
+		cookie string
+		filter string
+		want   bool
+	}{
+		{".example.com", "example.com", true},
+		{"example.com", ".example.com", true},
+		{"app.example.com", "example.com", true},
+		{"example.com", "app.example.com", false},
+		{"badexample.com", "example.com", false},
+		{"Example.COM", "example.com", true},
+		{"example.com", "", true},
+	}
+	for _, tc := range cases {
+		if got := cookieDomainMatches(tc.cookie, tc.filter); got != tc.want {
+			t.Fatalf("cookieDomainMatches(%q, %q) = %v, want %v", tc.cookie, tc.filter, got, tc.want)
+		}
+	}
+}
+
+func TestFilterCookies_SortedAndFiltered(t *testing.T) {
+	cookies := []playwright.Cookie{
+		{Name: "sid", Domain: "b.example.com", Path: "/"},
+		{Name: "theme", Domain: "a.example.com", Path: "/"},
+		{Name: "sid", Domain: "a.example.com", Path: "/"},
+		{Name: "sid", Domain: "other.test", Path: "/"},
+	}
+	got := filterCookies(cookies, CookieFilter{Domain: "example.com", Name: "sid"})
+	if len(got) != 2 {
+		t.Fatalf("expected 2 matches, got %+v", got)
+	}
+	if got[0].Domain != "a.example.com" || got[1].Domain != "b.example.com" {
+		t.Fatalf("expected domain-sorted output, got %+v", got)
+	}
+	if all := filterCookies(cookies, CookieFilter{}); len(all) != 4 || all[0].Name != "sid" || all[1].Name != "theme" {
+		t.Fatalf("unexpected unfiltered order: %+v", all)
+	}
+}
+
+func TestCookieFromArgs(t *testing.T) {
+	cookie, err := cookieFromArgs(map[string]interface{}{"name": "sid", "value": "", "domain": ".example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cookie.Path == nil || *cookie.Path != "/" || cookie.URL != nil || cookie.Expires != nil {
+		t.Fatalf("unexpected cookie: %+v", cookie)
+	}
+
+	cookie, err = cookieFromArgs(map[string]interface{}{
+		"name": "sid", "value": "1", "url": "https://example.com",
+		"expires": float64(1900000000), "secure": true, "same_site": "none", "http_only": true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cookie.URL == nil || *cookie.Expires != 1900000000 || *cookie.SameSite != *playwright.SameSiteAttributeNone || !*cookie.HttpOnly {
+		t.Fatalf("unexpected cookie: %+v", cookie)
+	}
+
+	bad := []map[string]interface{}{
+		{"value": "1", "domain": "example.com"},
+		{"name": "sid", "domain": "example.com"},
+		{"name": "sid", "value": "1"},
+		{"name": "sid", "value": "1", "url": "https://example.com", "domain": "example.com"},
+		{"name": "sid", "value": "1", "domain": "example.com", "same_site": "sometimes"},
+		{"name": "sid", "value": "1", "domain": "example.com", "same_site": "None"},
+		{"name": "sid", "value": "1", "domain": "example.com", "secure": "yes"},
+	}
+	for _, args := range bad {
+		if _, err := cookieFromArgs(args); err == nil {
+			t.Fatalf("expected error for %+v", args)
+		}
+	}
+}
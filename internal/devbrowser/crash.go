@@ -0,0 +1,401 @@
+package devbrowser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Daemon health states reported in /health "status".
+const (
+	HealthOK         = "ok"
+	HealthRecovering = "recovering"
+	HealthDegraded   = "degraded"
+)
+
+// Crash kinds. A page crash is a dead renderer: the page is replaced in its
+// context. A browser crash is the persistent or a named context closing
+// without us asking: the whole browser is relaunched.
+const (
+	CrashPage    = "page"
+	CrashBrowser = "browser"
+)
+
+const (
+	maxCrashReports    = 20
+	crashConsoleErrors = 10
+)
+
+// A page or browser that crashes more than maxCrashRecoveries times within
+// crashLoopWindow is left down: a renderer that dies on load would otherwise
+// be reopened forever, each round holding the host lock.
+const (
+	maxCrashRecoveries = 3
+	crashLoopWindow    = 2 * time.Minute
+)
+
+// CrashReport records one crash and what recovery did about it.
+type CrashReport struct {
+	ID            int64          `json:"id"`
+	Kind          string         `json:"kind"`
+	Context       string         `json:"context,omitempty"`
+	Page          string         `json:"page,omitempty"`
+	URL           string         `json:"url,omitempty"`
+	TimeMS        int64          `json:"time_ms"`
+	Recovered     bool           `json:"recovered"`
+	RecoveredMS   int64          `json:"recovered_ms,omitempty"`
+	Error         string         `json:"error,omitempty"`
+	ConsoleErrors []ConsoleEntry `json:"console_errors,omitempty"`
+}
+
+// crashLog is guarded by its own mutex, not the host's: /health must answer
+// while a recovery holds the host lock, or clients would think the daemon is
+// gone and start a second one on the same profile.
+type crashLog struct {
+	mu       sync.Mutex
+	reports  []CrashReport
+	nextID   int64
+	pending  int
+	failed   map[string]string
+	recent   map[string][]int64
+	settings BrowserContextSettings
+}
+
+func newCrashLog() *crashLog {
+	return &crashLog{failed: make(map[string]string), recent: make(map[string][]int64)}
+}
+
+// begin records a crash of key ("" for the browser) and marks the host as
+// recovering. settings is what /health reports until the recovery finishes.
+// It returns false when key crashed too often to recover again; the crash is
+// then recorded as failed and the host stays degraded, with no finish call.
+func (c *crashLog) begin(report CrashReport, key string, settings BrowserContextSettings) (int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextID++
+	report.ID = c.nextID
+	if report.TimeMS == 0 {
+		report.TimeMS = NowMS()
+	}
+	since := report.TimeMS - crashLoopWindow.Milliseconds()
+	times := []int64{}
+	for _, t := range c.recent[key] {
+		if t > since {
+			times = append(times, t)
+		}
+	}
+	times = append(times, report.TimeMS)
+	c.recent[key] = times
+	retry := len(times) <= maxCrashRecoveries
+	if !retry {
+		report.Error = fmt.Sprintf("crashed %d times in %s; not recovering", len(times), crashLoopWindow)
+		c.failed[key] = report.Error
+	}
+	if len(c.reports) >= maxCrashReports {
+		c.reports = c.reports[len(c.reports)-maxCrashReports+1:]
+	}
+	c.reports = append(c.reports, report)
+	if retry {
+		c.pending++
+		c.settings = cloneContextSettings(settings)
+	}
+	return report.ID, retry
+}
+
+// finish settles crash id. A failed recovery leaves the host degraded until
+// the page (or, for browser crashes, the browser) is started again.
+func (c *crashLog) finish(id int64, key string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending > 0 {
+		c.pending--
+	}
+	for i := range c.reports {
+		if c.reports[i].ID != id {
+			continue
+		}
+		if err != nil {
+			c.reports[i].Error = err.Error()
+		} else {
+			c.reports[i].Recovered = true
+			c.reports[i].RecoveredMS = NowMS()
+		}
+		break
+	}
+	if err != nil {
+		c.failed[key] = err.Error()
+	} else {
+		delete(c.failed, key)
+	}
+}
+
+// healthy clears a failed recovery for key; "" clears them all. The crash
+// history is kept, so a page reopened into the same crash is given up on at
+// once.
+func (c *crashLog) healthy(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if key == "" {
+		c.failed = make(map[string]string)
+		return
+	}
+	delete(c.failed, key)
+}
+
+func (c *crashLog) status() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case c.pending > 0:
+		return HealthRecovering
+	case len(c.failed) > 0:
+		return HealthDegraded
+	default:
+		return HealthOK
+	}
+}
+
+// degraded describes each failed recovery, e.g. "page main: crashed 4 times
+// in 2m0s; not recovering".
+func (c *crashLog) degraded() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]string, 0, len(c.failed))
+	for key, reason := range c.failed {
+		out = append(out, crashKeyLabel(key)+": "+reason)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func crashKeyLabel(key string) string {
+	if key == "" {
+		return "browser"
+	}
+	if contextName, page, ok := strings.Cut(key, "\x00"); ok {
+		return "context " + contextName + " page " + page
+	}
+	return "page " + key
+}
+
+func (c *crashLog) list() []CrashReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]CrashReport, len(c.reports))
+	for i, r := range c.reports {
+		r.ConsoleErrors = append([]ConsoleEntry(nil), r.ConsoleErrors...)
+		out[i] = r
+	}
+	return out
+}
+
+func (c *crashLog) recoverySettings() BrowserContextSettings {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return cloneContextSettings(c.settings)
+}
+
+// lastConsoleErrors keeps the newest max error-level entries, oldest first.
+func lastConsoleErrors(entries []ConsoleEntry, max int) []ConsoleEntry {
+	out := []ConsoleEntry{}
+	for i := len(entries) - 1; i >= 0 && len(out) < max; i-- {
+		if consoleLevelForType(entries[i].Type) == "error" {
+			out = append(out, entries[i])
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func crashEventData(r CrashReport) map[string]any {
+	data := map[string]any{"crash": r.Kind, "id": r.ID}
+	if r.URL != "" {
+		data["url"] = r.URL
+	}
+	if len(r.ConsoleErrors) > 0 {
+		data["console_errors"] = len(r.ConsoleErrors)
+	}
+	return data
+}
+
+// captureKnownPages is capturePages including pages that already closed:
+// after a browser crash every page is closed, but its last URL is still
+// known.
+func captureKnownPages(registry map[string]pageHolder) []pageRestoreState {
+	names := make([]string, 0, len(registry))
+	for name, holder := range registry {
+		if holder.page != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	restore := make([]pageRestoreState, 0, len(names))
+	for _, name := range names {
+		restore = append(restore, pageRestoreState{Name: name, URL: registry[name].page.URL()})
+	}
+	return restore
+}
+
+// CrashStatus reports the host health state and recent crashes without
+// taking the host lock.
+func (b *BrowserHost) CrashStatus() (string, []CrashReport) {
+	return b.crashes.status(), b.crashes.list()
+}
+
+// DegradedReasons lists the pages (or the browser) whose recovery failed or
+// was given up on, with the reason.
+func (b *BrowserHost) DegradedReasons() []string {
+	return b.crashes.degraded()
+}
+
+// RecoverySettings returns the context settings captured when the current
+// recovery began.
+func (b *BrowserHost) RecoverySettings() BrowserContextSettings {
+	return b.crashes.recoverySettings()
+}
+
+// OnRelaunch registers fn to run after the browser was relaunched by crash
+// recovery (without the host lock held), e.g. to rewrite daemon.json with the
+// new CDP endpoint.
+func (b *BrowserHost) OnRelaunch(fn func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onRelaunch = fn
+}
+
+// hookPageCrashLocked watches a page renderer. Playwright runs event handlers
+// on its dispatch goroutine, so recovery happens on a goroutine of its own.
+func (b *BrowserHost) hookPageCrashLocked(contextName, name string, page playwright.Page) {
+	page.OnCrash(func(playwright.Page) {
+		go b.recoverPage(contextName, name, page)
+	})
+}
+
+// hookContextCloseLocked watches a context for closing on its own, which
+// means the browser died (or the CDP connection behind named contexts did).
+// Closes we initiate are ignored: by the time the handler gets the lock the
+// context has been replaced or dropped.
+func (b *BrowserHost) hookContextCloseLocked(ctx playwright.BrowserContext) {
+	ctx.OnClose(func(playwright.BrowserContext) {
+		go b.recoverBrowser(ctx)
+	})
+}
+
+func (b *BrowserHost) recoverPage(contextName, name string, page playwright.Page) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, registry, err := b.scopeLocked(contextName)
+	if err != nil {
+		return
+	}
+	if holder, ok := registry[name]; !ok || holder.page != page {
+		return
+	}
+
+	key := pageLogKey(contextName, name)
+	report := CrashReport{Kind: CrashPage, Context: contextName, Page: name, URL: page.URL()}
+	report.ConsoleErrors = b.consoleErrorsLocked(key)
+	var retry bool
+	report.ID, retry = b.crashes.begin(report, key, b.settings)
+	if b.events != nil {
+		b.events.publish(key, name, EventCrash, 0, crashEventData(report))
+	}
+
+	_ = page.Close()
+	delete(registry, name)
+	if !retry {
+		return
+	}
+	if _, err = b.createPageLocked(contextName, name); err == nil {
+		err = navigatePageForRestore(registry[name].page, report.URL)
+	}
+	b.crashes.finish(report.ID, key, err)
+}
+
+func (b *BrowserHost) recoverBrowser(ctx playwright.BrowserContext) {
+	b.mu.Lock()
+	if !b.ownsContextLocked(ctx) {
+		b.mu.Unlock()
+		return
+	}
+
+	report := CrashReport{Kind: CrashBrowser, URL: b.lastMainURLLocked()}
+	report.ConsoleErrors = b.consoleErrorsLocked("main")
+	var retry bool
+	report.ID, retry = b.crashes.begin(report, "", b.settings)
+	b.publishCrashLocked(report)
+
+	restore := captureKnownPages(b.registry)
+	named := b.captureContextsLocked()
+	b.stopLocked()
+	if !retry {
+		b.mu.Unlock()
+		return
+	}
+	err := b.startLocked()
+	if err == nil {
+		err = b.restorePagesLocked(restore)
+	}
+	if err == nil {
+		err = b.restoreContextsLocked(named)
+	}
+	relaunched := b.context != nil
+	onRelaunch := b.onRelaunch
+	b.crashes.finish(report.ID, "", err)
+	b.mu.Unlock()
+
+	if relaunched && onRelaunch != nil {
+		onRelaunch()
+	}
+}
+
+func (b *BrowserHost) ownsContextLocked(ctx playwright.BrowserContext) bool {
+	if ctx == nil {
+		return false
+	}
+	if b.context == ctx {
+		return true
+	}
+	for _, nc := range b.contexts {
+		if nc.context == ctx {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *BrowserHost) lastMainURLLocked() string {
+	if holder, ok := b.registry["main"]; ok && holder.page != nil {
+		return holder.page.URL()
+	}
+	return ""
+}
+
+func (b *BrowserHost) consoleErrorsLocked(key string) []ConsoleEntry {
+	if b.logs == nil {
+		return nil
+	}
+	entries, _ := b.logs.list(key, 0, 0)
+	return lastConsoleErrors(entries, crashConsoleErrors)
+}
+
+// publishCrashLocked tells followers of every page; stopLocked then closes
+// their streams.
+func (b *BrowserHost) publishCrashLocked(report CrashReport) {
+	if b.events == nil {
+		return
+	}
+	data := crashEventData(report)
+	for name := range b.registry {
+		b.events.publish(pageLogKey("", name), name, EventCrash, 0, data)
+	}
+	for contextName, nc := range b.contexts {
+		for name := range nc.registry {
+			b.events.publish(pageLogKey(contextName, name), name, EventCrash, 0, data)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package devbrowser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCrashLogStatus(t *testing.T) {
+	c := newCrashLog()
+	if got := c.status(); got != HealthOK {
+		t.Fatalf("expected ok, got %q", got)
+	}
+
+	id, retry := c.begin(CrashReport{Kind: CrashPage, Page: "main"}, "main", BrowserContextSettings{Browser: BrowserChromium})
+	if !retry {
+		t.Fatal("expected the first crash to be recovered")
+	}
+	if got := c.status(); got != HealthRecovering {
+		t.Fatalf("expected recovering, got %q", got)
+	}
+	if got := c.recoverySettings().Browser; got != BrowserChromium {
+		t.Fatalf("expected recovery settings to be kept, got %q", got)
+	}
+	c.finish(id, "main", errors.New("goto failed"))
+	if got := c.status(); got != HealthDegraded {
+		t.Fatalf("expected degraded, got %q", got)
+	}
+	reports := c.list()
+	if len(reports) != 1 || reports[0].Recovered || reports[0].Error != "goto failed" {
+		t.Fatalf("unexpected reports: %+v", reports)
+	}
+
+	c.healthy("other")
+	if got := c.status(); got != HealthDegraded {
+		t.Fatalf("expected unrelated page to leave degraded, got %q", got)
+	}
+	c.healthy("main")
+	if got := c.status(); got != HealthOK {
+		t.Fatalf("expected ok after page recreated, got %q", got)
+	}
+
+	id, _ = c.begin(CrashReport{Kind: CrashBrowser}, "", BrowserContextSettings{})
+	c.finish(id, "", nil)
+	reports = c.list()
+	if len(reports) != 2 || !reports[1].Recovered || reports[1].RecoveredMS == 0 || reports[1].ID <= reports[0].ID {
+		t.Fatalf("unexpected reports: %+v", reports)
+	}
+	if got := c.status(); got != HealthOK {
+		t.Fatalf("expected ok, got %q", got)
+	}
+}
+
+func TestCrashLogKeepsNewest(t *testing.T) {
+	c := newCrashLog()
+	for i := 0; i < maxCrashReports+5; i++ {
+		// Spread out past the crash loop window, so every crash recovers.
+		id, _ := c.begin(CrashReport{Kind: CrashPage, TimeMS: int64(i+1) * crashLoopWindow.Milliseconds()}, "main", BrowserContextSettings{})
+		c.finish(id, "main", nil)
+	}
+	reports := c.list()
+	if len(reports) != maxCrashReports {
+		t.Fatalf("expected %d reports, got %d", maxCrashReports, len(reports))
+	}
+	if reports[len(reports)-1].ID != int64(maxCrashReports+5) {
+		t.Fatalf("expected newest report last, got %d", reports[len(reports)-1].ID)
+	}
+}
+
+func TestCrashLogStopsRecoveringCrashLoops(t *testing.T) {
+	c := newCrashLog()
+	start := NowMS()
+	for i := 0; i < maxCrashRecoveries; i++ {
+		id, retry := c.begin(CrashReport{Kind: CrashPage, Page: "main", TimeMS: start + int64(i)}, "main", BrowserContextSettings{})
+		if !retry {
+			t.Fatalf("crash %d should be recovered", i+1)
+		}
+		c.finish(id, "main", nil)
+	}
+	if got := c.status(); got != HealthOK {
+		t.Fatalf("expected ok, got %q", got)
+	}
+
+	_, retry := c.begin(CrashReport{Kind: CrashPage, Page: "main", TimeMS: start + maxCrashRecoveries}, "main", BrowserContextSettings{})
+	if retry {
+		t.Fatal("expected recovery to stop after the limit")
+	}
+	if got := c.status(); got != HealthDegraded {
+		t.Fatalf("expected degraded, got %q", got)
+	}
+	reports := c.list()
+	if last := reports[len(reports)-1]; last.Recovered || !strings.Contains(last.Error, "not recovering") {
+		t.Fatalf("unexpected last report: %+v", last)
+	}
+	reasons := c.degraded()
+	if len(reasons) != 1 || !strings.HasPrefix(reasons[0], "page main: crashed 4 times") {
+		t.Fatalf("unexpected degraded reasons: %v", reasons)
+	}
+
+	// Reopening the page clears degraded but not the history: another crash
+	// in the window is given up on at once.
+	c.healthy("main")
+	if _, retry := c.begin(CrashReport{Kind: CrashPage, Page: "main", TimeMS: start + maxCrashRecoveries + 1}, "main", BrowserContextSettings{}); retry {
+		t.Fatal("expected a crash inside the window to stay unrecovered")
+	}
+
+	// Other pages and the browser keep their own count, and old crashes age
+	// out of the window.
+	if _, retry := c.begin(CrashReport{Kind: CrashPage, Context: "alice", Page: "main", TimeMS: start}, pageLogKey("alice", "main"), BrowserContextSettings{}); !retry {
+		t.Fatal("expected another page to be recovered")
+	}
+	later := start + crashLoopWindow.Milliseconds() + maxCrashRecoveries + 2
+	if _, retry := c.begin(CrashReport{Kind: CrashPage, Page: "main", TimeMS: later}, "main", BrowserContextSettings{}); !retry {
+		t.Fatal("expected recovery once the window passed")
+	}
+	if got := crashKeyLabel(pageLogKey("alice", "main")); got != "context alice page main" {
+		t.Fatalf("unexpected label %q", got)
+	}
+}
+
+func TestLastConsoleErrors(t *testing.T) {
+	entries := []ConsoleEntry{
+		{ID: 1, Type: "error", Text: "a"},
+		{ID: 2, Type: "log", Text: "b"},
+		{ID: 3, Type: "pageerror", Text: "c"},
+		{ID: 4, Type: "warning", Text: "d"},
+		{ID: 5, Type: "error", Text: "e"},
+	}
+	got := lastConsoleErrors(entries, 2)
+	if len(got) != 2 || got[0].ID != 3 || got[1].ID != 5 {
+		t.Fatalf("unexpected errors: %+v", got)
+	}
+	if got := lastConsoleErrors(nil, 2); len(got) != 0 {
+		t.Fatalf("expected no errors, got %+v", got)
+	}
+}
+
+func TestDiagnoseReportSetCrashes(t *testing.T) {
+	r := &DiagnoseReport{Meta: DiagnoseMeta{Page: "main"}}
+	r.SetConsole([]ConsoleEntry{{ID: 1, TimeMS: 100, Type: "error", Text: "boom"}})
+	r.SetCrashes([]CrashReport{
+		{ID: 1, Kind: CrashPage, Page: "main", TimeMS: 200, Recovered: true, ConsoleErrors: []ConsoleEntry{{ID: 1, Type: "error", Text: "boom"}}},
+		{ID: 2, Kind: CrashPage, Page: "other", TimeMS: 300},
+		{ID: 3, Kind: CrashPage, Context: "alice", Page: "main", TimeMS: 300},
+		{ID: 4, Kind: CrashBrowser, TimeMS: 50},
+	}, "")
+
+	if len(r.Crashes) != 2 || r.Crashes[0].ID != 1 || r.Crashes[1].ID != 4 {
+		t.Fatalf("unexpected crashes: %+v", r.Crashes)
+	}
+	if !r.Summary.HasCrashes || !r.Summary.HasConsoleErrors {
+		t.Fatalf("unexpected summary: %+v", r.Summary)
+	}
+	kinds := []string{}
+	for _, ev := range r.Events {
+		kinds = append(kinds, ev.Kind)
+	}
+	want := []string{"crash", "console", "crash"}
+	if len(kinds) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, kinds)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("expected events %v, got %v", want, kinds)
+		}
+	}
+
+	// A later SetConsole keeps the crash events.
+	r.SetConsole(nil)
+	if len(r.Events) != 2 {
+		t.Fatalf("expected crash events to survive console reset, got %+v", r.Events)
+	}
+}
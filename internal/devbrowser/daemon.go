@@ -13,26 +13,43 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 type DaemonOptions struct {
-	Profile   string
-	Host      string
-	Port      int
+	Profile string
+	Browser string
+	Host    string
+	Port    int
+	// Socket, when set, is a Unix socket path to listen on instead of
+	// Host:Port.
+	Socket    string
 	CDPPort   int
 	Headless  bool
 	Window    *WindowSize
 	Device    string
 	StateFile string
 	Logger    *log.Logger
+	// CDPEndpoint connects to an existing Chromium instead of launching one.
+	CDPEndpoint string
+	// IdleTimeout shuts the daemon down after this long without client
+	// requests (health probes excluded); zero keeps it running.
+	IdleTimeout time.Duration
+	Limits      HostLimits
 }
 
+const overlayPoll = 500 * time.Millisecond
+
 type Daemon struct {
 	opts   DaemonOptions
 	host   *BrowserHost
 	server *http.Server
 	logger *log.Logger
+	token  string
+
+	lastActivity atomic.Int64
+	inflight     atomic.Int64
 }
 
 func ServeDaemon(opts DaemonOptions) error {
@@ -53,7 +70,17 @@ func ServeDaemon(opts DaemonOptions) error {
 		cdpPort = p
 	}
 
-	host := NewBrowserHost(profile, opts.Headless, cdpPort, opts.Window, opts.Device)
+	host := NewBrowserHost(profile, opts.Browser, opts.Headless, cdpPort, opts.Window, opts.Device)
+	host.SetLimits(opts.Limits)
+	endpoint, err := NormalizeCDPEndpoint(opts.CDPEndpoint)
+	if err != nil {
+		return err
+	}
+	if endpoint != "" && browserEngineOrDefault(opts.Browser) != BrowserChromium {
+		return errors.New("--cdp-endpoint requires chromium")
+	}
+	opts.CDPEndpoint = endpoint
+	host.SetCDPEndpoint(endpoint)
 	if err := host.Start(); err != nil {
 		return err
 	}
@@ -62,18 +89,33 @@ func ServeDaemon(opts DaemonOptions) error {
 	if strings.TrimSpace(stateFile) == "" {
 		stateFile = filepath.Join(StateDir(profile), "daemon.json")
 	}
-	if err := os.MkdirAll(filepath.Dir(stateFile), 0o755); err != nil {
+	if _, err := EnsureStateDir(profile); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(stateFile), 0o700); err != nil {
+		return err
+	}
+
+	token, err := newDaemonToken()
+	if err != nil {
 		return err
 	}
 
 	mux := http.NewServeMux()
-	d := &Daemon{opts: opts, host: host, logger: logger}
+	d := &Daemon{opts: opts, host: host, logger: logger, token: token}
 
 	mux.HandleFunc("/health", d.handleHealth)
 	mux.HandleFunc("/reconfigure", d.handleReconfigure)
 	mux.HandleFunc("/", d.handleRoot)
 	mux.HandleFunc("/pages", d.handlePages)
 	mux.HandleFunc("/pages/", d.handlePageSubresource)
+	mux.HandleFunc("/contexts", d.handleContexts)
+	mux.HandleFunc("/contexts/", d.handleContext)
+	mux.HandleFunc("/routes", d.handleRoutes)
+	mux.HandleFunc("/routes/", d.handleRoute)
+	mux.HandleFunc("/har", d.handleHAR)
+	mux.HandleFunc("/state", d.handleState)
+	mux.HandleFunc("/har/", d.handleHAR)
 	mux.HandleFunc("/shutdown", func(w http.ResponseWriter, r *http.Request) {
 		d.writeJSON(w, http.StatusOK, map[string]any{"ok": true})
 		go func() {
@@ -84,26 +126,49 @@ func ServeDaemon(opts DaemonOptions) error {
 
 	srv := &http.Server{
 		Addr:              fmt.Sprintf("%s:%d", opts.Host, opts.Port),
-		Handler:           mux,
+		Handler:           requireToken(token, d.trackActivity(mux)),
 		ReadHeaderTimeout: 5 * time.Second,
 	}
 	d.server = srv
 
-	ln, err := net.Listen("tcp", srv.Addr)
-	if err != nil {
-		return err
+	var ln net.Listener
+	if opts.Socket != "" {
+		ln, err = listenUnixSocket(opts.Socket)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(opts.Socket)
+		d.opts.Host, d.opts.Port = "", 0
+	} else {
+		ln, err = net.Listen("tcp", srv.Addr)
+		if err != nil {
+			return err
+		}
+		d.opts.Port = ln.Addr().(*net.TCPAddr).Port
 	}
 
 	d.opts.Profile = profile
-	d.opts.Port = ln.Addr().(*net.TCPAddr).Port
 	d.opts.CDPPort = cdpPort
 	d.opts.StateFile = stateFile
 	if err := d.writeCurrentState(); err != nil {
 		ln.Close()
 		return err
 	}
+	host.OnRelaunch(func() {
+		if err := d.writeCurrentState(); err != nil {
+			logger.Printf("rewrite state after crash recovery: %v", err)
+		}
+	})
 	defer os.Remove(stateFile)
 
+	done := make(chan struct{})
+	defer close(done)
+	d.lastActivity.Store(NowMS())
+	go d.sampleMemory(done)
+	if opts.IdleTimeout > 0 {
+		go d.watchIdle(opts.IdleTimeout, done)
+	}
+
 	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
@@ -121,9 +186,12 @@ func (d *Daemon) handleReconfigure(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var body struct {
+		Browser  string      `json:"browser"`
 		Headless bool        `json:"headless"`
 		Window   *WindowSize `json:"window"`
 		Device   string      `json:"device"`
+		// CDPEndpoint switches between launching and connecting.
+		CDPEndpoint string `json:"cdpEndpoint"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid json"})
@@ -133,13 +201,28 @@ func (d *Daemon) handleReconfigure(w http.ResponseWriter, r *http.Request) {
 		d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "use either window or device"})
 		return
 	}
-	if err := d.host.Reconfigure(body.Headless, body.Window, body.Device); err != nil {
+	browser, err := NormalizeBrowserEngine(body.Browser)
+	if err != nil {
+		d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	endpoint, err := NormalizeCDPEndpoint(body.CDPEndpoint)
+	if err == nil && endpoint != "" && browser != BrowserChromium {
+		err = errors.New("cdpEndpoint requires chromium")
+	}
+	if err != nil {
+		d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	if err := d.host.Reconfigure(browser, body.Headless, body.Window, body.Device, endpoint); err != nil {
 		d.writeJSON(w, http.StatusInternalServerError, map[string]any{"ok": false, "error": err.Error()})
 		return
 	}
+	d.opts.Browser = browser
 	d.opts.Headless = body.Headless
 	d.opts.Window = cloneWindowSize(body.Window)
 	d.opts.Device = strings.TrimSpace(body.Device)
+	d.opts.CDPEndpoint = endpoint
 	if err := d.writeCurrentState(); err != nil {
 		d.writeJSON(w, http.StatusInternalServerError, map[string]any{"ok": false, "error": err.Error()})
 		return
@@ -165,10 +248,20 @@ func (d *Daemon) handleRoot(w http.ResponseWriter, r *http.Request) {
 func (d *Daemon) handlePages(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		d.writeJSON(w, http.StatusOK, map[string]any{"pages": d.host.ListPages()})
+		contextName, ok := d.contextParam(w, r.URL.Query().Get("context"))
+		if !ok {
+			return
+		}
+		pages, err := d.host.ListPages(contextName)
+		if err != nil {
+			d.writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+		d.writeJSON(w, http.StatusOK, map[string]any{"pages": pages, "context": contextLabel(contextName), "contexts": d.host.ListContexts()})
 	case http.MethodPost:
 		var body struct {
-			Name string `json:"name"`
+			Name    string `json:"name"`
+			Context string `json:"context"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 			d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid json"})
@@ -178,14 +271,20 @@ func (d *Daemon) handlePages(w http.ResponseWriter, r *http.Request) {
 			d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "name is required and must be a non-empty string"})
 			return
 		}
-		entry, err := d.host.GetOrCreatePage(strings.TrimSpace(body.Name))
+		contextName, ok := d.contextParam(w, body.Context)
+		if !ok {
+			return
+		}
+		entry, err := d.host.GetOrCreatePage(contextName, strings.TrimSpace(body.Name))
 		if err != nil {
-			d.writeJSON(w, http.StatusInternalServerError, map[string]any{"ok": false, "error": err.Error()})
+			d.writeJSON(w, errorStatus(err, http.StatusInternalServerError), map[string]any{"ok": false, "error": err.Error()})
 			return
 		}
 		ws, _ := d.host.WSEndpoint()
 		d.writeJSON(w, http.StatusOK, map[string]any{
 			"wsEndpoint": ws,
+			"browser":    d.host.ContextSettings().Browser,
+			"context":    contextLabel(contextName),
 			"name":       entry.Name,
 			"targetId":   entry.TargetID,
 			"url":        entry.URL,
@@ -208,13 +307,17 @@ func (d *Daemon) handlePageSubresource(w http.ResponseWriter, r *http.Request) {
 		d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid page name"})
 		return
 	}
+	contextName, ok := d.contextParam(w, r.URL.Query().Get("context"))
+	if !ok {
+		return
+	}
 
 	if len(parts) == 1 {
 		if r.Method != http.MethodDelete {
 			d.writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "not found"})
 			return
 		}
-		if closed := d.host.ClosePage(name); !closed {
+		if closed := d.host.ClosePage(contextName, name); !closed {
 			d.writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "page not found"})
 			return
 		}
@@ -222,6 +325,26 @@ func (d *Daemon) handlePageSubresource(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(parts) == 2 && parts[1] == "network" {
+		d.handlePageNetwork(w, r, contextName, name)
+		return
+	}
+	if len(parts) >= 2 && parts[1] == "downloads" {
+		d.handlePageDownloads(w, r, contextName, name, parts[2:])
+		return
+	}
+	if len(parts) == 2 && parts[1] == "events" {
+		d.handlePageEvents(w, r, contextName, name)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "call" {
+		d.handlePageCall(w, r, contextName, name)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "hold" {
+		d.handlePageHold(w, r, contextName, name)
+		return
+	}
 	if len(parts) != 2 || parts[1] != "console" {
 		d.writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "not found"})
 		return
@@ -257,13 +380,9 @@ func (d *Daemon) handlePageSubresource(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logs, lastID, err := d.host.ConsoleLogs(name, since, 0)
+	logs, lastID, err := d.host.ConsoleLogs(contextName, name, since, 0)
 	if err != nil {
-		status := http.StatusBadRequest
-		if strings.Contains(err.Error(), "page not found") {
-			status = http.StatusNotFound
-		}
-		d.writeJSON(w, status, map[string]any{"ok": false, "error": err.Error()})
+		d.writeJSON(w, errorStatus(err, http.StatusBadRequest), map[string]any{"ok": false, "error": err.Error()})
 		return
 	}
 	logs = selectConsoleLogs(logs, levelFilter, since, limit)
@@ -289,6 +408,606 @@ func (d *Daemon) handlePageSubresource(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (d *Daemon) handlePageNetwork(w http.ResponseWriter, r *http.Request, contextName, name string) {
+	if r.Method != http.MethodGet {
+		d.writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "not found"})
+		return
+	}
+
+	query := r.URL.Query()
+	intParam := func(key string, def int64) (int64, bool) {
+		raw := strings.TrimSpace(query.Get(key))
+		if raw == "" {
+			return def, true
+		}
+		val, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || val < 0 {
+			d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid " + key})
+			return 0, false
+		}
+		return val, true
+	}
+	since, ok := intParam("since", 0)
+	if !ok {
+		return
+	}
+	limit, ok := intParam("limit", defaultNetworkLogMax)
+	if !ok {
+		return
+	}
+	status, ok := intParam("status", 0)
+	if !ok {
+		return
+	}
+	statusMin, ok := intParam("status_min", 0)
+	if !ok {
+		return
+	}
+	statusMax, ok := intParam("status_max", 0)
+	if !ok {
+		return
+	}
+	opts := NetworkMonitorOptions{
+		URLContains:    query.Get("url_contains"),
+		MethodEquals:   query.Get("method"),
+		TypeEquals:     query.Get("type"),
+		StatusEquals:   int(status),
+		StatusMin:      int(statusMin),
+		StatusMax:      int(statusMax),
+		OnlyFailed:     queryTruthy(query.Get("failed")),
+		IncludeHeaders: queryTruthy(query.Get("headers")),
+	}
+
+	logs, err := d.host.NetworkLogs(contextName, name)
+	if err != nil {
+		d.writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	entries := selectNetworkLogs(logs, opts, since, int(limit))
+	lastID := since
+	for _, e := range entries {
+		if e.ID > lastID {
+			lastID = e.ID
+		}
+	}
+
+	d.writeJSON(w, http.StatusOK, map[string]any{
+		"ok":      true,
+		"page":    name,
+		"since":   since,
+		"limit":   limit,
+		"last_id": lastID,
+		"entries": entries,
+	})
+}
+
+// handlePageEvents returns buffered console/network history, or with
+// follow=1 streams live events as NDJSON until the client disconnects or the
+// page closes. Overlays are read from the harness hooks every overlayPoll.
+func (d *Daemon) handlePageEvents(w http.ResponseWriter, r *http.Request, contextName, name string) {
+	if r.Method != http.MethodGet {
+		d.writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "not found"})
+		return
+	}
+	query := r.URL.Query()
+	kinds, err := ParseEventKinds(query.Get("kinds"))
+	if err != nil {
+		d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+
+	if !queryTruthy(query.Get("follow")) {
+		limit := 200
+		if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+			val, err := strconv.Atoi(raw)
+			if err != nil || val < 0 {
+				d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid limit"})
+				return
+			}
+			limit = val
+		}
+		events, err := d.host.EventHistory(contextName, name, kinds, limit)
+		if err != nil {
+			d.writeJSON(w, errorStatus(err, http.StatusBadRequest), map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+		d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "page": name, "events": events})
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		d.writeJSON(w, http.StatusInternalServerError, map[string]any{"ok": false, "error": "streaming unsupported"})
+		return
+	}
+	sub, cancel, err := d.host.SubscribeEvents(contextName, name, kinds)
+	if err != nil {
+		d.writeJSON(w, errorStatus(err, http.StatusBadRequest), map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	defer cancel()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	enc := json.NewEncoder(w)
+	send := func(ev PageEvent) bool {
+		if err := enc.Encode(ev); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	var overlayTick <-chan time.Time
+	overlaySince := NowMS()
+	if sub.Wants(EventOverlay) {
+		ticker := time.NewTicker(overlayPoll)
+		defer ticker.Stop()
+		overlayTick = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-sub.Events():
+			if !ok {
+				send(PageEvent{Kind: "closed", Page: name, TimeMS: NowMS()})
+				return
+			}
+			if n := sub.TakeDropped(); n > 0 {
+				if !send(PageEvent{Kind: "dropped", Page: name, TimeMS: NowMS(), Data: map[string]any{"count": n}}) {
+					return
+				}
+			}
+			if !send(ev) {
+				return
+			}
+		case <-overlayTick:
+			state, err := d.host.HarnessState(contextName, name)
+			if err != nil || state == nil {
+				continue
+			}
+			var overlays []map[string]any
+			overlays, overlaySince = newOverlays(state, overlaySince)
+			for _, ov := range overlays {
+				tm, _ := ov["time_ms"].(float64)
+				if !send(PageEvent{Kind: EventOverlay, Page: name, TimeMS: int64(tm), Data: ov}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// handlePageDownloads serves GET /pages/{name}/downloads and
+// POST /pages/{name}/downloads/{id}/save with body {"path": "..."}.
+func (d *Daemon) handlePageDownloads(w http.ResponseWriter, r *http.Request, contextName, name string, rest []string) {
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodGet:
+		entries, err := d.host.Downloads(contextName, name)
+		if err != nil {
+			d.writeJSON(w, errorStatus(err, http.StatusBadRequest), map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+		d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "page": name, "downloads": entries})
+	case len(rest) == 2 && rest[1] == "save" && r.Method == http.MethodPost:
+		id, err := strconv.ParseInt(rest[0], 10, 64)
+		if err != nil || id <= 0 {
+			d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid download id"})
+			return
+		}
+		var body struct {
+			Path string `json:"path"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid json"})
+				return
+			}
+		}
+		entry, err := d.host.SaveDownload(contextName, name, id, body.Path)
+		if err != nil {
+			d.writeJSON(w, errorStatus(err, http.StatusUnprocessableEntity), map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+		saved := ""
+		if n := len(entry.SavedTo); n > 0 {
+			saved = entry.SavedTo[n-1]
+		}
+		d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "download": entry, "path": saved})
+	default:
+		d.writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "not found"})
+	}
+}
+
+// handlePageCall runs a tool, or a batch of calls, on the daemon's own handle
+// for the page.
+func (d *Daemon) handlePageCall(w http.ResponseWriter, r *http.Request, contextName, name string) {
+	if r.Method != http.MethodPost {
+		d.writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "not found"})
+		return
+	}
+	var body struct {
+		Tool    string                   `json:"tool"`
+		Args    map[string]interface{}   `json:"args"`
+		Actions []map[string]interface{} `json:"actions"`
+		// WaitTimeoutMS is how long to wait for a busy page; 0 fails at
+		// once, absent means defaultPageWait.
+		WaitTimeoutMS *int64 `json:"wait_timeout_ms"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid json"})
+		return
+	}
+	if strings.TrimSpace(body.Tool) == "" && body.Actions == nil {
+		d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "tool or actions is required"})
+		return
+	}
+	op := strings.TrimSpace(body.Tool)
+	if body.Actions != nil {
+		op = "actions"
+	}
+	tools := []string{op}
+	for _, call := range body.Actions {
+		tool, _ := call["name"].(string)
+		tools = append(tools, strings.TrimSpace(tool))
+	}
+	for _, tool := range tools {
+		if err := CheckExternalContext(d.host.ContextSettings(), contextName, tool); err != nil {
+			d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+	}
+	wait := time.Duration(-1)
+	if body.WaitTimeoutMS != nil {
+		wait = time.Duration(max(*body.WaitTimeoutMS, 0)) * time.Millisecond
+	}
+	// The request context ends when the client disconnects: a queued call is
+	// dropped and a batch stops before its next action.
+	ctx := r.Context()
+	release, err := d.host.AcquirePage(ctx, contextName, name, op, wait)
+	if err != nil {
+		if ctx.Err() != nil {
+			d.logger.Printf("page %s: client went away while waiting for %s", name, op)
+			return
+		}
+		d.writeJSON(w, errorStatus(err, http.StatusInternalServerError), map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	defer release()
+	if ctx.Err() != nil {
+		return
+	}
+
+	page, err := d.host.PageHandle(contextName, name)
+	if err != nil {
+		d.writeJSON(w, errorStatus(err, http.StatusInternalServerError), map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	artifactDir := ArtifactDir(d.opts.Profile)
+	if body.Actions != nil {
+		res, err := RunActionsContext(ctx, page, body.Actions, artifactDir)
+		if ctx.Err() != nil {
+			d.logger.Printf("page %s: client went away, actions stopped", name)
+			return
+		}
+		if err == nil {
+			err = d.recordActionSnapshots(contextName, name, body.Actions, &res)
+		}
+		if err != nil {
+			d.writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+		d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "results": res.Results, "snapshot": res.Snapshot})
+		return
+	}
+	args := body.Args
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	var res RunResult
+	if tool := strings.TrimSpace(body.Tool); tool == "snapshot" {
+		res, err = d.host.SnapshotPage(contextName, name, page, args)
+	} else {
+		res, err = RunCall(page, tool, args, artifactDir)
+	}
+	if err != nil {
+		d.writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "result": res})
+}
+
+// handlePageHold serves POST /pages/{name}/hold for commands that drive the
+// page over CDP themselves (--attach, loop, assert, ...). It waits its turn
+// in the page queue like a call, answers {"ok": true} once the page is free
+// and keeps the response open; the page is released when the client closes
+// the connection.
+func (d *Daemon) handlePageHold(w http.ResponseWriter, r *http.Request, contextName, name string) {
+	if r.Method != http.MethodPost {
+		d.writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "not found"})
+		return
+	}
+	var body struct {
+		Op            string `json:"op"`
+		WaitTimeoutMS *int64 `json:"wait_timeout_ms"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid json"})
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		d.writeJSON(w, http.StatusInternalServerError, map[string]any{"ok": false, "error": "streaming unsupported"})
+		return
+	}
+	op := strings.TrimSpace(body.Op)
+	if op == "" {
+		op = "attach"
+	}
+	wait := time.Duration(-1)
+	if body.WaitTimeoutMS != nil {
+		wait = time.Duration(max(*body.WaitTimeoutMS, 0)) * time.Millisecond
+	}
+	ctx := r.Context()
+	release, err := d.host.AcquirePage(ctx, contextName, name, op, wait)
+	if err != nil {
+		if ctx.Err() != nil {
+			d.logger.Printf("page %s: client went away while waiting for %s", name, op)
+			return
+		}
+		d.writeJSON(w, errorStatus(err, http.StatusInternalServerError), map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	defer release()
+	d.writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	flusher.Flush()
+	<-ctx.Done()
+}
+
+// recordActionSnapshots passes each snapshot of a batch, in order, through
+// RecordSnapshot, so "diff" works inside actions too.
+func (d *Daemon) recordActionSnapshots(contextName, name string, calls []map[string]interface{}, res *ActionsResult) error {
+	for i, entry := range res.Results {
+		result, ok := entry["result"].(RunResult)
+		if entry["name"] != "snapshot" || !ok || i >= len(calls) {
+			continue
+		}
+		args, _ := calls[i]["arguments"].(map[string]interface{})
+		result, err := d.host.RecordSnapshot(contextName, name, args, result)
+		if err != nil {
+			return err
+		}
+		entry["result"] = result
+		if snap, ok := result["snapshot"].(string); ok {
+			res.Snapshot = snap
+		}
+	}
+	return nil
+}
+
+func queryTruthy(raw string) bool {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
+
+func (d *Daemon) handleRoutes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "routes": d.host.ListRoutes()})
+	case http.MethodPost:
+		var rule RouteRule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid json"})
+			return
+		}
+		added, err := d.host.AddRoute(rule)
+		if err != nil {
+			d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+		d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "route": added})
+	case http.MethodDelete:
+		cleared, err := d.host.ClearRoutes()
+		if err != nil {
+			d.writeJSON(w, http.StatusInternalServerError, map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+		d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "cleared": cleared})
+	default:
+		d.writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "not found"})
+	}
+}
+
+func (d *Daemon) handleRoute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		d.writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "not found"})
+		return
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/routes/"), 10, 64)
+	if err != nil || id <= 0 {
+		d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid route id"})
+		return
+	}
+	removed, err := d.host.RemoveRoute(id)
+	if err != nil {
+		d.writeJSON(w, http.StatusInternalServerError, map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	if !removed {
+		d.writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "route not found"})
+		return
+	}
+	d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "removed": id})
+}
+
+func (d *Daemon) handleHAR(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/har" && r.Method == http.MethodGet:
+		d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "har": d.host.HARStatus()})
+	case r.URL.Path == "/har/record" && r.Method == http.MethodPost:
+		var body struct {
+			Path string `json:"path"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid json"})
+			return
+		}
+		st, err := d.host.StartHARRecording(body.Path)
+		if err != nil {
+			d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+		d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "har": st})
+	case r.URL.Path == "/har/stop" && r.Method == http.MethodPost:
+		st, err := d.host.StopHARRecording()
+		if err != nil {
+			d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+		d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "har": st})
+	case r.URL.Path == "/har/replay" && r.Method == http.MethodPost:
+		var replay HARReplay
+		if err := json.NewDecoder(r.Body).Decode(&replay); err != nil {
+			d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid json"})
+			return
+		}
+		applied, err := d.host.SetHARReplay(replay)
+		if err != nil {
+			d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+		d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "replay": applied})
+	case r.URL.Path == "/har/replay" && r.Method == http.MethodDelete:
+		cleared, err := d.host.ClearHARReplay()
+		if err != nil {
+			d.writeJSON(w, http.StatusInternalServerError, map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+		d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "cleared": cleared})
+	default:
+		d.writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "not found"})
+	}
+}
+
+func (d *Daemon) handleState(w http.ResponseWriter, r *http.Request) {
+	contextName, ok := d.contextParam(w, r.URL.Query().Get("context"))
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		state, err := d.host.SaveStorageState(contextName)
+		if err != nil {
+			d.writeJSON(w, errorStatus(err, http.StatusInternalServerError), map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+		d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "state": state})
+	case http.MethodPost:
+		var state StorageStateFile
+		if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+			d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid json"})
+			return
+		}
+		summary, err := d.host.LoadStorageState(contextName, state)
+		if err != nil {
+			d.writeJSON(w, errorStatus(err, http.StatusBadRequest), map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+		d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "loaded": summary})
+	default:
+		d.writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "not found"})
+	}
+}
+
+func (d *Daemon) handleContexts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "contexts": d.host.ListContexts()})
+	case http.MethodPost:
+		var body struct {
+			Name   string      `json:"name"`
+			Window *WindowSize `json:"window"`
+			Device string      `json:"device"`
+			Apply  bool        `json:"apply"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid json"})
+			return
+		}
+		info, action, reason, err := d.host.EnsureContext(body.Name, body.Window, body.Device, body.Apply)
+		if err != nil {
+			d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+		d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "context": info, "action": string(action), "reason": reason})
+	default:
+		d.writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "not found"})
+	}
+}
+
+func (d *Daemon) handleContext(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		d.writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "not found"})
+		return
+	}
+	name, err := url.PathUnescape(strings.TrimPrefix(r.URL.Path, "/contexts/"))
+	if err != nil {
+		d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid context name"})
+		return
+	}
+	closed, err := d.host.CloseContext(name)
+	if err != nil {
+		d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	if !closed {
+		d.writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "context not found"})
+		return
+	}
+	d.writeJSON(w, http.StatusOK, map[string]any{"ok": true, "closed": name})
+}
+
+// contextParam validates a context name from a query string or body, writing
+// a 400 response when it is malformed.
+func (d *Daemon) contextParam(w http.ResponseWriter, raw string) (string, bool) {
+	name, err := NormalizeContextName(raw)
+	if err != nil {
+		d.writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": err.Error()})
+		return "", false
+	}
+	return name, true
+}
+
+func contextLabel(name string) string {
+	if name == "" {
+		return DefaultContextName
+	}
+	return name
+}
+
+// errorStatus maps "... not found" host errors (unknown page or context) to
+// 404 and everything else to fallback.
+func errorStatus(err error, fallback int) int {
+	if errors.Is(err, ErrPageLimit) || errors.Is(err, ErrMemoryLimit) {
+		return http.StatusTooManyRequests
+	}
+	if errors.Is(err, ErrPageBusy) {
+		return http.StatusConflict
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	return fallback
+}
+
 func selectConsoleLogs(logs []ConsoleEntry, filter consoleLevelFilter, since int64, limit int) []ConsoleEntry {
 	entries := filterConsoleEntries(logs, filter)
 	if limit <= 0 || len(entries) <= limit {
@@ -307,20 +1026,115 @@ func (d *Daemon) writeJSON(w http.ResponseWriter, status int, payload map[string
 }
 
 func (d *Daemon) healthPayload() map[string]any {
+	status, crashes := d.host.CrashStatus()
+	payload := map[string]any{
+		"ok":      true,
+		"status":  status,
+		"crashes": crashes,
+		"pid":     os.Getpid(),
+		"host":    d.opts.Host,
+		"port":    d.opts.Port,
+		"socket":  d.opts.Socket,
+		"profile": d.opts.Profile,
+		"cdpPort": d.opts.CDPPort,
+		"version": DaemonVersion(),
+		"queues":  d.host.PageQueues(),
+
+		"buildVersion": BuildVersion(),
+		"assetHash":    AssetHash(),
+	}
+	if status == HealthDegraded {
+		payload["degraded"] = d.host.DegradedReasons()
+	}
+	if status == HealthRecovering {
+		// The host lock is held until the relaunch finishes; answer from the
+		// settings captured when the crash was seen.
+		payload["context"] = d.host.RecoverySettings()
+		return payload
+	}
 	ws, _ := d.host.WSEndpoint()
-	context := d.host.ContextSettings()
-	return map[string]any{
-		"ok":         true,
-		"pid":        os.Getpid(),
-		"host":       d.opts.Host,
-		"port":       d.opts.Port,
-		"profile":    d.opts.Profile,
-		"cdpPort":    d.opts.CDPPort,
-		"wsEndpoint": ws,
-		"version":    DaemonVersion(),
-		"context":    context,
-		"pageURL":    d.host.PrimaryPageURL(),
+	payload["wsEndpoint"] = ws
+	payload["resources"] = d.resources()
+	payload["context"] = d.host.ContextSettings()
+	payload["contexts"] = d.host.ListContexts()
+	payload["pageURL"] = d.host.PrimaryPageURL()
+	return payload
+}
+
+// trackActivity records when the last client request ended; requests still
+// running (a followed event stream, a long call) keep the daemon busy.
+func (d *Daemon) trackActivity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		d.inflight.Add(1)
+		defer func() {
+			d.lastActivity.Store(NowMS())
+			d.inflight.Add(-1)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (d *Daemon) idleFor() time.Duration {
+	if d.inflight.Load() > 0 {
+		return 0
+	}
+	return time.Duration(NowMS()-d.lastActivity.Load()) * time.Millisecond
+}
+
+func (d *Daemon) watchIdle(timeout time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(idleCheckInterval(timeout))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if idle := d.idleFor(); idle >= timeout {
+				d.logger.Printf("idle for %s (timeout %s); shutting down", idle.Round(time.Second), timeout)
+				_ = d.server.Shutdown(context.Background())
+				return
+			}
+		}
+	}
+}
+
+func idleCheckInterval(timeout time.Duration) time.Duration {
+	interval := timeout / 10
+	if interval < time.Second {
+		return time.Second
+	}
+	if interval > 30*time.Second {
+		return 30 * time.Second
 	}
+	return interval
+}
+
+func (d *Daemon) sampleMemory(done <-chan struct{}) {
+	ticker := time.NewTicker(memorySampleInterval)
+	defer ticker.Stop()
+	logged := false
+	for {
+		if _, err := d.host.SampleMemory(); err != nil && !logged {
+			d.logger.Printf("sample browser memory: %v", err)
+			logged = true
+		}
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Daemon) resources() DaemonResources {
+	res := d.host.Resources()
+	res.IdleMS = d.idleFor().Milliseconds()
+	res.IdleTimeoutMS = d.opts.IdleTimeout.Milliseconds()
+	return res
 }
 
 func (d *Daemon) writeCurrentState() error {
@@ -332,12 +1146,17 @@ func (d *Daemon) writeCurrentState() error {
 		PID:        os.Getpid(),
 		Host:       d.opts.Host,
 		Port:       d.opts.Port,
+		Socket:     d.opts.Socket,
 		Profile:    d.opts.Profile,
 		CDPPort:    d.opts.CDPPort,
 		WSEndpoint: ws,
 		Version:    DaemonVersion(),
 		Context:    d.host.ContextSettings(),
 		PageURL:    d.host.PrimaryPageURL(),
+		Token:      d.token,
+
+		BuildVersion: BuildVersion(),
+		AssetHash:    AssetHash(),
 	})
 }
 
@@ -350,12 +1169,18 @@ func chooseFreePort() (int, error) {
 	return ln.Addr().(*net.TCPAddr).Port, nil
 }
 
+// writeStateFile writes data atomically with mode 0600: the state holds the
+// daemon token.
 func writeStateFile(path string, data any) error {
 	tmp := path + ".tmp"
-	f, err := os.Create(tmp)
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
 	if err != nil {
 		return err
 	}
+	if err := f.Chmod(0o600); err != nil {
+		f.Close()
+		return err
+	}
 	enc := json.NewEncoder(f)
 	enc.SetIndent("", "  ")
 	if err := enc.Encode(data); err != nil {
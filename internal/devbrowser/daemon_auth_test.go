@@ -0,0 +1,108 @@
+package devbrowser
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestRequireToken(t *testing.T) {
+	handler := requireToken("secret", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for _, header := range []string{"", "Bearer wrong", "secret", "Bearer secret "} {
+		req := httptest.NewRequest(http.MethodPost, "/shutdown", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("Authorization %q: expected 401, got %d", header, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected valid token to pass, got %d", rec.Code)
+	}
+}
+
+func TestHTTPJSONSendsDaemonToken(t *testing.T) {
+	server := httptest.NewServer(requireToken("tok", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	})))
+	defer server.Close()
+
+	data, err := HTTPJSON(http.MethodGet, server.URL+"/health", nil, 0)
+	if err != nil || data["ok"] == true {
+		t.Fatalf("expected unauthorized without a known token, got %v, %v", data, err)
+	}
+
+	host, port := splitTestServerAddr(t, server.URL)
+	rememberDaemon(&DaemonState{Host: host, Port: port, Token: "tok"})
+	defer rememberDaemon(&DaemonState{Host: host, Port: port})
+	data, err = HTTPJSON(http.MethodGet, server.URL+"/health", nil, 0)
+	if err != nil || data["ok"] != true {
+		t.Fatalf("expected token to be sent, got %v, %v", data, err)
+	}
+}
+
+func TestWriteStateFileIsPrivate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.json")
+	if err := writeStateFile(path, DaemonState{Token: "tok"}); err != nil {
+		t.Fatalf("writeStateFile: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("expected mode 0600, got %o", perm)
+	}
+}
+
+func splitTestServerAddr(t *testing.T, rawURL string) (string, int) {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("parse %s: %v", rawURL, err)
+	}
+	host, portRaw, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		t.Fatalf("split %s: %v", u.Host, err)
+	}
+	port, err := strconv.Atoi(portRaw)
+	if err != nil {
+		t.Fatalf("port %s: %v", portRaw, err)
+	}
+	return host, port
+}
+
+func TestEnsureStateDirIsPrivate(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	// A state dir an older build left world-readable is tightened.
+	if err := os.MkdirAll(StateDir("p"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := EnsureStateDir("p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o700 {
+		t.Fatalf("state dir mode = %o, want 700", mode)
+	}
+}
@@ -5,3 +5,5 @@ package devbrowser
 import "os/exec"
 
 func configureDaemonProcess(cmd *exec.Cmd) {}
+
+func processAlive(pid int) bool { return false }
@@ -3,6 +3,7 @@
 package devbrowser
 
 import (
+	"errors"
 	"os/exec"
 	"syscall"
 )
@@ -12,3 +13,10 @@ func configureDaemonProcess(cmd *exec.Cmd) {
 	// short-lived CLI invocations and agent runners that clean up child groups.
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
 }
+
+// processAlive reports whether pid exists (signal 0 probes without
+// delivering anything; EPERM means it exists under another user).
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
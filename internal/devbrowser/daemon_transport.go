@@ -0,0 +1,122 @@
+package devbrowser
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxUnixSocketPath is the portable sun_path limit (104 on macOS, 108 on
+// Linux, both including the terminating NUL).
+const maxUnixSocketPath = 103
+
+type daemonEndpoint struct {
+	token  string
+	socket string
+}
+
+// daemonEndpoints maps a daemon URL host to the token and socket last read
+// from its state file, so HTTPJSON can authenticate and dial Unix sockets
+// without callers threading either through.
+var daemonEndpoints sync.Map
+
+// daemonAddr is the URL host for a daemon: host:port over TCP, or a stable
+// name derived from the socket path.
+func daemonAddr(host string, port int, socket string) string {
+	if socket != "" {
+		sum := sha256.Sum256([]byte(socket))
+		return "unix-" + hex.EncodeToString(sum[:6])
+	}
+	if host == "" || port == 0 {
+		return ""
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+func daemonBaseURL(host string, port int, socket string) string {
+	addr := daemonAddr(host, port, socket)
+	if addr == "" {
+		return ""
+	}
+	return "http://" + addr
+}
+
+func rememberDaemon(state *DaemonState) {
+	if state == nil {
+		return
+	}
+	addr := daemonAddr(state.Host, state.Port, state.Socket)
+	if addr == "" {
+		return
+	}
+	daemonEndpoints.Store(addr, daemonEndpoint{token: state.Token, socket: state.Socket})
+}
+
+func lookupDaemon(rawURL string) daemonEndpoint {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return daemonEndpoint{}
+	}
+	if ep, ok := daemonEndpoints.Load(u.Host); ok {
+		return ep.(daemonEndpoint)
+	}
+	return daemonEndpoint{}
+}
+
+func daemonHTTPClient(ep daemonEndpoint, timeout time.Duration) *http.Client {
+	if ep.socket == "" {
+		return &http.Client{Timeout: timeout}
+	}
+	socket := ep.socket
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+}
+
+// listenUnixSocket listens on path with mode 0600 so only the owner can reach
+// the daemon. A leftover socket from a crashed daemon is replaced; one that
+// still accepts connections is an error.
+func listenUnixSocket(path string) (net.Listener, error) {
+	if len(path) > maxUnixSocketPath {
+		return nil, fmt.Errorf("unix socket path too long (%d bytes, max %d): %s; set XDG_STATE_HOME to a shorter directory", len(path), maxUnixSocketPath, path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, 500*time.Millisecond); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("unix socket %s is in use by another daemon", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
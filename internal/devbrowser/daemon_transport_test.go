@@ -0,0 +1,76 @@
+package devbrowser
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHTTPJSONDialsUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "daemon.sock")
+	ln, err := listenUnixSocket(socket)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := &http.Server{Handler: requireToken("tok", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true,"path":"` + r.URL.Path + `"}`))
+	}))}
+	go func() { _ = srv.Serve(ln) }()
+	defer srv.Close()
+
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatalf("stat socket: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("expected socket mode 0600, got %o", perm)
+	}
+
+	state := &DaemonState{Socket: socket, Token: "tok"}
+	rememberDaemon(state)
+	base := daemonBaseURL(state.Host, state.Port, state.Socket)
+	if !strings.HasPrefix(base, "http://unix-") {
+		t.Fatalf("unexpected base url %q", base)
+	}
+	data, err := HTTPJSON(http.MethodGet, base+"/health", nil, 0)
+	if err != nil || data["ok"] != true || data["path"] != "/health" {
+		t.Fatalf("expected request over the socket, got %v, %v", data, err)
+	}
+
+	if _, err := listenUnixSocket(socket); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Fatalf("expected live socket to be refused, got %v", err)
+	}
+}
+
+func TestListenUnixSocketReplacesStaleSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "daemon.sock")
+	if err := os.WriteFile(socket, nil, 0o600); err != nil {
+		t.Fatalf("write stale socket: %v", err)
+	}
+	ln, err := listenUnixSocket(socket)
+	if err != nil {
+		t.Fatalf("expected stale socket to be replaced, got %v", err)
+	}
+	ln.Close()
+
+	long := filepath.Join(t.TempDir(), strings.Repeat("x", maxUnixSocketPath), "daemon.sock")
+	if _, err := listenUnixSocket(long); err == nil || !strings.Contains(err.Error(), "too long") {
+		t.Fatalf("expected path length error, got %v", err)
+	}
+}
+
+func TestDaemonAddr(t *testing.T) {
+	if got := daemonAddr("127.0.0.1", 9222, ""); got != "127.0.0.1:9222" {
+		t.Fatalf("unexpected tcp addr %q", got)
+	}
+	if got := daemonAddr("", 0, ""); got != "" {
+		t.Fatalf("expected empty addr without port, got %q", got)
+	}
+	a, b := daemonAddr("", 0, "/a/daemon.sock"), daemonAddr("", 0, "/b/daemon.sock")
+	if a == b || a != daemonAddr("127.0.0.1", 1, "/a/daemon.sock") {
+		t.Fatalf("expected socket addrs to be stable and distinct, got %q %q", a, b)
+	}
+}
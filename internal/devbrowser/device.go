@@ -87,6 +87,21 @@ func applyDeviceDescriptor(opts *playwright.BrowserTypeLaunchPersistentContextOp
 	opts.HasTouch = playwright.Bool(desc.HasTouch)
 }
 
+// newContextOptionsFromLaunch carries the emulation fields of persistent
+// launch options over to a regular browser.NewContext call.
+func newContextOptionsFromLaunch(launch *playwright.BrowserTypeLaunchPersistentContextOptions) playwright.BrowserNewContextOptions {
+	return playwright.BrowserNewContextOptions{
+		AcceptDownloads:   launch.AcceptDownloads,
+		IgnoreHttpsErrors: launch.IgnoreHttpsErrors,
+		UserAgent:         launch.UserAgent,
+		Viewport:          launch.Viewport,
+		Screen:            launch.Screen,
+		DeviceScaleFactor: launch.DeviceScaleFactor,
+		IsMobile:          launch.IsMobile,
+		HasTouch:          launch.HasTouch,
+	}
+}
+
 func ListDeviceNames() ([]string, error) {
 	pw, err := playwright.Run()
 	if err != nil {
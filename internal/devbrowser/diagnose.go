@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -66,6 +67,7 @@ type DiagnoseMeta struct {
 	TS          string `json:"ts"`
 	RunID       string `json:"runId"`
 	ArtifactDir string `json:"artifactDir,omitempty"`
+	ReplayHAR   string `json:"replayHar,omitempty"`
 }
 
 type DiagnoseConsoleCounts struct {
@@ -103,6 +105,7 @@ type DiagnoseSummary struct {
 	HasConsoleErrors  bool `json:"hasConsoleErrors"`
 	HasHttp4xx5xx     bool `json:"hasHttp4xx5xx"`
 	HasFailedRequests bool `json:"hasFailedRequests"`
+	HasCrashes        bool `json:"hasCrashes"`
 
 	HasHarnessErrors     bool   `json:"hasHarnessErrors"`
 	HarnessErrorCount    int    `json:"harnessErrorCount"`
@@ -118,7 +121,7 @@ type DiagnoseSummary struct {
 }
 
 type DiagnoseEvent struct {
-	Kind   string         `json:"kind"` // console|network|errorhook|overlay
+	Kind   string         `json:"kind"` // console|network|errorhook|overlay|crash
 	TimeMS int64          `json:"time_ms"`
 	Data   map[string]any `json:"data"`
 }
@@ -134,6 +137,8 @@ type DiagnoseReport struct {
 	Perf      map[string]any          `json:"perf"`
 	Snapshot  DiagnoseSnapshotSection `json:"snapshot"`
 	Harness   DiagnoseHarnessSection  `json:"harness"`
+	Routes    []RouteRule             `json:"routes,omitempty"`
+	Crashes   []CrashReport           `json:"crashes,omitempty"`
 	Events    []DiagnoseEvent         `json:"events"`
 	Artifacts DiagnoseArtifacts       `json:"artifacts"`
 	Summary   DiagnoseSummary         `json:"summary"`
@@ -305,10 +310,51 @@ func (r *DiagnoseReport) SetConsole(entries []ConsoleEntry) {
 	}
 	r.Console = DiagnoseConsoleSection{Entries: entries, Counts: counts}
 	// Rebuild events when console is populated.
-	r.Events = BuildDiagnoseEvents(r.Console.Entries, r.Network.Entries, r.Harness.State)
+	r.rebuildEvents()
 	r.computeSummary()
 }
 
+// SetCrashes records crashes the daemon recovered from (or failed to) for
+// this page in contextName, plus browser-wide ones, and adds them to the
+// timeline.
+func (r *DiagnoseReport) SetCrashes(crashes []CrashReport, contextName string) {
+	out := make([]CrashReport, 0, len(crashes))
+	for _, c := range crashes {
+		if c.Kind == CrashPage && (c.Page != r.Meta.Page || c.Context != contextName) {
+			continue
+		}
+		out = append(out, c)
+	}
+	if len(out) == 0 {
+		out = nil
+	}
+	r.Crashes = out
+	r.rebuildEvents()
+	r.computeSummary()
+}
+
+func (r *DiagnoseReport) rebuildEvents() {
+	events := BuildDiagnoseEvents(r.Console.Entries, r.Network.Entries, r.Harness.State)
+	if len(r.Crashes) > 0 {
+		events = append(events, crashDiagnoseEvents(r.Crashes)...)
+		sortDiagnoseEvents(events)
+	}
+	r.Events = events
+}
+
+// SetRoutes records the daemon's active interception rules (with hit counts)
+// so mocked or aborted requests are visible in the report.
+func (r *DiagnoseReport) SetRoutes(routes []RouteRule) {
+	if len(routes) == 0 {
+		r.Routes = nil
+		return
+	}
+	out := make([]RouteRule, len(routes))
+	copy(out, routes)
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	r.Routes = out
+}
+
 func (r *DiagnoseReport) computeSummary() {
 	// Console errors.
 	hasConsoleErrors := r.Console.Counts.Error > 0
@@ -409,6 +455,7 @@ func (r *DiagnoseReport) computeSummary() {
 		HasConsoleErrors:  hasConsoleErrors,
 		HasHttp4xx5xx:     has4xx5xx,
 		HasFailedRequests: hasFailed,
+		HasCrashes:        len(r.Crashes) > 0,
 
 		HasHarnessErrors:     hasHarnessErrors,
 		HarnessErrorCount:    harnessErrorCount,
@@ -0,0 +1,168 @@
+package devbrowser
+
+import (
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const defaultDownloadLogMax = 100
+
+// Download states reported in DownloadEntry.State.
+const (
+	DownloadInProgress = "in_progress"
+	DownloadCompleted  = "completed"
+	DownloadFailed     = "failed"
+)
+
+// DownloadEntry is a download observed on a named page. Times are epoch
+// milliseconds; SavedTo lists every path the file was saved to.
+type DownloadEntry struct {
+	ID                int64    `json:"id"`
+	URL               string   `json:"url"`
+	SuggestedFilename string   `json:"suggested_filename"`
+	State             string   `json:"state"`
+	Started           int64    `json:"started_ms"`
+	Finished          int64    `json:"finished_ms,omitempty"`
+	Size              int64    `json:"size,omitempty"`
+	Error             string   `json:"error,omitempty"`
+	SavedTo           []string `json:"saved_to,omitempty"`
+}
+
+type downloadRecord struct {
+	entry    DownloadEntry
+	download playwright.Download
+}
+
+// downloadStore follows networkStore: a ring buffer per page key with IDs that
+// increase monotonically across pages. It keeps the Playwright handle so a
+// finished download can be saved later; Playwright deletes its temp copy when
+// the context closes.
+type downloadStore struct {
+	mu     sync.Mutex
+	logs   map[string][]*downloadRecord
+	max    int
+	nextID int64
+}
+
+func newDownloadStore(max int) *downloadStore {
+	if max <= 0 {
+		max = defaultDownloadLogMax
+	}
+	return &downloadStore{
+		logs: make(map[string][]*downloadRecord),
+		max:  max,
+	}
+}
+
+// track records a new download and waits for it to settle in the background.
+func (s *downloadStore) track(name string, dl playwright.Download) {
+	if dl == nil {
+		return
+	}
+	rec := s.add(name, dl)
+	go func() {
+		path, err := dl.Path()
+		s.settle(rec, path, err)
+	}()
+}
+
+func (s *downloadStore) add(name string, dl playwright.Download) *downloadRecord {
+	rec := &downloadRecord{
+		entry: DownloadEntry{
+			URL:               dl.URL(),
+			SuggestedFilename: dl.SuggestedFilename(),
+			State:             DownloadInProgress,
+			Started:           NowMS(),
+		},
+		download: dl,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	rec.entry.ID = s.nextID
+	logs := s.logs[name]
+	if s.max > 0 && len(logs) >= s.max {
+		logs = logs[len(logs)-s.max+1:]
+	}
+	s.logs[name] = append(logs, rec)
+	return rec
+}
+
+func (s *downloadStore) settle(rec *downloadRecord, path string, err error) {
+	var size int64
+	if err == nil {
+		if info, statErr := os.Stat(path); statErr == nil {
+			size = info.Size()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec.entry.Finished = NowMS()
+	if err != nil {
+		rec.entry.State = DownloadFailed
+		rec.entry.Error = err.Error()
+		return
+	}
+	rec.entry.State = DownloadCompleted
+	rec.entry.Size = size
+}
+
+func (s *downloadStore) list(name string) []DownloadEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	logs := s.logs[name]
+	out := make([]DownloadEntry, 0, len(logs))
+	for _, rec := range logs {
+		out = append(out, cloneDownloadEntry(rec.entry))
+	}
+	return out
+}
+
+// lookup returns the entry and handle for id on the given page key.
+func (s *downloadStore) lookup(name string, id int64) (DownloadEntry, playwright.Download, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, rec := range s.logs[name] {
+		if rec.entry.ID == id {
+			return cloneDownloadEntry(rec.entry), rec.download, nil
+		}
+	}
+	return DownloadEntry{}, nil, errors.New("download not found")
+}
+
+func (s *downloadStore) markSaved(name string, id int64, path string) (DownloadEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, rec := range s.logs[name] {
+		if rec.entry.ID == id {
+			rec.entry.SavedTo = append(rec.entry.SavedTo, path)
+			return cloneDownloadEntry(rec.entry), nil
+		}
+	}
+	return DownloadEntry{}, errors.New("download not found")
+}
+
+func (s *downloadStore) clear(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.logs, name)
+}
+
+func (s *downloadStore) clearAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logs = make(map[string][]*downloadRecord)
+	s.nextID = 0
+}
+
+func cloneDownloadEntry(e DownloadEntry) DownloadEntry {
+	if e.SavedTo != nil {
+		e.SavedTo = append([]string(nil), e.SavedTo...)
+	}
+	return e
+}
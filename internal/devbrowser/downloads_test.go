@@ -0,0 +1,66 @@
+package devbrowser
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+func TestDownloadStoreLifecycle(t *testing.T) {
+	store := newDownloadStore(2)
+	a := store.add("main", fakeDownload{url: "https://example.com/a.csv", name: "a.csv"})
+	store.add("other", fakeDownload{url: "https://example.com/b.csv", name: "b.csv"})
+	if a.entry.ID != 1 || a.entry.State != DownloadInProgress {
+		t.Fatalf("unexpected first entry: %+v", a.entry)
+	}
+
+	store.settle(a, "/nonexistent", nil)
+	list := store.list("main")
+	if len(list) != 1 || list[0].State != DownloadCompleted || list[0].Finished == 0 {
+		t.Fatalf("expected completed download, got %+v", list)
+	}
+
+	c := store.add("main", fakeDownload{name: "c.csv"})
+	store.settle(c, "", errors.New("canceled"))
+	store.add("main", fakeDownload{name: "d.csv"})
+	list = store.list("main")
+	if len(list) != 2 || list[0].ID != 3 || list[0].State != DownloadFailed || list[0].Error != "canceled" {
+		t.Fatalf("expected ring buffer to keep failed c and d, got %+v", list)
+	}
+
+	if _, _, err := store.lookup("main", 1); err == nil {
+		t.Fatal("expected evicted download to be gone")
+	}
+	if _, _, err := store.lookup("other", 2); err != nil {
+		t.Fatalf("lookup other: %v", err)
+	}
+	saved, err := store.markSaved("other", 2, "/tmp/b.csv")
+	if err != nil || len(saved.SavedTo) != 1 {
+		t.Fatalf("markSaved: %+v, %v", saved, err)
+	}
+
+	store.clear("other")
+	if len(store.list("other")) != 0 {
+		t.Fatal("expected cleared page to have no downloads")
+	}
+	store.clearAll()
+	if e := store.add("main", fakeDownload{}); e.entry.ID != 1 {
+		t.Fatalf("expected ids to restart after clearAll, got %d", e.entry.ID)
+	}
+}
+
+type fakeDownload struct {
+	url  string
+	name string
+}
+
+func (f fakeDownload) Cancel() error             { return nil }
+func (f fakeDownload) Delete() error             { return nil }
+func (f fakeDownload) Failure() error            { return nil }
+func (f fakeDownload) Page() playwright.Page     { return nil }
+func (f fakeDownload) Path() (string, error)     { return "", nil }
+func (f fakeDownload) SaveAs(string) error       { return nil }
+func (f fakeDownload) SuggestedFilename() string { return f.name }
+func (f fakeDownload) URL() string               { return f.url }
+func (f fakeDownload) String() string            { return f.url }
@@ -70,6 +70,31 @@ func BuildDiagnoseEvents(console []ConsoleEntry, network []NetworkEntry, harness
 		}
 	}
 
+	sortDiagnoseEvents(events)
+	return events
+}
+
+// crashDiagnoseEvents turns crash reports into timeline events carrying the
+// console errors seen just before each crash.
+func crashDiagnoseEvents(crashes []CrashReport) []DiagnoseEvent {
+	events := make([]DiagnoseEvent, 0, len(crashes))
+	for _, c := range crashes {
+		data := map[string]any{
+			"id":             c.ID,
+			"crash":          c.Kind,
+			"context":        c.Context,
+			"page":           c.Page,
+			"url":            c.URL,
+			"recovered":      c.Recovered,
+			"error":          c.Error,
+			"console_errors": c.ConsoleErrors,
+		}
+		events = append(events, DiagnoseEvent{Kind: "crash", TimeMS: c.TimeMS, Data: data})
+	}
+	return events
+}
+
+func sortDiagnoseEvents(events []DiagnoseEvent) {
 	// Use stable sort with complete tie-breakers for deterministic output
 	sort.SliceStable(events, func(i, j int) bool {
 		if events[i].TimeMS != events[j].TimeMS {
@@ -81,8 +106,6 @@ func BuildDiagnoseEvents(console []ConsoleEntry, network []NetworkEntry, harness
 		// Stable tie-breakers by kind
 		return stableCompareData(events[i], events[j])
 	})
-
-	return events
 }
 
 // stableCompareData provides deterministic ordering for events with same TimeMS and Kind
@@ -127,6 +150,10 @@ func stableCompareData(a, b DiagnoseEvent) bool {
 	case "overlay":
 		// Compare by text
 		return stringField(a.Data, "text") < stringField(b.Data, "text")
+	case "crash":
+		idA, _ := int64Field(a.Data, "id")
+		idB, _ := int64Field(b.Data, "id")
+		return idA < idB
 	default:
 		return false
 	}
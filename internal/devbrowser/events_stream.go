@@ -0,0 +1,270 @@
+package devbrowser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Event kinds pushed by the daemon's /pages/{name}/events stream.
+const (
+	EventConsole    = "console"
+	EventPageError  = "pageerror"
+	EventNavigation = "navigation"
+	EventNetwork    = "network"
+	EventOverlay    = "overlay"
+	EventCrash      = "crash"
+)
+
+// EventKinds lists every kind a follower can select.
+var EventKinds = []string{EventConsole, EventPageError, EventNavigation, EventNetwork, EventOverlay, EventCrash}
+
+const eventBufferSize = 256
+
+// PageEvent is one line of the event stream. Console, network and navigation
+// events carry IDs that increase monotonically across pages; overlay,
+// history and control ("dropped", "closed") events have none.
+type PageEvent struct {
+	ID     int64          `json:"id,omitempty"`
+	Kind   string         `json:"kind"`
+	Page   string         `json:"page"`
+	TimeMS int64          `json:"time_ms"`
+	Data   map[string]any `json:"data,omitempty"`
+}
+
+// ParseEventKinds reads a comma-separated kind list; "" and "all" select
+// every kind.
+func ParseEventKinds(raw string) (map[string]bool, error) {
+	kinds := make(map[string]bool)
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.EqualFold(raw, "all") {
+		for _, k := range EventKinds {
+			kinds[k] = true
+		}
+		return kinds, nil
+	}
+	for _, part := range strings.Split(raw, ",") {
+		k := strings.ToLower(strings.TrimSpace(part))
+		if k == "" {
+			continue
+		}
+		known := false
+		for _, candidate := range EventKinds {
+			if k == candidate {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown event kind %q (use %s)", k, strings.Join(EventKinds, ","))
+		}
+		kinds[k] = true
+	}
+	if len(kinds) == 0 {
+		return nil, fmt.Errorf("no event kinds in %q", raw)
+	}
+	return kinds, nil
+}
+
+type eventSubscriber struct {
+	ch      chan PageEvent
+	kinds   map[string]bool
+	dropped atomic.Int64
+}
+
+// Events is closed when the page (or the whole browser) goes away.
+func (s *eventSubscriber) Events() <-chan PageEvent { return s.ch }
+
+// TakeDropped returns and resets the number of events dropped because the
+// follower fell behind.
+func (s *eventSubscriber) TakeDropped() int64 { return s.dropped.Swap(0) }
+
+// Wants reports whether the subscriber selected kind.
+func (s *eventSubscriber) Wants(kind string) bool { return s.kinds[kind] }
+
+// eventHub fans page events out to followers. Publishing never blocks: a
+// follower whose buffer is full loses the event and is told how many it
+// missed.
+type eventHub struct {
+	mu     sync.Mutex
+	subs   map[string]map[*eventSubscriber]struct{}
+	nextID int64
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{subs: make(map[string]map[*eventSubscriber]struct{})}
+}
+
+func (h *eventHub) subscribe(key string, kinds map[string]bool) *eventSubscriber {
+	sub := &eventSubscriber{ch: make(chan PageEvent, eventBufferSize), kinds: kinds}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[key] == nil {
+		h.subs[key] = make(map[*eventSubscriber]struct{})
+	}
+	h.subs[key][sub] = struct{}{}
+	return sub
+}
+
+func (h *eventHub) unsubscribe(key string, sub *eventSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if subs, ok := h.subs[key]; ok {
+		if _, ok := subs[sub]; ok {
+			delete(subs, sub)
+			close(sub.ch)
+		}
+		if len(subs) == 0 {
+			delete(h.subs, key)
+		}
+	}
+}
+
+func (h *eventHub) publish(key, page, kind string, timeMS int64, data map[string]any) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	subs := h.subs[key]
+	if len(subs) == 0 {
+		return
+	}
+	h.nextID++
+	if timeMS == 0 {
+		timeMS = NowMS()
+	}
+	ev := PageEvent{ID: h.nextID, Kind: kind, Page: page, TimeMS: timeMS, Data: data}
+	for sub := range subs {
+		if !sub.kinds[kind] {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// closePage ends every stream following key.
+func (h *eventHub) closePage(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs[key] {
+		close(sub.ch)
+	}
+	delete(h.subs, key)
+}
+
+func (h *eventHub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for key, subs := range h.subs {
+		for sub := range subs {
+			close(sub.ch)
+		}
+		delete(h.subs, key)
+	}
+}
+
+func consoleEventKind(e ConsoleEntry) string {
+	if e.Type == "pageerror" {
+		return EventPageError
+	}
+	return EventConsole
+}
+
+func consoleEventData(e ConsoleEntry) map[string]any {
+	data := map[string]any{"id": e.ID, "type": e.Type, "text": e.Text}
+	if e.URL != "" {
+		data["url"] = e.URL
+		data["line"] = e.Line
+		data["col"] = e.Column
+	}
+	return data
+}
+
+func networkEventData(e NetworkLogEntry) map[string]any {
+	data := map[string]any{
+		"id":          e.ID,
+		"url":         e.URL,
+		"method":      e.Method,
+		"type":        e.Type,
+		"status":      e.Status,
+		"ok":          e.OK,
+		"duration_ms": e.Finished - e.Started,
+	}
+	if e.Error != "" {
+		data["error"] = e.Error
+	}
+	return data
+}
+
+// hookPageEvents publishes navigations; console, page errors and network
+// completions are published where the stores record them.
+func (b *BrowserHost) hookPageEvents(key, name string, page playwright.Page) {
+	if b.events == nil {
+		return
+	}
+	page.OnFrameNavigated(func(frame playwright.Frame) {
+		if frame == nil || frame.ParentFrame() != nil {
+			return
+		}
+		b.events.publish(key, name, EventNavigation, 0, map[string]any{"url": frame.URL()})
+	})
+}
+
+// newOverlays returns harness overlay records newer than sinceMS, oldest
+// first, along with the newest time seen.
+func newOverlays(state map[string]any, sinceMS int64) ([]map[string]any, int64) {
+	arr, _ := state["overlays"].([]interface{})
+	latest := sinceMS
+	out := make([]map[string]any, 0)
+	for _, v := range arr {
+		m, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		tm, ok := m["time_ms"].(float64)
+		if !ok || int64(tm) <= sinceMS {
+			continue
+		}
+		out = append(out, m)
+		if int64(tm) > latest {
+			latest = int64(tm)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		ti, _ := out[i]["time_ms"].(float64)
+		tj, _ := out[j]["time_ms"].(float64)
+		return ti < tj
+	})
+	return out, latest
+}
+
+// eventHistory merges the buffered console and network logs into events,
+// oldest first, keeping the newest limit.
+func eventHistory(name string, console []ConsoleEntry, network []NetworkLogEntry, kinds map[string]bool, limit int) []PageEvent {
+	events := make([]PageEvent, 0, len(console)+len(network))
+	for _, e := range console {
+		kind := consoleEventKind(e)
+		if kinds[kind] {
+			events = append(events, PageEvent{Kind: kind, Page: name, TimeMS: e.TimeMS, Data: consoleEventData(e)})
+		}
+	}
+	if kinds[EventNetwork] {
+		for _, e := range network {
+			if e.Pending {
+				continue
+			}
+			events = append(events, PageEvent{Kind: EventNetwork, Page: name, TimeMS: e.Finished, Data: networkEventData(e)})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].TimeMS < events[j].TimeMS })
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	return events
+}
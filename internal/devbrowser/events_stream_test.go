@@ -0,0 +1,97 @@
+package devbrowser
+
+import "testing"
+
+func TestParseEventKinds(t *testing.T) {
+	all, err := ParseEventKinds("")
+	if err != nil || len(all) != len(EventKinds) {
+		t.Fatalf("expected all kinds, got %v, %v", all, err)
+	}
+	kinds, err := ParseEventKinds(" Console, network ,")
+	if err != nil || !kinds[EventConsole] || !kinds[EventNetwork] || kinds[EventOverlay] {
+		t.Fatalf("unexpected kinds %v, %v", kinds, err)
+	}
+	for _, bad := range []string{"console,requests", ",", " , "} {
+		if _, err := ParseEventKinds(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestEventHubFiltersDropsAndCloses(t *testing.T) {
+	hub := newEventHub()
+	hub.publish("main", "main", EventConsole, 0, nil) // no followers: ignored
+
+	consoleOnly := hub.subscribe("main", map[string]bool{EventConsole: true})
+	other := hub.subscribe("other", map[string]bool{EventConsole: true})
+
+	hub.publish("main", "main", EventNetwork, 10, nil)
+	hub.publish("main", "main", EventConsole, 20, map[string]any{"text": "hi"})
+	ev := <-consoleOnly.Events()
+	if ev.Kind != EventConsole || ev.TimeMS != 20 || ev.ID != 2 || ev.Data["text"] != "hi" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	select {
+	case ev := <-other.Events():
+		t.Fatalf("event leaked to another page: %+v", ev)
+	default:
+	}
+
+	for i := 0; i < eventBufferSize+3; i++ {
+		hub.publish("main", "main", EventConsole, 1, nil)
+	}
+	if n := consoleOnly.TakeDropped(); n != 3 {
+		t.Fatalf("expected 3 dropped events, got %d", n)
+	}
+	if n := consoleOnly.TakeDropped(); n != 0 {
+		t.Fatalf("expected dropped count to reset, got %d", n)
+	}
+
+	hub.closePage("main")
+	for range consoleOnly.Events() {
+	}
+	hub.unsubscribe("main", consoleOnly) // already closed: must not panic
+	hub.closeAll()
+	if _, ok := <-other.Events(); ok {
+		t.Fatal("expected closeAll to close every stream")
+	}
+}
+
+func TestNewOverlays(t *testing.T) {
+	state := map[string]any{"overlays": []interface{}{
+		map[string]any{"type": "vite", "time_ms": float64(300), "text": "b"},
+		map[string]any{"type": "vite", "time_ms": float64(100), "text": "old"},
+		map[string]any{"type": "vite", "time_ms": float64(200), "text": "a"},
+		"junk",
+	}}
+	got, latest := newOverlays(state, 100)
+	if len(got) != 2 || got[0]["text"] != "a" || got[1]["text"] != "b" || latest != 300 {
+		t.Fatalf("unexpected overlays %v latest=%d", got, latest)
+	}
+	if got, latest := newOverlays(state, 300); len(got) != 0 || latest != 300 {
+		t.Fatalf("expected nothing new, got %v latest=%d", got, latest)
+	}
+}
+
+func TestEventHistory(t *testing.T) {
+	console := []ConsoleEntry{
+		{ID: 1, TimeMS: 30, Type: "log", Text: "later"},
+		{ID: 2, TimeMS: 10, Type: "pageerror", Text: "boom"},
+	}
+	network := []NetworkLogEntry{
+		{ID: 1, NetworkEntry: NetworkEntry{URL: "/api", Started: 5, Finished: 20, Status: 200}},
+		{ID: 2, NetworkEntry: NetworkEntry{URL: "/slow", Started: 6}, Pending: true},
+	}
+	all, _ := ParseEventKinds("")
+	events := eventHistory("main", console, network, all, 0)
+	if len(events) != 3 || events[0].Kind != EventPageError || events[1].Kind != EventNetwork || events[2].Kind != EventConsole {
+		t.Fatalf("unexpected history %+v", events)
+	}
+	if d := events[1].Data["duration_ms"]; d != int64(15) {
+		t.Fatalf("expected duration 15, got %v", d)
+	}
+	events = eventHistory("main", console, network, map[string]bool{EventConsole: true}, 1)
+	if len(events) != 1 || events[0].Data["text"] != "later" {
+		t.Fatalf("expected kind filter and limit, got %+v", events)
+	}
+}
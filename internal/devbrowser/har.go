@@ -0,0 +1,342 @@
+package devbrowser
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const (
+	HARNotFoundAbort    = "abort"
+	HARNotFoundFallback = "fallback"
+)
+
+// HARFile is the subset of the HAR 1.2 format written by the recorder. It is
+// also what Playwright's routeFromHAR consumes for replay.
+type HARFile struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	ResourceType    string      `json:"_resourceType,omitempty"`
+
+	startedMS float64
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// HARStatus describes the daemon's recording/replay state.
+type HARStatus struct {
+	Recording  bool       `json:"recording"`
+	RecordPath string     `json:"record_path,omitempty"`
+	Entries    int        `json:"entries"`
+	Failed     int        `json:"failed"`
+	StartedMS  int64      `json:"started_ms,omitempty"`
+	Replay     *HARReplay `json:"replay,omitempty"`
+}
+
+// HARReplay configures serving responses from a HAR file. NotFound decides
+// what happens to requests missing from the file: abort (strict) or fallback
+// to the network.
+type HARReplay struct {
+	Path      string `json:"path"`
+	NotFound  string `json:"not_found,omitempty"`
+	URL       string `json:"url,omitempty"`
+	StartedMS int64  `json:"started_ms,omitempty"`
+}
+
+type harRecorder struct {
+	mu        sync.Mutex
+	path      string
+	startedMS int64
+	entries   []HAREntry
+	failed    int
+	pending   sync.WaitGroup
+}
+
+func newHARRecorder(path string) *harRecorder {
+	return &harRecorder{path: path, startedMS: NowMS()}
+}
+
+// capture runs off the event goroutine: fetching bodies is a protocol round-trip.
+func (r *harRecorder) capture(req playwright.Request) {
+	r.pending.Add(1)
+	go func() {
+		defer r.pending.Done()
+		entry, ok := buildHAREntry(req)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if !ok {
+			r.failed++
+			return
+		}
+		r.entries = append(r.entries, entry)
+	}()
+}
+
+func (r *harRecorder) status() HARStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return HARStatus{
+		Recording:  true,
+		RecordPath: r.path,
+		Entries:    len(r.entries),
+		Failed:     r.failed,
+		StartedMS:  r.startedMS,
+	}
+}
+
+// finish waits briefly for in-flight captures and writes the HAR file.
+func (r *harRecorder) finish(timeout time.Duration) (HARStatus, error) {
+	done := make(chan struct{})
+	go func() {
+		r.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+
+	r.mu.Lock()
+	entries := append([]HAREntry{}, r.entries...)
+	r.mu.Unlock()
+	sortHAREntries(entries)
+
+	har := HARFile{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "dev-browser-go", Version: DaemonVersion()},
+		Entries: entries,
+	}}
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return HARStatus{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return HARStatus{}, err
+	}
+	// Recordings hold cookies and Authorization headers; keep them private,
+	// also when replacing an older file.
+	if err := os.WriteFile(r.path, data, 0o600); err != nil {
+		return HARStatus{}, err
+	}
+	if err := os.Chmod(r.path, 0o600); err != nil {
+		return HARStatus{}, err
+	}
+	st := r.status()
+	st.Recording = false
+	return st, nil
+}
+
+func sortHAREntries(entries []HAREntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].startedMS == entries[j].startedMS {
+			return entries[i].Request.URL < entries[j].Request.URL
+		}
+		return entries[i].startedMS < entries[j].startedMS
+	})
+}
+
+func buildHAREntry(req playwright.Request) (HAREntry, bool) {
+	if req == nil {
+		return HAREntry{}, false
+	}
+	resp, err := req.Response()
+	if err != nil || resp == nil {
+		return HAREntry{}, false
+	}
+
+	timing := req.Timing()
+	entry := HAREntry{
+		Request: HARRequest{
+			Method:      req.Method(),
+			URL:         req.URL(),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []HARNameValue{},
+			Headers:     harHeaders(req.HeadersArray()),
+			QueryString: harQueryString(req.URL()),
+			HeadersSize: -1,
+			BodySize:    0,
+		},
+		Response: HARResponse{
+			Status:      resp.Status(),
+			StatusText:  resp.StatusText(),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []HARNameValue{},
+			Headers:     harHeaders(resp.HeadersArray()),
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		ResourceType: safeString(req.ResourceType()),
+	}
+
+	if body, err := req.PostDataBuffer(); err == nil && len(body) > 0 {
+		mime, _ := req.HeaderValue("content-type")
+		entry.Request.PostData = &HARPostData{MimeType: mime, Text: string(body)}
+		entry.Request.BodySize = len(body)
+	}
+
+	mime, _ := resp.HeaderValue("content-type")
+	entry.Response.Content.MimeType = mime
+	if location, _ := resp.HeaderValue("location"); location != "" {
+		entry.Response.RedirectURL = location
+	}
+	// Redirect responses have no body; Body() errors for them.
+	if body, err := resp.Body(); err == nil {
+		entry.Response.Content.Size = len(body)
+		entry.Response.BodySize = len(body)
+		if len(body) > 0 {
+			if looksBinary(body) {
+				entry.Response.Content.Text = base64.StdEncoding.EncodeToString(body)
+				entry.Response.Content.Encoding = "base64"
+			} else {
+				entry.Response.Content.Text = string(body)
+			}
+		}
+	}
+
+	if timing != nil {
+		entry.startedMS = timing.StartTime
+		entry.StartedDateTime = time.UnixMilli(int64(timing.StartTime)).UTC().Format("2006-01-02T15:04:05.000Z")
+		entry.Timings.Send = 0
+		entry.Timings.Wait = harDuration(timing.RequestStart, timing.ResponseStart)
+		entry.Timings.Receive = harDuration(timing.ResponseStart, timing.ResponseEnd)
+		entry.Time = entry.Timings.Send + entry.Timings.Wait + entry.Timings.Receive
+	} else {
+		entry.StartedDateTime = time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	}
+	return entry, true
+}
+
+func harDuration(from, to float64) float64 {
+	if from < 0 || to < 0 || to < from {
+		return 0
+	}
+	return to - from
+}
+
+func harHeaders(headers []playwright.NameValue, err error) []HARNameValue {
+	out := []HARNameValue{}
+	if err != nil {
+		return out
+	}
+	for _, h := range headers {
+		out = append(out, HARNameValue{Name: h.Name, Value: h.Value})
+	}
+	return out
+}
+
+func harQueryString(rawURL string) []HARNameValue {
+	out := []HARNameValue{}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return out
+	}
+	q := u.Query()
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range q[k] {
+			out = append(out, HARNameValue{Name: k, Value: v})
+		}
+	}
+	return out
+}
+
+func normalizeHARReplay(replay HARReplay) (HARReplay, error) {
+	replay.Path = strings.TrimSpace(replay.Path)
+	replay.NotFound = strings.ToLower(strings.TrimSpace(replay.NotFound))
+	replay.URL = strings.TrimSpace(replay.URL)
+	if replay.Path == "" {
+		return HARReplay{}, errors.New("har path required")
+	}
+	if !filepath.IsAbs(replay.Path) {
+		return HARReplay{}, errors.New("har path must be absolute")
+	}
+	if _, err := os.Stat(replay.Path); err != nil {
+		return HARReplay{}, fmt.Errorf("har path: %w", err)
+	}
+	switch replay.NotFound {
+	case "":
+		replay.NotFound = HARNotFoundAbort
+	case HARNotFoundAbort, HARNotFoundFallback:
+	default:
+		return HARReplay{}, fmt.Errorf("invalid not_found %q (expected abort or fallback)", replay.NotFound)
+	}
+	return replay, nil
+}
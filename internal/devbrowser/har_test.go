@@ -0,0 +1,133 @@
+package devbrowser
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHARQueryString_Sorted(t *testing.T) {
+	got := harQueryString("https://example.com/a?b=2&a=1&b=3")
+	want := []HARNameValue{{"a", "1"}, {"b", "2"}, {"b", "3"}}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d params, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("param %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+	if q := harQueryString("https://example.com/"); q == nil || len(q) != 0 {
+		t.Fatalf("expected empty non-nil query string, got %#v", q)
+	}
+}
+
+func TestHARDuration(t *testing.T) {
+	if got := harDuration(10, 25); got != 15 {
+		t.Fatalf("expected 15, got %v", got)
+	}
+	if got := harDuration(-1, 25); got != 0 {
+		t.Fatalf("expected 0 for unavailable timing, got %v", got)
+	}
+	if got := harDuration(30, 25); got != 0 {
+		t.Fatalf("expected 0 for negative span, got %v", got)
+	}
+}
+
+func TestHARRecorderFinish_WritesSortedHAR(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "session.har")
+	rec := newHARRecorder(path)
+	rec.entries = []HAREntry{
+		{Request: HARRequest{URL: "https://example.com/b"}, startedMS: 20},
+		{Request: HARRequest{URL: "https://example.com/a"}, startedMS: 10},
+	}
+	rec.failed = 1
+
+	st, err := rec.finish(time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st.Recording || st.Entries != 2 || st.Failed != 1 || st.RecordPath != path {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat har: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("har should be private, got mode %v", info.Mode().Perm())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read har: %v", err)
+	}
+	var har HARFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatalf("decode har: %v", err)
+	}
+	if har.Log.Version != "1.2" || har.Log.Creator.Name != "dev-browser-go" {
+		t.Fatalf("unexpected log header: %+v", har.Log)
+	}
+	if len(har.Log.Entries) != 2 || har.Log.Entries[0].Request.URL != "https://example.com/a" {
+		t.Fatalf("expected entries sorted by start time, got %+v", har.Log.Entries)
+	}
+}
+
+func TestNormalizeHARReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.har")
+	if err := os.WriteFile(path, []byte(`{"log":{}}`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	replay, err := normalizeHARReplay(HARReplay{Path: path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replay.NotFound != HARNotFoundAbort {
+		t.Fatalf("expected default not_found abort, got %q", replay.NotFound)
+	}
+	if _, err := normalizeHARReplay(HARReplay{Path: path, NotFound: "Fallback"}); err != nil {
+		t.Fatalf("expected fallback to be accepted: %v", err)
+	}
+
+	for _, bad := range []HARReplay{
+		{},
+		{Path: "relative.har"},
+		{Path: filepath.Join(t.TempDir(), "missing.har")},
+		{Path: path, NotFound: "ignore"},
+	} {
+		if _, err := normalizeHARReplay(bad); err == nil {
+			t.Fatalf("expected error for %+v", bad)
+		}
+	}
+}
+
+func TestResolveArtifactReadPath_FallsBackToArtifactDir(t *testing.T) {
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+	t.Chdir(t.TempDir())
+
+	profile := "har-test"
+	dir := ArtifactDir(profile)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	want := filepath.Join(dir, "session.har")
+	if err := os.WriteFile(want, []byte(`{}`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	got, err := ResolveArtifactReadPath(profile, "session.har")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+	if _, err := ResolveArtifactReadPath(profile, "missing.har"); err == nil {
+		t.Fatalf("expected error for missing file")
+	}
+}
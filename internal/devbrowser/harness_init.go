@@ -18,6 +18,14 @@ func InstallHarnessInit(context playwright.BrowserContext) error {
 	return context.AddInitScript(playwright.Script{Content: playwright.String(harnessInitJS)})
 }
 
+// InstallHarnessInitOnPage is InstallHarnessInit for a single page.
+func InstallHarnessInitOnPage(page playwright.Page) error {
+	if page == nil {
+		return nil
+	}
+	return page.AddInitScript(playwright.Script{Content: playwright.String(harnessInitJS)})
+}
+
 func EnsureHarnessOnPage(page playwright.Page) {
 	if page == nil {
 		return
@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/playwright-community/playwright-go"
@@ -24,19 +25,58 @@ type PageEntry struct {
 
 type BrowserHost struct {
 	profile  string
+	browser  string
 	headless bool
 	cdpPort  int
 	window   *WindowSize
 	device   string
+	// cdpEndpoint, when set, connects to an existing Chromium (see
+	// connectLocked); external is that browser's connection.
+	cdpEndpoint string
 
 	mu       sync.Mutex
 	pw       *playwright.Playwright
+	external playwright.Browser
 	context  playwright.BrowserContext
 	ws       string
 	registry map[string]pageHolder
 	userData string
 	logs     *consoleStore
+	network  *networkStore
+	download *downloadStore
+	events   *eventHub
 	settings BrowserContextSettings
+
+	// contexts holds named isolated contexts created next to the persistent
+	// one; contextBrowser is the Browser used to create them, since a
+	// persistent context has no Browser handle (see contextBrowserLocked).
+	contexts       map[string]*namedContext
+	contextBrowser playwright.Browser
+
+	routes         *routeStore
+	routeInstalled bool
+	harReplay      *HARReplay
+
+	// harMu guards har separately from mu: it is read from Playwright event
+	// handlers, which must not block on long-held host operations.
+	harMu sync.Mutex
+	har   *harRecorder
+
+	// crashes tracks renderer/browser crashes and recovery; onRelaunch runs
+	// after a crash relaunched the browser.
+	crashes    *crashLog
+	onRelaunch func()
+
+	// limits caps new pages; memoryMB is the last sample of the browser's
+	// process tree (see SampleMemory).
+	limits   HostLimits
+	memoryMB atomic.Int64
+
+	// ops serializes operations per named page (see AcquirePage).
+	ops *pageOps
+
+	// snapshots holds each page's last snapshot for `snapshot --diff`.
+	snapshots *snapshotStore
 }
 
 type pageHolder struct {
@@ -45,26 +85,37 @@ type pageHolder struct {
 	consoleHooked bool
 }
 
-func NewBrowserHost(profile string, headless bool, cdpPort int, window *WindowSize, device string) *BrowserHost {
-	stateBase := filepath.Join(PlatformStateDir(), cacheSubdir, profile)
-	settings := normalizeContextRequest(headless, window, device)
+func NewBrowserHost(profile, browser string, headless bool, cdpPort int, window *WindowSize, device string) *BrowserHost {
+	settings := normalizeContextRequest(browser, headless, window, device)
 	return &BrowserHost{
 		profile:  profile,
+		browser:  settings.Browser,
 		headless: settings.Headless,
 		cdpPort:  cdpPort,
 		window:   cloneWindowSize(settings.Window),
 		device:   settings.Device,
 		registry: make(map[string]pageHolder),
-		userData: filepath.Join(stateBase, "chromium-profile"),
+		contexts: make(map[string]*namedContext),
+		userData: userDataDir(profile, settings.Browser),
 		logs:     newConsoleStore(0),
+		network:  newNetworkStore(0),
+		download: newDownloadStore(0),
+		events:   newEventHub(),
 		settings: settings,
+		routes:   newRouteStore(),
+		crashes:  newCrashLog(),
+		ops:      newPageOps(),
+
+		snapshots: newSnapshotStore(),
 	}
 }
 
+// WSEndpoint returns the CDP endpoint, which is empty for engines without
+// CDP.
 func (b *BrowserHost) WSEndpoint() (string, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	if b.ws == "" {
+	if b.context == nil || (b.ws == "" && BrowserSupportsCDP(b.browser)) {
 		return "", errors.New("host not started")
 	}
 	return b.ws, nil
@@ -83,6 +134,14 @@ func (b *BrowserHost) Stop() {
 }
 
 func (b *BrowserHost) stopLocked() {
+	for name := range b.contexts {
+		b.closeContextLocked(name)
+	}
+	if b.contextBrowser != nil {
+		_ = b.contextBrowser.Close()
+	}
+	b.contextBrowser = nil
+
 	for name, holder := range b.registry {
 		if holder.page != nil && !holder.page.IsClosed() {
 			_ = holder.page.Close()
@@ -90,10 +149,15 @@ func (b *BrowserHost) stopLocked() {
 		delete(b.registry, name)
 	}
 
-	if b.context != nil {
+	if b.external != nil {
+		// Disconnect only: the browser and its default context are the user's.
+		_ = b.external.Close()
+	} else if b.context != nil {
 		_ = b.context.Close()
 	}
+	b.external = nil
 	b.context = nil
+	b.routeInstalled = false
 
 	if b.pw != nil {
 		_ = b.pw.Stop()
@@ -103,6 +167,18 @@ func (b *BrowserHost) stopLocked() {
 	if b.logs != nil {
 		b.logs.clearAll()
 	}
+	if b.network != nil {
+		b.network.clearAll()
+	}
+	if b.download != nil {
+		b.download.clearAll()
+	}
+	if b.events != nil {
+		b.events.closeAll()
+	}
+	if b.snapshots != nil {
+		b.snapshots.clearAll()
+	}
 }
 
 func (b *BrowserHost) ContextSettings() BrowserContextSettings {
@@ -130,59 +206,85 @@ func (b *BrowserHost) PrimaryPageURL() string {
 	return ""
 }
 
-func (b *BrowserHost) Reconfigure(headless bool, window *WindowSize, device string) error {
+func (b *BrowserHost) Reconfigure(browser string, headless bool, window *WindowSize, device, cdpEndpoint string) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	requested := normalizeContextRequest(headless, window, device)
+	requested := normalizeContextRequest(browser, headless, window, device)
+	requested.CDPEndpoint = cdpEndpoint
 	if effectiveContextMatches(b.settings, requested) {
 		return nil
 	}
 
 	restore := b.capturePagesLocked()
+	named := b.captureContextsLocked()
 	b.stopLocked()
 
+	b.browser = requested.Browser
+	b.userData = userDataDir(b.profile, requested.Browser)
 	b.headless = requested.Headless
 	b.window = cloneWindowSize(requested.Window)
 	b.device = requested.Device
+	b.cdpEndpoint = requested.CDPEndpoint
 	b.settings = requested
 
 	if err := b.startLocked(); err != nil {
 		return err
 	}
-	return b.restorePagesLocked(restore)
+	if err := b.restorePagesLocked(restore); err != nil {
+		return err
+	}
+	return b.restoreContextsLocked(named)
 }
 
-func (b *BrowserHost) ListPages() []string {
+func (b *BrowserHost) ListPages(contextName string) ([]string, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	names := []string{}
-	for name, holder := range b.registry {
-		if holder.page != nil && !holder.page.IsClosed() {
-			names = append(names, name)
-		}
+	_, registry, err := b.scopeLocked(contextName)
+	if err != nil {
+		return nil, err
 	}
-	return names
+	return openPageNames(registry), nil
 }
 
-func (b *BrowserHost) ClosePage(name string) bool {
+func (b *BrowserHost) ClosePage(contextName, name string) bool {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	holder, ok := b.registry[name]
+	_, registry, err := b.scopeLocked(contextName)
+	if err != nil {
+		return false
+	}
+	holder, ok := registry[name]
 	if !ok {
 		return false
 	}
 	if holder.page != nil && !holder.page.IsClosed() {
 		_ = holder.page.Close()
 	}
-	delete(b.registry, name)
+	delete(registry, name)
+	b.clearPageLogs(pageLogKey(contextName, name))
+	return true
+}
+
+func (b *BrowserHost) clearPageLogs(key string) {
 	if b.logs != nil {
-		b.logs.clear(name)
+		b.logs.clear(key)
+	}
+	if b.network != nil {
+		b.network.clear(key)
+	}
+	if b.download != nil {
+		b.download.clear(key)
+	}
+	if b.events != nil {
+		b.events.closePage(key)
+	}
+	if b.snapshots != nil {
+		b.snapshots.clear(key)
 	}
-	return true
 }
 
-func (b *BrowserHost) GetOrCreatePage(name string) (PageEntry, error) {
+func (b *BrowserHost) GetOrCreatePage(contextName, name string) (PageEntry, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -191,40 +293,83 @@ func (b *BrowserHost) GetOrCreatePage(name string) (PageEntry, error) {
 			return PageEntry{}, err
 		}
 	}
+	ctx, registry, err := b.scopeLocked(contextName)
+	if err != nil {
+		return PageEntry{}, err
+	}
 
-	if holder, ok := b.registry[name]; ok && holder.page != nil && !holder.page.IsClosed() {
+	if holder, ok := registry[name]; ok && holder.page != nil && !holder.page.IsClosed() {
 		identity := PageIdentity{}
-		identity, err := b.describeHolderLocked(holder)
+		identity, err := b.describeHolder(ctx, holder)
 		if err == nil {
 			holder.targetID = identity.TargetID
-			b.registry[name] = holder
+			registry[name] = holder
 		} else {
 			identity = PageIdentity{
 				TargetID: holder.targetID,
 				URL:      holder.page.URL(),
 				Title:    safeTitle(holder.page),
 			}
-			if strings.TrimSpace(identity.TargetID) == "" {
+			if BrowserSupportsCDP(b.browser) && strings.TrimSpace(identity.TargetID) == "" {
 				return PageEntry{}, fmt.Errorf("resolve target id for page %q: %w", name, err)
 			}
 		}
 		if !holder.consoleHooked {
-			b.attachConsoleLocked(name, holder.page)
+			b.attachConsoleLocked(contextName, name, holder.page)
 		}
 		return PageEntry{Name: name, TargetID: identity.TargetID, URL: identity.URL, Title: identity.Title}, nil
 	}
 
-	page, err := b.context.NewPage()
+	// Limits apply to pages asked for by clients; crash recovery and restores
+	// bring back pages that were already counted.
+	if err := b.checkLimitsLocked(); err != nil {
+		return PageEntry{}, err
+	}
+	return b.createPageLocked(contextName, name)
+}
+
+// PageHandle returns the daemon's own handle for a named page, creating it
+// when missing. Callers drive the page without holding the host lock.
+func (b *BrowserHost) PageHandle(contextName, name string) (playwright.Page, error) {
+	if _, err := b.GetOrCreatePage(contextName, name); err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, registry, err := b.scopeLocked(contextName)
+	if err != nil {
+		return nil, err
+	}
+	holder, ok := registry[name]
+	if !ok || holder.page == nil || holder.page.IsClosed() {
+		return nil, errors.New("page not found")
+	}
+	return holder.page, nil
+}
+
+func (b *BrowserHost) createPageLocked(contextName, name string) (PageEntry, error) {
+	ctx, registry, err := b.scopeLocked(contextName)
 	if err != nil {
 		return PageEntry{}, err
 	}
-	identity, err := describePageInContext(b.context, page)
+	page, err := ctx.NewPage()
+	if err != nil {
+		return PageEntry{}, err
+	}
+	identity, err := b.describePage(ctx, page)
 	if err != nil {
 		_ = page.Close()
 		return PageEntry{}, err
 	}
-	b.registry[name] = pageHolder{page: page, targetID: identity.TargetID}
-	b.attachConsoleLocked(name, page)
+	if contextName == "" && b.external != nil {
+		if err := b.hookInterceptionLocked(page); err != nil {
+			_ = page.Close()
+			return PageEntry{}, err
+		}
+	}
+	registry[name] = pageHolder{page: page, targetID: identity.TargetID}
+	b.attachConsoleLocked(contextName, name, page)
+	b.crashes.healthy(pageLogKey(contextName, name))
 	return PageEntry{Name: name, TargetID: identity.TargetID, URL: identity.URL, Title: identity.Title}, nil
 }
 
@@ -232,12 +377,15 @@ func (b *BrowserHost) startLocked() error {
 	if b.context != nil {
 		return nil
 	}
+	if b.cdpEndpoint != "" {
+		return b.connectLocked()
+	}
 
-	if err := playwright.Install(&playwright.RunOptions{Browsers: []string{"chromium"}}); err != nil {
+	if err := playwright.Install(&playwright.RunOptions{Browsers: []string{b.browser}}); err != nil {
 		return fmt.Errorf("install playwright: %w", err)
 	}
 
-	if err := os.MkdirAll(b.userData, 0o755); err != nil {
+	if err := os.MkdirAll(b.userData, 0o700); err != nil {
 		return err
 	}
 
@@ -272,8 +420,9 @@ func (b *BrowserHost) startLocked() error {
 		opts.Viewport = &playwright.Size{Width: window.Width, Height: window.Height}
 		opts.Screen = &playwright.Size{Width: window.Width, Height: window.Height}
 	}
+	adaptLaunchOptionsForEngine(b.browser, &opts)
 
-	context, err := pw.Chromium.LaunchPersistentContext(b.userData, opts)
+	context, err := browserTypeFor(pw, b.browser).LaunchPersistentContext(b.userData, opts)
 	if err != nil {
 		pw.Stop()
 		return fmt.Errorf("launch context: %w", err)
@@ -285,11 +434,14 @@ func (b *BrowserHost) startLocked() error {
 		return fmt.Errorf("install harness init: %w", err)
 	}
 
-	ws, err := waitForWSEndpoint(b.cdpPort, 10*time.Second)
-	if err != nil {
-		context.Close()
-		pw.Stop()
-		return err
+	ws := ""
+	if BrowserSupportsCDP(b.browser) {
+		ws, err = waitForWSEndpoint(b.cdpPort, 10*time.Second)
+		if err != nil {
+			context.Close()
+			pw.Stop()
+			return err
+		}
 	}
 
 	pages := context.Pages()
@@ -304,7 +456,7 @@ func (b *BrowserHost) startLocked() error {
 	}
 
 	mainPage := pages[0]
-	tid, err := resolveTargetID(context, mainPage)
+	identity, err := b.describePage(context, mainPage)
 	if err != nil {
 		context.Close()
 		pw.Stop()
@@ -314,9 +466,15 @@ func (b *BrowserHost) startLocked() error {
 	b.pw = pw
 	b.context = context
 	b.ws = ws
-	b.settings = browserContextSettingsFromLaunch(b.headless, deviceName, window, &opts)
-	b.registry["main"] = pageHolder{page: mainPage, targetID: tid}
-	b.attachConsoleLocked("main", mainPage)
+	b.settings = browserContextSettingsFromLaunch(b.browser, b.headless, deviceName, window, &opts)
+	b.registry["main"] = pageHolder{page: mainPage, targetID: identity.TargetID}
+	b.attachConsoleLocked("", "main", mainPage)
+	b.hookContextCloseLocked(context)
+	b.crashes.healthy("")
+	if err := b.hookInterceptionLocked(context); err != nil {
+		return err
+	}
+	b.routeInstalled = b.routes.len() > 0
 
 	for _, pg := range pages[1:] {
 		_ = pg.Close()
@@ -330,21 +488,7 @@ type pageRestoreState struct {
 }
 
 func (b *BrowserHost) capturePagesLocked() []pageRestoreState {
-	names := make([]string, 0, len(b.registry))
-	for name, holder := range b.registry {
-		if holder.page != nil && !holder.page.IsClosed() {
-			names = append(names, name)
-		}
-	}
-	sort.Strings(names)
-	restore := make([]pageRestoreState, 0, len(names))
-	for _, name := range names {
-		restore = append(restore, pageRestoreState{
-			Name: name,
-			URL:  b.registry[name].page.URL(),
-		})
-	}
-	return restore
+	return capturePages(b.registry)
 }
 
 func (b *BrowserHost) restorePagesLocked(pages []pageRestoreState) error {
@@ -362,12 +506,12 @@ func (b *BrowserHost) restorePagesLocked(pages []pageRestoreState) error {
 			if err := navigatePageForRestore(mainHolder.page, state.URL); err != nil {
 				return fmt.Errorf("restore page %q: %w", state.Name, err)
 			}
-			identity, err := describePageInContext(b.context, mainHolder.page)
+			identity, err := b.describePage(b.context, mainHolder.page)
 			if err != nil {
 				return fmt.Errorf("restore page %q: %w", state.Name, err)
 			}
 			b.registry["main"] = pageHolder{page: mainHolder.page, targetID: identity.TargetID}
-			b.attachConsoleLocked("main", mainHolder.page)
+			b.attachConsoleLocked("", "main", mainHolder.page)
 			restoredMain = true
 		}
 		break
@@ -385,13 +529,13 @@ func (b *BrowserHost) restorePagesLocked(pages []pageRestoreState) error {
 			_ = page.Close()
 			return fmt.Errorf("restore page %q: %w", state.Name, err)
 		}
-		identity, err := describePageInContext(b.context, page)
+		identity, err := b.describePage(b.context, page)
 		if err != nil {
 			_ = page.Close()
 			return fmt.Errorf("restore page %q: %w", state.Name, err)
 		}
 		b.registry[state.Name] = pageHolder{page: page, targetID: identity.TargetID}
-		b.attachConsoleLocked(state.Name, page)
+		b.attachConsoleLocked("", state.Name, page)
 	}
 
 	if !restoredMain {
@@ -425,8 +569,9 @@ func cloneContextSettings(src BrowserContextSettings) BrowserContextSettings {
 	return src
 }
 
-func browserContextSettingsFromLaunch(headless bool, device string, window *WindowSize, opts *playwright.BrowserTypeLaunchPersistentContextOptions) BrowserContextSettings {
+func browserContextSettingsFromLaunch(browser string, headless bool, device string, window *WindowSize, opts *playwright.BrowserTypeLaunchPersistentContextOptions) BrowserContextSettings {
 	settings := BrowserContextSettings{
+		Browser:  browserEngineOrDefault(browser),
 		Headless: headless,
 		Device:   strings.TrimSpace(device),
 		Window:   cloneWindowSize(window),
@@ -455,8 +600,12 @@ func browserContextSettingsFromLaunch(headless bool, device string, window *Wind
 	return settings
 }
 
-func (b *BrowserHost) attachConsoleLocked(name string, page playwright.Page) {
-	holder, ok := b.registry[name]
+func (b *BrowserHost) attachConsoleLocked(contextName, name string, page playwright.Page) {
+	_, registry, err := b.scopeLocked(contextName)
+	if err != nil {
+		return
+	}
+	holder, ok := registry[name]
 	if !ok {
 		// Page must be in registry before attaching console
 		return
@@ -466,51 +615,200 @@ func (b *BrowserHost) attachConsoleLocked(name string, page playwright.Page) {
 	}
 	// Ensure harness init is installed for this page/document.
 	EnsureHarnessOnPage(page)
+	if b.external != nil && contextName == "" {
+		// The user's context gets no init script; scope it to our tabs.
+		_ = InstallHarnessInitOnPage(page)
+	}
 
+	key := pageLogKey(contextName, name)
 	page.OnConsole(func(msg playwright.ConsoleMessage) {
 		if b.logs != nil {
-			b.logs.append(name, msg)
+			b.publishConsole(key, name, b.logs.append(key, msg))
 		}
 	})
 	page.OnPageError(func(err error) {
-		if b.logs != nil {
-			b.logs.appendPageError(name, err)
+		if b.logs != nil && err != nil {
+			b.publishConsole(key, name, b.logs.appendPageError(key, err))
 		}
 	})
+	if b.network != nil {
+		page.OnRequest(func(req playwright.Request) { b.network.request(key, req) })
+		page.OnResponse(b.network.response)
+		page.OnRequestFinished(func(req playwright.Request) {
+			if entry, ok := b.network.finished(req); ok {
+				b.publishNetwork(key, name, entry)
+			}
+		})
+		page.OnRequestFailed(func(req playwright.Request) {
+			if entry, ok := b.network.failed(req); ok {
+				b.publishNetwork(key, name, entry)
+			}
+		})
+	}
+	b.hookPageEvents(key, name, page)
+	b.hookPageCrashLocked(contextName, name, page)
+	if b.download != nil {
+		page.OnDownload(func(dl playwright.Download) { b.download.track(key, dl) })
+	}
 	holder.page = page
 	holder.consoleHooked = true
-	b.registry[name] = holder
+	registry[name] = holder
 }
 
-func (b *BrowserHost) ConsoleLogs(name string, since int64, limit int) ([]ConsoleEntry, int64, error) {
+func (b *BrowserHost) ConsoleLogs(contextName, name string, since int64, limit int) ([]ConsoleEntry, int64, error) {
 	if since < 0 {
 		return nil, 0, errors.New("since must be >= 0")
 	}
 	if limit < 0 {
 		return nil, 0, errors.New("limit must be >= 0")
 	}
-	b.mu.Lock()
-	holder, ok := b.registry[name]
-	pageOk := ok && holder.page != nil && !holder.page.IsClosed()
-	b.mu.Unlock()
-	if !pageOk {
-		return nil, 0, errors.New("page not found")
+	if err := b.checkPage(contextName, name); err != nil {
+		return nil, 0, err
 	}
 	if b.logs == nil {
 		return nil, 0, nil
 	}
-	entries, lastID := b.logs.list(name, since, limit)
+	entries, lastID := b.logs.list(pageLogKey(contextName, name), since, limit)
 	return entries, lastID, nil
 }
 
-func (b *BrowserHost) describeHolderLocked(holder pageHolder) (PageIdentity, error) {
+func (b *BrowserHost) NetworkLogs(contextName, name string) ([]NetworkLogEntry, error) {
+	if err := b.checkPage(contextName, name); err != nil {
+		return nil, err
+	}
+	if b.network == nil {
+		return nil, nil
+	}
+	return b.network.list(pageLogKey(contextName, name)), nil
+}
+
+func (b *BrowserHost) Downloads(contextName, name string) ([]DownloadEntry, error) {
+	if err := b.checkPage(contextName, name); err != nil {
+		return nil, err
+	}
+	if b.download == nil {
+		return nil, nil
+	}
+	return b.download.list(pageLogKey(contextName, name)), nil
+}
+
+// SaveDownload copies a recorded download to path, resolved like any other
+// artifact write (relative to the artifact dir, suggested filename if empty).
+// It blocks until the download finishes.
+func (b *BrowserHost) SaveDownload(contextName, name string, id int64, path string) (DownloadEntry, error) {
+	if err := b.checkPage(contextName, name); err != nil {
+		return DownloadEntry{}, err
+	}
+	if b.download == nil {
+		return DownloadEntry{}, errors.New("download not found")
+	}
+	key := pageLogKey(contextName, name)
+	entry, dl, err := b.download.lookup(key, id)
+	if err != nil {
+		return DownloadEntry{}, err
+	}
+	if entry.State == DownloadFailed {
+		return DownloadEntry{}, fmt.Errorf("download %d failed: %s", id, entry.Error)
+	}
+	defaultName := filepath.Base(entry.SuggestedFilename)
+	if defaultName == "" || defaultName == "." || defaultName == string(filepath.Separator) {
+		defaultName = fmt.Sprintf("download-%d", id)
+	}
+	target, err := SafeArtifactPath(ArtifactDir(b.profile), path, defaultName)
+	if err != nil {
+		return DownloadEntry{}, err
+	}
+	if err := dl.SaveAs(target); err != nil {
+		return DownloadEntry{}, err
+	}
+	return b.download.markSaved(key, id, target)
+}
+
+func (b *BrowserHost) publishConsole(key, name string, entry ConsoleEntry) {
+	if b.events != nil {
+		b.events.publish(key, name, consoleEventKind(entry), entry.TimeMS, consoleEventData(entry))
+	}
+}
+
+func (b *BrowserHost) publishNetwork(key, name string, entry NetworkLogEntry) {
+	if b.events != nil {
+		b.events.publish(key, name, EventNetwork, entry.Finished, networkEventData(entry))
+	}
+}
+
+// SubscribeEvents follows a page's live events until cancel is called or the
+// page closes (which closes the channel).
+func (b *BrowserHost) SubscribeEvents(contextName, name string, kinds map[string]bool) (*eventSubscriber, func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, err := b.existingPageLocked(contextName, name); err != nil {
+		return nil, nil, err
+	}
+	key := pageLogKey(contextName, name)
+	sub := b.events.subscribe(key, kinds)
+	return sub, func() { b.events.unsubscribe(key, sub) }, nil
+}
+
+// EventHistory returns the buffered console and network logs as events.
+func (b *BrowserHost) EventHistory(contextName, name string, kinds map[string]bool, limit int) ([]PageEvent, error) {
+	console, _, err := b.ConsoleLogs(contextName, name, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	network, err := b.NetworkLogs(contextName, name)
+	if err != nil {
+		return nil, err
+	}
+	return eventHistory(name, console, network, kinds, limit), nil
+}
+
+// HarnessState reads the harness hooks (errors, overlays) of an open page.
+func (b *BrowserHost) HarnessState(contextName, name string) (map[string]any, error) {
+	b.mu.Lock()
+	page, err := b.existingPageLocked(contextName, name)
+	b.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return ReadHarnessState(page)
+}
+
+func (b *BrowserHost) existingPageLocked(contextName, name string) (playwright.Page, error) {
+	_, registry, err := b.scopeLocked(contextName)
+	if err != nil {
+		return nil, err
+	}
+	holder, ok := registry[name]
+	if !ok || holder.page == nil || holder.page.IsClosed() {
+		return nil, errors.New("page not found")
+	}
+	return holder.page, nil
+}
+
+func (b *BrowserHost) checkPage(contextName, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, err := b.existingPageLocked(contextName, name)
+	return err
+}
+
+func (b *BrowserHost) describeHolder(ctx playwright.BrowserContext, holder pageHolder) (PageIdentity, error) {
 	if holder.page == nil {
 		return PageIdentity{}, errors.New("page is nil")
 	}
 	if holder.page.IsClosed() {
 		return PageIdentity{}, errors.New("page is closed")
 	}
-	return describePageInContext(b.context, holder.page)
+	return b.describePage(ctx, holder.page)
+}
+
+// describePage resolves the CDP target id on Chromium. Other engines have no
+// target ids; their pages are addressed by name through the daemon only.
+func (b *BrowserHost) describePage(ctx playwright.BrowserContext, page playwright.Page) (PageIdentity, error) {
+	if !BrowserSupportsCDP(b.browser) {
+		return PageIdentity{URL: page.URL(), Title: safeTitle(page)}, nil
+	}
+	return describePageInContext(ctx, page)
 }
 
 func resolveTargetID(context playwright.BrowserContext, page playwright.Page) (string, error) {
@@ -536,7 +834,10 @@ func resolveTargetID(context playwright.BrowserContext, page playwright.Page) (s
 }
 
 func waitForWSEndpoint(port int, timeout time.Duration) (string, error) {
-	url := fmt.Sprintf("http://127.0.0.1:%d/json/version", port)
+	return waitForWSEndpointURL(fmt.Sprintf("http://127.0.0.1:%d/json/version", port), timeout)
+}
+
+func waitForWSEndpointURL(url string, timeout time.Duration) (string, error) {
 	deadline := time.Now().Add(timeout)
 	var lastErr error
 	for time.Now().Before(deadline) {
@@ -562,3 +863,341 @@ func waitForWSEndpoint(port int, timeout time.Duration) (string, error) {
 	}
 	return "", fmt.Errorf("timed out waiting for Chromium CDP endpoint at %s", url)
 }
+
+func (b *BrowserHost) AddRoute(rule RouteRule) (RouteRule, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	added, err := b.routes.add(rule)
+	if err != nil {
+		return RouteRule{}, err
+	}
+	if err := b.syncRoutesLocked(); err != nil {
+		b.routes.remove(added.ID)
+		return RouteRule{}, err
+	}
+	return added, nil
+}
+
+func (b *BrowserHost) ListRoutes() []RouteRule {
+	return b.routes.list()
+}
+
+func (b *BrowserHost) RemoveRoute(id int64) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.routes.remove(id) {
+		return false, nil
+	}
+	return true, b.syncRoutesLocked()
+}
+
+func (b *BrowserHost) ClearRoutes() (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := b.routes.clear()
+	return n, b.syncRoutesLocked()
+}
+
+// syncRoutesLocked installs the route handler while rules exist and removes
+// it once they are gone, so unrouted sessions pay no interception cost.
+func (b *BrowserHost) syncRoutesLocked() error {
+	if b.routes.len() > 0 == b.routeInstalled {
+		return nil
+	}
+	return b.installInterceptionLocked()
+}
+
+func (b *BrowserHost) handleRoute(route playwright.Route) {
+	req := route.Request()
+	rule, ok := b.routes.match(req.Method(), req.URL())
+	if !ok {
+		_ = route.Fallback()
+		return
+	}
+	_ = applyRouteRule(route, rule)
+}
+
+// interceptTarget is what route rules, HAR replay and HAR recording hook
+// into: a browser context, or a single page where the context is not ours.
+type interceptTarget interface {
+	Route(url interface{}, handler func(playwright.Route), times ...int) error
+	OnRequestFinished(fn func(playwright.Request))
+	OnRequestFailed(fn func(playwright.Request))
+}
+
+// interceptTargetsLocked lists where route rules and HAR replay apply: the
+// default context and every named one. The default context of a browser
+// reached through --cdp-endpoint is the user's, so there only the pages the
+// host opened are hooked.
+func (b *BrowserHost) interceptTargetsLocked() []interceptTarget {
+	targets := []interceptTarget{}
+	if b.external != nil {
+		for _, holder := range b.registry {
+			if holder.page != nil && !holder.page.IsClosed() {
+				targets = append(targets, holder.page)
+			}
+		}
+	} else if b.context != nil {
+		targets = append(targets, b.context)
+	}
+	for _, nc := range b.contexts {
+		targets = append(targets, nc.context)
+	}
+	return targets
+}
+
+// installInterceptionLocked (re)installs HAR replay and route rules on every
+// target.
+func (b *BrowserHost) installInterceptionLocked() error {
+	for _, target := range b.interceptTargetsLocked() {
+		var err error
+		switch t := target.(type) {
+		case playwright.Page:
+			err = t.UnrouteAll()
+		case playwright.BrowserContext:
+			err = t.UnrouteAll()
+		}
+		if err != nil {
+			return err
+		}
+		if err := b.interceptLocked(target); err != nil {
+			return err
+		}
+	}
+	b.routeInstalled = b.routes.len() > 0
+	return nil
+}
+
+// interceptLocked installs HAR replay and then route rules on one target,
+// so rules registered last take precedence over HAR responses.
+func (b *BrowserHost) interceptLocked(target interceptTarget) error {
+	if replay := b.harReplay; replay != nil {
+		notFound := playwright.HarNotFoundAbort
+		if replay.NotFound == HARNotFoundFallback {
+			notFound = playwright.HarNotFoundFallback
+		}
+		var url *string
+		if replay.URL != "" {
+			url = playwright.String(replay.URL)
+		}
+		var err error
+		switch t := target.(type) {
+		case playwright.Page:
+			err = t.RouteFromHAR(replay.Path, playwright.PageRouteFromHAROptions{NotFound: notFound, URL: url})
+		case playwright.BrowserContext:
+			err = t.RouteFromHAR(replay.Path, playwright.BrowserContextRouteFromHAROptions{NotFound: notFound, URL: url})
+		}
+		if err != nil {
+			return fmt.Errorf("replay har: %w", err)
+		}
+	}
+	if b.routes.len() > 0 {
+		return target.Route("**/*", b.handleRoute)
+	}
+	return nil
+}
+
+// hookInterceptionLocked sets up a target the host created: HAR recording,
+// route rules and HAR replay.
+func (b *BrowserHost) hookInterceptionLocked(target interceptTarget) error {
+	target.OnRequestFinished(b.recordHARRequest)
+	target.OnRequestFailed(b.recordHARRequest)
+	if err := b.interceptLocked(target); err != nil {
+		return fmt.Errorf("install routes: %w", err)
+	}
+	return nil
+}
+
+func (b *BrowserHost) StartHARRecording(path string) (HARStatus, error) {
+	path = strings.TrimSpace(path)
+	if path == "" || !filepath.IsAbs(path) {
+		return HARStatus{}, errors.New("har path must be absolute")
+	}
+	b.harMu.Lock()
+	defer b.harMu.Unlock()
+	if b.har != nil {
+		return HARStatus{}, fmt.Errorf("already recording to %s", b.har.path)
+	}
+	b.har = newHARRecorder(path)
+	return b.har.status(), nil
+}
+
+func (b *BrowserHost) StopHARRecording() (HARStatus, error) {
+	b.harMu.Lock()
+	rec := b.har
+	b.har = nil
+	b.harMu.Unlock()
+	if rec == nil {
+		return HARStatus{}, errors.New("not recording")
+	}
+	return rec.finish(10 * time.Second)
+}
+
+func (b *BrowserHost) HARStatus() HARStatus {
+	b.harMu.Lock()
+	rec := b.har
+	b.harMu.Unlock()
+	st := HARStatus{}
+	if rec != nil {
+		st = rec.status()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.harReplay != nil {
+		replay := *b.harReplay
+		st.Replay = &replay
+	}
+	return st
+}
+
+func (b *BrowserHost) SetHARReplay(replay HARReplay) (HARReplay, error) {
+	replay, err := normalizeHARReplay(replay)
+	if err != nil {
+		return HARReplay{}, err
+	}
+	replay.StartedMS = NowMS()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	prev := b.harReplay
+	b.harReplay = &replay
+	if err := b.installInterceptionLocked(); err != nil {
+		b.harReplay = prev
+		_ = b.installInterceptionLocked()
+		return HARReplay{}, err
+	}
+	return replay, nil
+}
+
+func (b *BrowserHost) ClearHARReplay() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.harReplay == nil {
+		return false, nil
+	}
+	b.harReplay = nil
+	return true, b.installInterceptionLocked()
+}
+
+func (b *BrowserHost) recordHARRequest(req playwright.Request) {
+	b.harMu.Lock()
+	rec := b.har
+	b.harMu.Unlock()
+	if rec != nil {
+		rec.capture(req)
+	}
+}
+
+// SaveStorageState exports cookies, localStorage (via Playwright) and the
+// sessionStorage of every open named page in the given context.
+func (b *BrowserHost) SaveStorageState(contextName string) (StorageStateFile, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.context == nil {
+		if err := b.startLocked(); err != nil {
+			return StorageStateFile{}, err
+		}
+	}
+	ctx, registry, err := b.scopeLocked(contextName)
+	if err != nil {
+		return StorageStateFile{}, err
+	}
+	st, err := ctx.StorageState()
+	if err != nil {
+		return StorageStateFile{}, fmt.Errorf("storage state: %w", err)
+	}
+	state := StorageStateFile{
+		Version: storageStateVersion,
+		SavedMS: NowMS(),
+		Cookies: st.Cookies,
+		Origins: st.Origins,
+	}
+	if state.Cookies == nil {
+		state.Cookies = []playwright.Cookie{}
+	}
+	if state.Origins == nil {
+		state.Origins = []playwright.Origin{}
+	}
+	for name, holder := range registry {
+		if holder.page == nil || holder.page.IsClosed() {
+			continue
+		}
+		if snap, ok := readSessionStorage(holder.page); ok {
+			snap.Page = name
+			state.SessionStorage = append(state.SessionStorage, snap)
+		}
+	}
+	sortStorageState(&state)
+	return state, nil
+}
+
+// LoadStorageState adds cookies and writes local/session storage into the
+// running context. localStorage is written from stubbed origin documents in a
+// scratch page so no requests reach the real sites. sessionStorage goes into
+// the open page of the same name, at once if it is on the saved origin and
+// otherwise when it next loads that origin; missing pages are reported in
+// Skipped.
+func (b *BrowserHost) LoadStorageState(contextName string, state StorageStateFile) (StorageStateSummary, error) {
+	if err := validateStorageState(state); err != nil {
+		return StorageStateSummary{}, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.context == nil {
+		if err := b.startLocked(); err != nil {
+			return StorageStateSummary{}, err
+		}
+	}
+	if err := CheckExternalContext(b.settings, contextName, "state_load"); err != nil {
+		return StorageStateSummary{}, err
+	}
+	ctx, registry, err := b.scopeLocked(contextName)
+	if err != nil {
+		return StorageStateSummary{}, err
+	}
+	summary := state.Summary()
+
+	if len(state.Cookies) > 0 {
+		if err := ctx.AddCookies(cookiesForAdd(state.Cookies)); err != nil {
+			return StorageStateSummary{}, fmt.Errorf("add cookies: %w", err)
+		}
+	}
+
+	if len(state.Origins) > 0 {
+		scratch, err := ctx.NewPage()
+		if err != nil {
+			return StorageStateSummary{}, err
+		}
+		for _, origin := range state.Origins {
+			if len(origin.LocalStorage) == 0 {
+				continue
+			}
+			if err := writeOriginStorage(scratch, origin.Origin, "local", origin.LocalStorage); err != nil {
+				_ = scratch.Close()
+				return StorageStateSummary{}, err
+			}
+		}
+		_ = scratch.Close()
+	}
+
+	// sessionStorage belongs to a tab, so it can only go into pages that are
+	// open; they are never navigated for it.
+	marker := fmt.Sprintf("__devBrowserStateLoaded:%d", NowMS())
+	summary.SessionStorage = 0
+	for _, snap := range state.SessionStorage {
+		holder, ok := registry[snap.Page]
+		if !ok || holder.page == nil || holder.page.IsClosed() {
+			summary.Skipped = append(summary.Skipped, fmt.Sprintf("sessionStorage %s (%s): page not open; open it with goto --page %s and load again", snap.Page, snap.Origin, snap.Page))
+			continue
+		}
+		written, err := restoreSessionStorage(holder.page, snap, marker)
+		if err != nil {
+			summary.Skipped = append(summary.Skipped, fmt.Sprintf("sessionStorage %s (%s): %v", snap.Page, snap.Origin, err))
+			continue
+		}
+		summary.SessionStorage++
+		if !written {
+			summary.Pending = append(summary.Pending, fmt.Sprintf("sessionStorage %s (%s): written when the page next loads that origin", snap.Page, snap.Origin))
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,200 @@
+package devbrowser
+
+import (
+	"sync"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const defaultNetworkLogMax = 500
+
+// NetworkLogEntry is a request recorded by the daemon for a named page.
+// Started/Finished are epoch milliseconds (unlike network-monitor, where they
+// are relative to the capture start). Bodies are never retained.
+type NetworkLogEntry struct {
+	ID int64 `json:"id"`
+	NetworkEntry
+	Pending bool `json:"pending,omitempty"`
+}
+
+type networkRef struct {
+	name string
+	id   int64
+}
+
+// networkStore mirrors consoleStore: a ring buffer per page name with IDs that
+// increase monotonically across pages, so `--since` works after the fact.
+type networkStore struct {
+	mu       sync.Mutex
+	logs     map[string][]NetworkLogEntry
+	inflight map[playwright.Request]networkRef
+	max      int
+	nextID   int64
+}
+
+func newNetworkStore(max int) *networkStore {
+	if max <= 0 {
+		max = defaultNetworkLogMax
+	}
+	return &networkStore{
+		logs:     make(map[string][]NetworkLogEntry),
+		inflight: make(map[playwright.Request]networkRef),
+		max:      max,
+	}
+}
+
+func (n *networkStore) request(name string, req playwright.Request) {
+	if req == nil {
+		return
+	}
+	entry := NetworkLogEntry{
+		NetworkEntry: NetworkEntry{
+			URL:            req.URL(),
+			Method:         req.Method(),
+			Type:           safeString(req.ResourceType()),
+			Started:        NowMS(),
+			RequestHeaders: req.Headers(),
+		},
+		Pending: true,
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.nextID++
+	entry.ID = n.nextID
+	logs := n.logs[name]
+	if n.max > 0 && len(logs) >= n.max {
+		for _, evicted := range logs[:len(logs)-n.max+1] {
+			n.forgetLocked(evicted.ID)
+		}
+		logs = logs[len(logs)-n.max+1:]
+	}
+	n.logs[name] = append(logs, entry)
+	n.inflight[req] = networkRef{name: name, id: entry.ID}
+}
+
+func (n *networkStore) response(resp playwright.Response) {
+	if resp == nil {
+		return
+	}
+	status := resp.Status()
+	headers := resp.Headers()
+	n.update(resp.Request(), false, func(e *NetworkLogEntry) {
+		e.Status = status
+		e.OK = status >= 200 && status < 400
+		e.ResponseHeaders = headers
+	})
+}
+
+// finished and failed return the completed entry so callers can publish it.
+func (n *networkStore) finished(req playwright.Request) (NetworkLogEntry, bool) {
+	return n.update(req, true, func(e *NetworkLogEntry) {
+		e.Finished = NowMS()
+		e.Pending = false
+	})
+}
+
+func (n *networkStore) failed(req playwright.Request) (NetworkLogEntry, bool) {
+	if req == nil {
+		return NetworkLogEntry{}, false
+	}
+	msg := "request failed"
+	if f := req.Failure(); f != nil {
+		msg = f.Error()
+	}
+	return n.update(req, true, func(e *NetworkLogEntry) {
+		e.Finished = NowMS()
+		e.Pending = false
+		e.OK = false
+		e.Error = msg
+	})
+}
+
+func (n *networkStore) update(req playwright.Request, done bool, fn func(*NetworkLogEntry)) (NetworkLogEntry, bool) {
+	if req == nil {
+		return NetworkLogEntry{}, false
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	ref, ok := n.inflight[req]
+	if !ok {
+		return NetworkLogEntry{}, false
+	}
+	if done {
+		delete(n.inflight, req)
+	}
+	logs := n.logs[ref.name]
+	for i := len(logs) - 1; i >= 0; i-- {
+		if logs[i].ID == ref.id {
+			fn(&logs[i])
+			return logs[i], true
+		}
+	}
+	return NetworkLogEntry{}, false
+}
+
+func (n *networkStore) forgetLocked(id int64) {
+	for req, ref := range n.inflight {
+		if ref.id == id {
+			delete(n.inflight, req)
+			return
+		}
+	}
+}
+
+func (n *networkStore) list(name string) []NetworkLogEntry {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	logs := n.logs[name]
+	out := make([]NetworkLogEntry, len(logs))
+	copy(out, logs)
+	return out
+}
+
+func (n *networkStore) clear(name string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.logs, name)
+	for req, ref := range n.inflight {
+		if ref.name == name {
+			delete(n.inflight, req)
+		}
+	}
+}
+
+func (n *networkStore) clearAll() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.logs = make(map[string][]NetworkLogEntry)
+	n.inflight = make(map[playwright.Request]networkRef)
+	n.nextID = 0
+}
+
+// selectNetworkLogs applies filters, then since/limit with the same semantics
+// as console: after `since` keep the oldest `limit`, otherwise the newest.
+func selectNetworkLogs(logs []NetworkLogEntry, opts NetworkMonitorOptions, since int64, limit int) []NetworkLogEntry {
+	out := make([]NetworkLogEntry, 0, len(logs))
+	for _, e := range logs {
+		if e.ID <= since {
+			continue
+		}
+		if opts.OnlyFailed && e.Pending {
+			continue
+		}
+		if !matchNetwork(e.NetworkEntry, opts) {
+			continue
+		}
+		if !opts.IncludeHeaders {
+			e.RequestHeaders = nil
+			e.ResponseHeaders = nil
+		}
+		out = append(out, e)
+	}
+	if limit <= 0 || len(out) <= limit {
+		return out
+	}
+	if since > 0 {
+		return out[:limit]
+	}
+	return out[len(out)-limit:]
+}
@@ -0,0 +1,75 @@
+package devbrowser
+
+import "testing"
+
+func networkLogFixture() []NetworkLogEntry {
+	return []NetworkLogEntry{
+		{ID: 1, NetworkEntry: NetworkEntry{URL: "https://example.com/", Method: "GET", Type: "document", Status: 200, OK: true, RequestHeaders: map[string]string{"a": "1"}}},
+		{ID: 2, NetworkEntry: NetworkEntry{URL: "https://example.com/api/users", Method: "GET", Type: "fetch", Status: 500}},
+		{ID: 3, NetworkEntry: NetworkEntry{URL: "https://example.com/api/save", Method: "POST", Type: "fetch"}, Pending: true},
+		{ID: 4, NetworkEntry: NetworkEntry{URL: "https://example.com/app.js", Method: "GET", Type: "script", Error: "net::ERR_FAILED"}},
+	}
+}
+
+func TestSelectNetworkLogs_SinceAndLimit(t *testing.T) {
+	logs := networkLogFixture()
+
+	afterSince := selectNetworkLogs(logs, NetworkMonitorOptions{}, 1, 2)
+	if len(afterSince) != 2 || afterSince[0].ID != 2 || afterSince[1].ID != 3 {
+		t.Fatalf("expected oldest entries after since, got %+v", afterSince)
+	}
+
+	newest := selectNetworkLogs(logs, NetworkMonitorOptions{}, 0, 2)
+	if len(newest) != 2 || newest[0].ID != 3 || newest[1].ID != 4 {
+		t.Fatalf("expected newest entries without since, got %+v", newest)
+	}
+
+	if all := selectNetworkLogs(logs, NetworkMonitorOptions{}, 0, 0); len(all) != 4 {
+		t.Fatalf("expected limit 0 to return everything, got %d", len(all))
+	}
+}
+
+func TestSelectNetworkLogs_Filters(t *testing.T) {
+	logs := networkLogFixture()
+
+	failed := selectNetworkLogs(logs, NetworkMonitorOptions{OnlyFailed: true}, 0, 0)
+	if len(failed) != 2 || failed[0].ID != 2 || failed[1].ID != 4 {
+		t.Fatalf("expected failed entries without pending ones, got %+v", failed)
+	}
+
+	api := selectNetworkLogs(logs, NetworkMonitorOptions{URLContains: "/api/", MethodEquals: "post"}, 0, 0)
+	if len(api) != 1 || api[0].ID != 3 {
+		t.Fatalf("expected POST /api/ entry, got %+v", api)
+	}
+
+	noHeaders := selectNetworkLogs(logs, NetworkMonitorOptions{}, 0, 1)
+	if len(noHeaders) != 1 || noHeaders[0].RequestHeaders != nil {
+		t.Fatalf("expected headers stripped by default, got %+v", noHeaders)
+	}
+	withHeaders := selectNetworkLogs(logs, NetworkMonitorOptions{IncludeHeaders: true}, 0, 0)
+	if withHeaders[0].RequestHeaders["a"] != "1" {
+		t.Fatalf("expected headers retained, got %+v", withHeaders[0])
+	}
+	if logs[0].RequestHeaders == nil {
+		t.Fatalf("selection must not mutate the stored log")
+	}
+}
+
+func TestNetworkStore_ClearResetsIDs(t *testing.T) {
+	store := newNetworkStore(0)
+	if store.max != defaultNetworkLogMax {
+		t.Fatalf("expected default max %d, got %d", defaultNetworkLogMax, store.max)
+	}
+	store.logs["main"] = networkLogFixture()
+	store.logs["other"] = networkLogFixture()
+	store.nextID = 4
+
+	store.clear("main")
+	if len(store.list("main")) != 0 || len(store.list("other")) != 4 {
+		t.Fatalf("expected only main cleared")
+	}
+	store.clearAll()
+	if len(store.list("other")) != 0 || store.nextID != 0 {
+		t.Fatalf("expected clearAll to reset logs and ids")
+	}
+}
@@ -0,0 +1,151 @@
+package devbrowser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrPageBusy is returned when a page operation could not start within its
+// wait timeout because another operation holds the page.
+var ErrPageBusy = errors.New("page busy")
+
+// defaultPageWait is how long a call waits for its page when the client did
+// not say.
+const defaultPageWait = 30 * time.Second
+
+// PageQueueStatus is one busy page in the "queues" block of /health.
+type PageQueueStatus struct {
+	Context   string `json:"context,omitempty"`
+	Page      string `json:"page"`
+	Running   string `json:"running,omitempty"`
+	RunningMS int64  `json:"running_ms,omitempty"`
+	Waiting   int    `json:"waiting"`
+}
+
+// pageOps runs operations on a named page one at a time, in arrival order.
+// It has its own lock so /health can report queues while the host is busy.
+type pageOps struct {
+	mu    sync.Mutex
+	slots map[string]*pageSlot
+}
+
+type pageSlot struct {
+	contextName string
+	page        string
+	// sem holds one token while an operation runs; blocked senders queue
+	// in FIFO order.
+	sem     chan struct{}
+	op      string
+	startMS int64
+	waiting int
+}
+
+func newPageOps() *pageOps {
+	return &pageOps{slots: make(map[string]*pageSlot)}
+}
+
+// acquire waits up to wait (not at all when <= 0) for the page, giving up
+// early when ctx ends. The returned func releases the page.
+func (q *pageOps) acquire(ctx context.Context, contextName, page, op string, wait time.Duration) (func(), error) {
+	key := pageLogKey(contextName, page)
+	q.mu.Lock()
+	slot := q.slots[key]
+	if slot == nil {
+		slot = &pageSlot{contextName: contextName, page: page, sem: make(chan struct{}, 1)}
+		q.slots[key] = slot
+	}
+	slot.waiting++
+	q.mu.Unlock()
+
+	acquired := false
+	var err error
+	if wait <= 0 {
+		select {
+		case slot.sem <- struct{}{}:
+			acquired = true
+		default:
+		}
+	} else {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case slot.sem <- struct{}{}:
+			acquired = true
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	slot.waiting--
+	if !acquired {
+		if err == nil {
+			err = fmt.Errorf("%w: %s is running %s for %s (%d waiting); retry or raise --wait-timeout",
+				ErrPageBusy, page, slot.op, time.Duration(NowMS()-slot.startMS)*time.Millisecond, slot.waiting)
+		}
+		q.dropIdleLocked(key, slot)
+		return nil, err
+	}
+	slot.op = op
+	slot.startMS = NowMS()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			slot.op = ""
+			slot.startMS = 0
+			<-slot.sem
+			q.dropIdleLocked(key, slot)
+		})
+	}, nil
+}
+
+func (q *pageOps) dropIdleLocked(key string, slot *pageSlot) {
+	if slot.op == "" && slot.waiting == 0 && q.slots[key] == slot {
+		delete(q.slots, key)
+	}
+}
+
+func (q *pageOps) status() []PageQueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := NowMS()
+	out := make([]PageQueueStatus, 0, len(q.slots))
+	for _, slot := range q.slots {
+		st := PageQueueStatus{Context: slot.contextName, Page: slot.page, Running: slot.op, Waiting: slot.waiting}
+		if slot.op != "" {
+			st.RunningMS = now - slot.startMS
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Context != out[j].Context {
+			return out[i].Context < out[j].Context
+		}
+		return out[i].Page < out[j].Page
+	})
+	return out
+}
+
+// AcquirePage serializes operations on a named page: it waits up to wait for
+// the page to be free (defaultPageWait when negative) and returns ErrPageBusy
+// when it is not, or ctx's error when the client went away meanwhile.
+func (b *BrowserHost) AcquirePage(ctx context.Context, contextName, name, op string, wait time.Duration) (func(), error) {
+	if wait < 0 {
+		wait = defaultPageWait
+	}
+	return b.ops.acquire(ctx, contextName, name, op, wait)
+}
+
+// PageQueues lists pages with a running or waiting operation.
+func (b *BrowserHost) PageQueues() []PageQueueStatus {
+	return b.ops.status()
+}
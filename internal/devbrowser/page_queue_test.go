@@ -0,0 +1,144 @@
+package devbrowser
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPageOpsSerializes(t *testing.T) {
+	q := newPageOps()
+	release, err := q.acquire(context.Background(), "", "main", "snapshot", time.Second)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	_, err = q.acquire(context.Background(), "", "main", "click", 0)
+	if !errors.Is(err, ErrPageBusy) || !strings.Contains(err.Error(), "running snapshot") {
+		t.Fatalf("expected busy error naming the running op, got %v", err)
+	}
+	if got := errorStatus(err, http.StatusInternalServerError); got != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", got)
+	}
+	if _, err := q.acquire(context.Background(), "", "other", "click", 0); err != nil {
+		t.Fatalf("expected other page to be free, got %v", err)
+	}
+	if _, err := q.acquire(context.Background(), "alice", "main", "click", 0); err != nil {
+		t.Fatalf("expected same page name in another context to be free, got %v", err)
+	}
+
+	acquired := make(chan func())
+	go func() {
+		next, err := q.acquire(context.Background(), "", "main", "click", 5*time.Second)
+		if err != nil {
+			t.Errorf("queued acquire: %v", err)
+		}
+		acquired <- next
+	}()
+	waitFor(t, func() bool {
+		st := pageQueueFor(q.status(), "", "main")
+		return st != nil && st.Waiting == 1 && st.Running == "snapshot"
+	})
+
+	release()
+	release() // releasing twice is harmless
+	next := <-acquired
+	if st := pageQueueFor(q.status(), "", "main"); st == nil || st.Running != "click" || st.Waiting != 0 {
+		t.Fatalf("expected queued click to run, got %+v", st)
+	}
+	next()
+	if st := pageQueueFor(q.status(), "", "main"); st != nil {
+		t.Fatalf("expected idle page to leave the queue list, got %+v", st)
+	}
+}
+
+func TestPageOpsWaitTimeoutAndCancel(t *testing.T) {
+	q := newPageOps()
+	release, err := q.acquire(context.Background(), "", "main", "goto", time.Second)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer release()
+
+	start := time.Now()
+	if _, err := q.acquire(context.Background(), "", "main", "click", 50*time.Millisecond); !errors.Is(err, ErrPageBusy) {
+		t.Fatalf("expected busy after wait timeout, got %v", err)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Fatal("expected acquire to wait before giving up")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := q.acquire(ctx, "", "main", "click", 5*time.Second); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+	if st := pageQueueFor(q.status(), "", "main"); st == nil || st.Waiting != 0 {
+		t.Fatalf("expected cancelled waiter to leave the queue, got %+v", st)
+	}
+}
+
+func TestHoldPageHoldsUntilReleased(t *testing.T) {
+	d := &Daemon{host: &BrowserHost{ops: newPageOps()}, logger: log.New(io.Discard, "", 0)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d.handlePageHold(w, r, "", "main")
+	}))
+	defer srv.Close()
+
+	release, err := HoldPage(srv.URL, "loop", time.Second)
+	if err != nil {
+		t.Fatalf("hold: %v", err)
+	}
+	if st := pageQueueFor(d.host.PageQueues(), "", "main"); st == nil || st.Running != "loop" {
+		t.Fatalf("expected the hold to show as running loop, got %+v", st)
+	}
+	if _, err := HoldPage(srv.URL, "assert", 0); err == nil || !strings.Contains(err.Error(), "running loop") {
+		t.Fatalf("expected a second hold to find the page busy, got %v", err)
+	}
+	release()
+	waitFor(t, func() bool { return pageQueueFor(d.host.PageQueues(), "", "main") == nil })
+	next, err := d.host.AcquirePage(context.Background(), "", "main", "snapshot", 0)
+	if err != nil {
+		t.Fatalf("expected the page to be free after release, got %v", err)
+	}
+	next()
+}
+
+func TestRunActionsContextStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := RunActionsContext(ctx, nil, []map[string]interface{}{{"name": "snapshot"}}, t.TempDir())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation before the first call, got %v", err)
+	}
+}
+
+func pageQueueFor(queues []PageQueueStatus, contextName, page string) *PageQueueStatus {
+	for i := range queues {
+		if queues[i].Context == contextName && queues[i].Page == page {
+			return &queues[i]
+		}
+	}
+	return nil
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("condition not met in time")
+}
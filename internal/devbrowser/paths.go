@@ -54,10 +54,29 @@ func StateDir(profile string) string {
 	return filepath.Join(PlatformStateDir(), cacheSubdir, profile)
 }
 
+// EnsureStateDir creates the profile state dir with mode 0700 and tightens
+// one an older build created world-readable: it holds the daemon socket, the
+// browser profile and the logs.
+func EnsureStateDir(profile string) (string, error) {
+	dir := StateDir(profile)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(dir, 0o700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
 func StateFile(profile string) string {
 	return filepath.Join(StateDir(profile), "daemon.json")
 }
 
+// DaemonSocketPath is where a daemon started with --socket listens.
+func DaemonSocketPath(profile string) string {
+	return filepath.Join(StateDir(profile), "daemon.sock")
+}
+
 func SafeArtifactPath(artifactDir, pathArg, defaultName string) (string, error) {
 	allowUnsafe := envTruthy("DEV_BROWSER_ALLOW_UNSAFE_PATHS")
 
@@ -106,6 +125,34 @@ func SafeArtifactPath(artifactDir, pathArg, defaultName string) (string, error)
 	return resolved, nil
 }
 
+// ResolveArtifactReadPath resolves an input file (HAR, saved state). Absolute
+// paths are used as-is; relative paths are tried against the working directory
+// first and then the profile artifact dir, where saves land by default.
+func ResolveArtifactReadPath(profile, pathArg string) (string, error) {
+	p := strings.TrimSpace(os.ExpandEnv(pathArg))
+	if p == "" {
+		return "", errors.New("path required")
+	}
+	if strings.HasPrefix(p, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			p = filepath.Join(home, strings.TrimPrefix(p, "~"))
+		}
+	}
+	if filepath.IsAbs(p) {
+		return p, nil
+	}
+	if abs, err := filepath.Abs(p); err == nil {
+		if _, err := os.Stat(abs); err == nil {
+			return abs, nil
+		}
+	}
+	candidate := filepath.Join(ArtifactDir(profile), p)
+	if _, err := os.Stat(candidate); err != nil {
+		return "", fmt.Errorf("file not found: %s", pathArg)
+	}
+	return candidate, nil
+}
+
 func envTruthy(name string) bool {
 	v := strings.ToLower(strings.TrimSpace(os.Getenv(name)))
 	return v == "1" || v == "true" || v == "yes" || v == "on"
@@ -0,0 +1,208 @@
+package devbrowser
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProfileInfo describes one profile found on disk. A profile exists when it
+// has a state dir (daemon.json, user data) or a cache dir (artifacts).
+type ProfileInfo struct {
+	Name    string `json:"name"`
+	Running bool   `json:"running"`
+	// Unresponsive is set when daemon.json names a live process that does
+	// not answer /health; such profiles are never pruned.
+	Unresponsive bool                    `json:"unresponsive,omitempty"`
+	StaleState   bool                    `json:"stale_state,omitempty"`
+	PID          int                     `json:"pid,omitempty"`
+	URL          string                  `json:"url,omitempty"`
+	Status       string                  `json:"status,omitempty"`
+	Context      *BrowserContextSettings `json:"context,omitempty"`
+
+	StateDir      string   `json:"state_dir"`
+	UserDataDirs  []string `json:"user_data_dirs,omitempty"`
+	UserDataBytes int64    `json:"user_data_bytes"`
+	CacheDir      string   `json:"cache_dir"`
+	ArtifactBytes int64    `json:"artifact_bytes"`
+}
+
+// Busy reports whether a daemon may still be using the profile's files.
+func (p ProfileInfo) Busy() bool {
+	return p.Running || p.Unresponsive
+}
+
+// PruneOptions selects what PruneProfile removes from a stopped profile.
+// Stale daemon state (daemon.json, daemon.sock) is always removed.
+type PruneOptions struct {
+	UserData  bool
+	Artifacts bool
+	DryRun    bool
+}
+
+// ErrProfileRunning is returned when deleting files of a profile whose daemon
+// is still up.
+var ErrProfileRunning = errors.New("profile daemon is running")
+
+// ProfilesStateRoot and ProfilesCacheRoot hold one directory per profile.
+func ProfilesStateRoot() string {
+	return filepath.Join(PlatformStateDir(), cacheSubdir)
+}
+
+func ProfilesCacheRoot() string {
+	return filepath.Join(PlatformCacheDir(), cacheSubdir)
+}
+
+func profileCacheDir(profile string) string {
+	return filepath.Join(ProfilesCacheRoot(), profile)
+}
+
+// ValidateProfileName rejects names that would escape the state/cache roots.
+func ValidateProfileName(name string) error {
+	if strings.TrimSpace(name) == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+	return nil
+}
+
+// ListProfiles inspects every profile under the state and cache roots, by
+// name.
+func ListProfiles() ([]ProfileInfo, error) {
+	names, err := profileNames(ProfilesStateRoot(), ProfilesCacheRoot())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ProfileInfo, 0, len(names))
+	for _, name := range names {
+		out = append(out, InspectProfile(name))
+	}
+	return out, nil
+}
+
+func profileNames(roots ...string) ([]string, error) {
+	seen := map[string]bool{}
+	for _, root := range roots {
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.IsDir() && ValidateProfileName(e.Name()) == nil {
+				seen[e.Name()] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// InspectProfile reports daemon liveness, settings and disk usage. Settings
+// come from /health when the daemon answers, else from the last daemon.json.
+func InspectProfile(name string) ProfileInfo {
+	info := ProfileInfo{
+		Name:     name,
+		StateDir: StateDir(name),
+		CacheDir: profileCacheDir(name),
+	}
+	state, _ := ReadState(name)
+	if health, err := ReadDaemonHealth(name); err == nil && health != nil && health.OK {
+		info.Running = true
+		info.PID = health.PID
+		info.URL = daemonBaseURL(health.Host, health.Port, health.Socket)
+		info.Status = health.Status
+		context := health.Context
+		info.Context = &context
+	} else if state != nil {
+		info.PID = state.PID
+		context := state.Context
+		info.Context = &context
+		if state.PID > 0 && processAlive(state.PID) {
+			info.Unresponsive = true
+		} else {
+			info.StaleState = true
+		}
+	}
+
+	info.UserDataDirs, _ = filepath.Glob(filepath.Join(info.StateDir, "*-profile"))
+	for _, dir := range info.UserDataDirs {
+		info.UserDataBytes += dirSize(dir)
+	}
+	info.ArtifactBytes = dirSize(info.CacheDir)
+	return info
+}
+
+// PruneProfile removes stale state and, when asked, user data and artifacts
+// of a profile without a live daemon. It returns the paths removed (or that
+// would be, with DryRun).
+func PruneProfile(info ProfileInfo, opts PruneOptions) ([]string, error) {
+	if info.Busy() {
+		return nil, fmt.Errorf("%w: %s", ErrProfileRunning, info.Name)
+	}
+	targets := []string{}
+	if info.StaleState {
+		targets = append(targets, StateFile(info.Name), DaemonSocketPath(info.Name))
+	}
+	if opts.UserData {
+		targets = append(targets, info.UserDataDirs...)
+	}
+	if opts.Artifacts {
+		targets = append(targets, info.CacheDir)
+	}
+	return removePaths(targets, opts.DryRun)
+}
+
+// RemoveProfile deletes a stopped profile's state dir (user data included)
+// and cache dir.
+func RemoveProfile(info ProfileInfo, dryRun bool) ([]string, error) {
+	if err := ValidateProfileName(info.Name); err != nil {
+		return nil, err
+	}
+	if info.Busy() {
+		return nil, fmt.Errorf("%w: %s", ErrProfileRunning, info.Name)
+	}
+	return removePaths([]string{StateDir(info.Name), profileCacheDir(info.Name)}, dryRun)
+}
+
+func removePaths(paths []string, dryRun bool) ([]string, error) {
+	removed := []string{}
+	for _, p := range paths {
+		if _, err := os.Lstat(p); err != nil {
+			continue
+		}
+		if !dryRun {
+			if err := os.RemoveAll(p); err != nil {
+				return removed, err
+			}
+		}
+		removed = append(removed, p)
+	}
+	return removed, nil
+}
+
+// dirSize sums regular file sizes below root; unreadable entries are skipped.
+func dirSize(root string) int64 {
+	var total int64
+	_ = filepath.WalkDir(root, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
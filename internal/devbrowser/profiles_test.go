@@ -0,0 +1,98 @@
+package devbrowser
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateProfileName(t *testing.T) {
+	for _, ok := range []string{"default", "mobile-1", "ci.2"} {
+		if err := ValidateProfileName(ok); err != nil {
+			t.Fatalf("expected %q to be valid, got %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"", " ", ".", "..", "a/b", `a\b`} {
+		if err := ValidateProfileName(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestListAndPruneProfiles(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	// "old" crashed without cleaning up: daemon.json points at no process.
+	writeTestFile(t, filepath.Join(userDataDir("old", BrowserChromium), "Default", "Cookies"), 2048)
+	writeTestFile(t, filepath.Join(ArtifactDir("old"), "shot.png"), 100)
+	state, _ := json.Marshal(DaemonState{PID: 0, Host: "127.0.0.1", Port: 1, Context: BrowserContextSettings{Browser: BrowserChromium, Headless: true}})
+	writeTestFile(t, StateFile("old"), 0)
+	if err := os.WriteFile(StateFile("old"), state, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// "art" only has artifacts.
+	writeTestFile(t, filepath.Join(ArtifactDir("art"), "report.json"), 10)
+
+	profiles, err := ListProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != 2 || profiles[0].Name != "art" || profiles[1].Name != "old" {
+		t.Fatalf("unexpected profiles: %+v", profiles)
+	}
+	old := profiles[1]
+	if old.Busy() || !old.StaleState || old.Context == nil || !old.Context.Headless {
+		t.Fatalf("expected stale stopped profile, got %+v", old)
+	}
+	if old.UserDataBytes != 2048 || old.ArtifactBytes != 100 {
+		t.Fatalf("unexpected sizes: user-data=%d artifacts=%d", old.UserDataBytes, old.ArtifactBytes)
+	}
+
+	removed, err := PruneProfile(old, PruneOptions{UserData: true, DryRun: true})
+	if err != nil || len(removed) != 2 {
+		t.Fatalf("expected dry run to list state file and user data, got %v, %v", removed, err)
+	}
+	if _, err := os.Stat(StateFile("old")); err != nil {
+		t.Fatal("dry run must not delete")
+	}
+
+	if _, err := PruneProfile(old, PruneOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(StateFile("old")); !os.IsNotExist(err) {
+		t.Fatalf("expected stale state file removed, got %v", err)
+	}
+	if _, err := os.Stat(userDataDir("old", BrowserChromium)); err != nil {
+		t.Fatal("user data must be kept without UserData")
+	}
+
+	running := ProfileInfo{Name: "old", Running: true}
+	if _, err := PruneProfile(running, PruneOptions{UserData: true}); !errors.Is(err, ErrProfileRunning) {
+		t.Fatalf("expected running profile to be refused, got %v", err)
+	}
+	if _, err := RemoveProfile(running, false); !errors.Is(err, ErrProfileRunning) {
+		t.Fatalf("expected running profile to be refused, got %v", err)
+	}
+
+	if _, err := RemoveProfile(InspectProfile("old"), false); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{StateDir("old"), profileCacheDir("old")} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Fatalf("expected %s removed, got %v", dir, err)
+		}
+	}
+}
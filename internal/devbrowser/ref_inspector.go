@@ -11,11 +11,15 @@ type RefInspectOptions struct {
 }
 
 func InspectRef(page playwright.Page, ref string, engine string, opts RefInspectOptions) (map[string]interface{}, error) {
-	if err := ensureInjected(page, engine); err != nil {
+	target, local, frame, err := resolveRefTarget(page, ref)
+	if err != nil {
 		return nil, err
 	}
-	payload := map[string]interface{}{"ref": ref, "opts": map[string]interface{}{"styleProps": opts.StyleProps}}
-	res, err := page.Evaluate(`(p) => globalThis.__devBrowser_inspectRef(p.ref, p.opts)`, payload)
+	if err := ensureInjected(target, engine); err != nil {
+		return nil, err
+	}
+	payload := map[string]interface{}{"ref": local, "opts": map[string]interface{}{"styleProps": opts.StyleProps}}
+	res, err := target.Evaluate(`(p) => globalThis.__devBrowser_inspectRef(p.ref, p.opts)`, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -23,6 +27,10 @@ func InspectRef(page playwright.Page, ref string, engine string, opts RefInspect
 	if !ok {
 		return nil, fmt.Errorf("unexpected inspect result")
 	}
+	if frame != "" {
+		m["ref"] = ref
+		m["frame"] = frame
+	}
 	return m, nil
 }
 
@@ -0,0 +1,202 @@
+package devbrowser
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HostLimits caps what one daemon may hold; zero means unlimited.
+type HostLimits struct {
+	MaxPages    int
+	MaxMemoryMB int
+}
+
+// DaemonResources is the "resources" block of /health: current usage next to
+// the configured limits (omitted when unlimited).
+type DaemonResources struct {
+	Pages         int   `json:"pages"`
+	MaxPages      int   `json:"max_pages,omitempty"`
+	MemoryMB      int64 `json:"memory_mb"`
+	MaxMemoryMB   int   `json:"max_memory_mb,omitempty"`
+	IdleMS        int64 `json:"idle_ms"`
+	IdleTimeoutMS int64 `json:"idle_timeout_ms,omitempty"`
+}
+
+const memorySampleInterval = 10 * time.Second
+
+// ErrPageLimit and ErrMemoryLimit are returned when a new page would exceed
+// the daemon's HostLimits.
+var (
+	ErrPageLimit   = errors.New("page limit reached")
+	ErrMemoryLimit = errors.New("memory limit reached")
+)
+
+// SetLimits applies page and memory caps to pages created from now on.
+func (b *BrowserHost) SetLimits(limits HostLimits) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.limits = limits
+}
+
+// SampleMemory records the memory of the browser processes (everything the
+// daemon spawned, Playwright driver included) and returns it in MB.
+func (b *BrowserHost) SampleMemory() (int64, error) {
+	procs, err := listProcesses()
+	if err != nil {
+		return 0, err
+	}
+	mb := descendantRSS(procs, os.Getpid()) / (1024 * 1024)
+	b.memoryMB.Store(mb)
+	return mb, nil
+}
+
+// Resources reports page count and the last memory sample against the limits.
+func (b *BrowserHost) Resources() DaemonResources {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return DaemonResources{
+		Pages:       b.openPageCountLocked(),
+		MaxPages:    b.limits.MaxPages,
+		MemoryMB:    b.memoryMB.Load(),
+		MaxMemoryMB: b.limits.MaxMemoryMB,
+	}
+}
+
+func (b *BrowserHost) openPageCountLocked() int {
+	n := len(openPageNames(b.registry))
+	for _, nc := range b.contexts {
+		n += len(openPageNames(nc.registry))
+	}
+	return n
+}
+
+// checkLimitsLocked is called before opening a new page.
+func (b *BrowserHost) checkLimitsLocked() error {
+	if max := b.limits.MaxPages; max > 0 && b.openPageCountLocked() >= max {
+		return fmt.Errorf("%w (max %d open pages; close-page or stop unused ones)", ErrPageLimit, max)
+	}
+	if max := b.limits.MaxMemoryMB; max > 0 {
+		if used := b.memoryMB.Load(); used >= int64(max) {
+			return fmt.Errorf("%w (browser uses %dMB of %dMB; close pages or restart the profile)", ErrMemoryLimit, used, max)
+		}
+	}
+	return nil
+}
+
+type procInfo struct {
+	pid  int
+	ppid int
+	rss  int64 // bytes
+}
+
+// descendantRSS sums the resident memory of every process below root.
+func descendantRSS(procs []procInfo, root int) int64 {
+	children := make(map[int][]procInfo)
+	for _, p := range procs {
+		children[p.ppid] = append(children[p.ppid], p)
+	}
+	var total int64
+	seen := map[int]bool{root: true}
+	queue := []int{root}
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		for _, c := range children[pid] {
+			if seen[c.pid] {
+				continue
+			}
+			seen[c.pid] = true
+			total += c.rss
+			queue = append(queue, c.pid)
+		}
+	}
+	return total
+}
+
+// listProcesses reads /proc where it exists and falls back to ps (macOS).
+func listProcesses() ([]procInfo, error) {
+	if _, err := os.Stat("/proc/self/stat"); err == nil {
+		return listProcProcesses("/proc")
+	}
+	out, err := exec.Command("ps", "-A", "-o", "pid=,ppid=,rss=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("list processes: %w", err)
+	}
+	return parsePSOutput(out), nil
+}
+
+func listProcProcesses(root string) ([]procInfo, error) {
+	stats, err := filepath.Glob(filepath.Join(root, "[0-9]*", "stat"))
+	if err != nil {
+		return nil, err
+	}
+	pageSize := int64(os.Getpagesize())
+	procs := make([]procInfo, 0, len(stats))
+	for _, path := range stats {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue // exited meanwhile
+		}
+		if p, ok := parseProcStat(string(data), pageSize); ok {
+			procs = append(procs, p)
+		}
+	}
+	return procs, nil
+}
+
+// parseProcStat reads pid, ppid and rss from /proc/<pid>/stat. The command
+// name may contain spaces and parentheses, so fields are counted from the
+// last ')'.
+func parseProcStat(stat string, pageSize int64) (procInfo, bool) {
+	open := strings.IndexByte(stat, '(')
+	close := strings.LastIndexByte(stat, ')')
+	if open <= 0 || close < open {
+		return procInfo{}, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(stat[:open]))
+	if err != nil {
+		return procInfo{}, false
+	}
+	// Fields after the name start at field 3 (state); ppid is 4, rss is 24.
+	fields := strings.Fields(stat[close+1:])
+	if len(fields) < 22 {
+		return procInfo{}, false
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return procInfo{}, false
+	}
+	pages, err := strconv.ParseInt(fields[21], 10, 64)
+	if err != nil {
+		return procInfo{}, false
+	}
+	return procInfo{pid: pid, ppid: ppid, rss: pages * pageSize}, true
+}
+
+// parsePSOutput reads "pid ppid rss(KiB)" lines.
+func parsePSOutput(out []byte) []procInfo {
+	procs := []procInfo{}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		kb, err3 := strconv.ParseInt(fields[2], 10, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		procs = append(procs, procInfo{pid: pid, ppid: ppid, rss: kb * 1024})
+	}
+	return procs
+}
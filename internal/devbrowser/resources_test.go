@@ -0,0 +1,126 @@
+package devbrowser
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+type fakePage struct {
+	playwright.Page
+	closed bool
+}
+
+func (p *fakePage) IsClosed() bool { return p.closed }
+
+func TestParseProcStat(t *testing.T) {
+	stat := "4242 (chrome (renderer)) S 4200 4242 4200 0 -1 4194560 100 0 0 0 5 2 0 0 20 0 12 0 3000 123456789 256 18446744073709551615"
+	p, ok := parseProcStat(stat, 4096)
+	if !ok {
+		t.Fatal("expected stat to parse")
+	}
+	if p.pid != 4242 || p.ppid != 4200 || p.rss != 256*4096 {
+		t.Fatalf("unexpected proc: %+v", p)
+	}
+	if _, ok := parseProcStat("garbage", 4096); ok {
+		t.Fatal("expected garbage to be rejected")
+	}
+}
+
+func TestParsePSOutput(t *testing.T) {
+	out := []byte("    1     0  1024\n  200     1  2048\nbogus line\n  201   200   512\n")
+	procs := parsePSOutput(out)
+	if len(procs) != 3 {
+		t.Fatalf("expected 3 procs, got %+v", procs)
+	}
+	if procs[1].pid != 200 || procs[1].ppid != 1 || procs[1].rss != 2048*1024 {
+		t.Fatalf("unexpected proc: %+v", procs[1])
+	}
+}
+
+func TestDescendantRSS(t *testing.T) {
+	procs := []procInfo{
+		{pid: 10, ppid: 1, rss: 100},  // daemon
+		{pid: 11, ppid: 10, rss: 200}, // driver
+		{pid: 12, ppid: 11, rss: 300}, // browser
+		{pid: 13, ppid: 12, rss: 400}, // renderer
+		{pid: 20, ppid: 1, rss: 999},  // unrelated
+	}
+	if got := descendantRSS(procs, 10); got != 900 {
+		t.Fatalf("expected 900, got %d", got)
+	}
+	if got := descendantRSS(procs, 99); got != 0 {
+		t.Fatalf("expected 0 for unknown root, got %d", got)
+	}
+}
+
+func TestCheckLimits(t *testing.T) {
+	b := NewBrowserHost("test", "", true, 0, nil, "")
+	b.registry["main"] = pageHolder{page: &fakePage{}}
+	b.registry["gone"] = pageHolder{page: &fakePage{closed: true}}
+	b.contexts["alice"] = &namedContext{registry: map[string]pageHolder{"main": {page: &fakePage{}}}}
+
+	if err := b.checkLimitsLocked(); err != nil {
+		t.Fatalf("expected no limits, got %v", err)
+	}
+	b.SetLimits(HostLimits{MaxPages: 2})
+	if err := b.checkLimitsLocked(); !errors.Is(err, ErrPageLimit) {
+		t.Fatalf("expected page limit, got %v", err)
+	}
+	if res := b.Resources(); res.Pages != 2 || res.MaxPages != 2 {
+		t.Fatalf("unexpected resources: %+v", res)
+	}
+
+	b.SetLimits(HostLimits{MaxPages: 3, MaxMemoryMB: 500})
+	b.memoryMB.Store(499)
+	if err := b.checkLimitsLocked(); err != nil {
+		t.Fatalf("expected room under limits, got %v", err)
+	}
+	b.memoryMB.Store(500)
+	if err := b.checkLimitsLocked(); !errors.Is(err, ErrMemoryLimit) {
+		t.Fatalf("expected memory limit, got %v", err)
+	}
+	if got := errorStatus(b.checkLimitsLocked(), http.StatusInternalServerError); got != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", got)
+	}
+}
+
+func TestIdleCheckInterval(t *testing.T) {
+	cases := map[time.Duration]time.Duration{
+		2 * time.Second:  time.Second,
+		time.Minute:      6 * time.Second,
+		30 * time.Minute: 30 * time.Second,
+	}
+	for timeout, want := range cases {
+		if got := idleCheckInterval(timeout); got != want {
+			t.Fatalf("idleCheckInterval(%s) = %s, want %s", timeout, got, want)
+		}
+	}
+}
+
+func TestTrackActivity(t *testing.T) {
+	d := &Daemon{}
+	d.lastActivity.Store(NowMS() - 60_000)
+
+	var idleDuring time.Duration
+	h := d.trackActivity(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idleDuring = d.idleFor()
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	if d.idleFor() < time.Minute {
+		t.Fatalf("expected /health not to count as activity, idle=%s", d.idleFor())
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/pages", nil))
+	if idleDuring != 0 {
+		t.Fatalf("expected a running request to keep the daemon busy, idle=%s", idleDuring)
+	}
+	if d.idleFor() >= time.Second {
+		t.Fatalf("expected request to reset idle time, idle=%s", d.idleFor())
+	}
+}
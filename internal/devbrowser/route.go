@@ -0,0 +1,329 @@
+package devbrowser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const (
+	RouteActionFulfill  = "fulfill"
+	RouteActionAbort    = "abort"
+	RouteActionContinue = "continue"
+)
+
+// routeAbortCodes mirrors the error codes accepted by Playwright's route.abort.
+var routeAbortCodes = map[string]bool{
+	"aborted": true, "accessdenied": true, "addressunreachable": true, "blockedbyclient": true,
+	"blockedbyresponse": true, "connectionaborted": true, "connectionclosed": true, "connectionfailed": true,
+	"connectionrefused": true, "connectionreset": true, "internetdisconnected": true, "namenotresolved": true,
+	"timedout": true, "failed": true,
+}
+
+// RouteRule is a persistent request interception rule owned by the daemon.
+//
+// A rule matches on URL (Playwright-style glob) or Regex, plus an optional
+// method, and then fulfills, aborts, or continues the request. DelayMs applies
+// before any action; Headers are response headers for fulfill and request
+// header overrides for continue (an empty value removes the header).
+type RouteRule struct {
+	ID          int64             `json:"id"`
+	URL         string            `json:"url,omitempty"`
+	Regex       string            `json:"regex,omitempty"`
+	Method      string            `json:"method,omitempty"`
+	Action      string            `json:"action"`
+	Status      int               `json:"status,omitempty"`
+	Body        string            `json:"body,omitempty"`
+	BodyPath    string            `json:"body_path,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	DelayMs     int               `json:"delay_ms,omitempty"`
+	ErrorCode   string            `json:"error_code,omitempty"`
+	Hits        int64             `json:"hits"`
+	CreatedMS   int64             `json:"created_ms"`
+}
+
+type routeEntry struct {
+	rule    RouteRule
+	matcher *regexp.Regexp
+}
+
+type routeStore struct {
+	mu     sync.Mutex
+	rules  []*routeEntry
+	nextID int64
+}
+
+func newRouteStore() *routeStore {
+	return &routeStore{}
+}
+
+func (s *routeStore) add(rule RouteRule) (RouteRule, error) {
+	rule, matcher, err := normalizeRouteRule(rule)
+	if err != nil {
+		return RouteRule{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	rule.ID = s.nextID
+	rule.Hits = 0
+	rule.CreatedMS = NowMS()
+	s.rules = append(s.rules, &routeEntry{rule: rule, matcher: matcher})
+	return cloneRouteRule(rule), nil
+}
+
+func (s *routeStore) list() []RouteRule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]RouteRule, 0, len(s.rules))
+	for _, entry := range s.rules {
+		out = append(out, cloneRouteRule(entry.rule))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (s *routeStore) remove(id int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, entry := range s.rules {
+		if entry.rule.ID == id {
+			s.rules = append(s.rules[:i], s.rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *routeStore) clear() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.rules)
+	s.rules = nil
+	return n
+}
+
+func (s *routeStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.rules)
+}
+
+// match returns the newest rule matching the request and records a hit.
+// Newest-first mirrors Playwright, where the last registered route wins.
+func (s *routeStore) match(method, rawURL string) (RouteRule, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.rules) - 1; i >= 0; i-- {
+		entry := s.rules[i]
+		if entry.rule.Method != "" && !strings.EqualFold(entry.rule.Method, method) {
+			continue
+		}
+		if !entry.matcher.MatchString(rawURL) {
+			continue
+		}
+		entry.rule.Hits++
+		return cloneRouteRule(entry.rule), true
+	}
+	return RouteRule{}, false
+}
+
+func normalizeRouteRule(rule RouteRule) (RouteRule, *regexp.Regexp, error) {
+	rule.URL = strings.TrimSpace(rule.URL)
+	rule.Regex = strings.TrimSpace(rule.Regex)
+	rule.Method = strings.ToUpper(strings.TrimSpace(rule.Method))
+	rule.Action = strings.ToLower(strings.TrimSpace(rule.Action))
+	rule.ContentType = strings.TrimSpace(rule.ContentType)
+	rule.ErrorCode = strings.ToLower(strings.TrimSpace(rule.ErrorCode))
+
+	if rule.URL == "" && rule.Regex == "" {
+		return RouteRule{}, nil, errors.New("route requires url or regex")
+	}
+	if rule.URL != "" && rule.Regex != "" {
+		return RouteRule{}, nil, errors.New("use either url or regex")
+	}
+	if rule.DelayMs < 0 {
+		return RouteRule{}, nil, errors.New("delay_ms must be >= 0")
+	}
+
+	var matcher *regexp.Regexp
+	var err error
+	if rule.Regex != "" {
+		matcher, err = regexp.Compile(rule.Regex)
+		if err != nil {
+			return RouteRule{}, nil, fmt.Errorf("invalid regex: %w", err)
+		}
+	} else {
+		matcher, err = globToRegexp(rule.URL)
+		if err != nil {
+			return RouteRule{}, nil, err
+		}
+	}
+
+	if rule.Action == "" {
+		rule.Action = RouteActionFulfill
+	}
+	switch rule.Action {
+	case RouteActionFulfill:
+		if rule.Status == 0 {
+			rule.Status = 200
+		}
+		if rule.Status < 100 || rule.Status > 599 {
+			return RouteRule{}, nil, fmt.Errorf("invalid status %d", rule.Status)
+		}
+		if rule.Body != "" && strings.TrimSpace(rule.BodyPath) != "" {
+			return RouteRule{}, nil, errors.New("use either body or body_path")
+		}
+		if path := strings.TrimSpace(rule.BodyPath); path != "" {
+			// Relative to whose working directory? The CLI resolves it.
+			if !filepath.IsAbs(path) {
+				return RouteRule{}, nil, errors.New("body_path must be absolute")
+			}
+			if _, err := os.Stat(path); err != nil {
+				return RouteRule{}, nil, fmt.Errorf("body_path: %w", err)
+			}
+			rule.BodyPath = path
+		}
+		rule.ErrorCode = ""
+	case RouteActionAbort:
+		if rule.ErrorCode == "" {
+			rule.ErrorCode = "failed"
+		}
+		if !routeAbortCodes[rule.ErrorCode] {
+			return RouteRule{}, nil, fmt.Errorf("invalid error_code %q", rule.ErrorCode)
+		}
+		if rule.Status != 0 || rule.Body != "" || rule.BodyPath != "" {
+			return RouteRule{}, nil, errors.New("abort does not accept status or body")
+		}
+	case RouteActionContinue:
+		if rule.Status != 0 || rule.Body != "" || rule.BodyPath != "" {
+			return RouteRule{}, nil, errors.New("continue does not accept status or body")
+		}
+		rule.ErrorCode = ""
+	default:
+		return RouteRule{}, nil, fmt.Errorf("invalid action %q (expected fulfill, abort, continue)", rule.Action)
+	}
+	rule.Headers = cloneStringMap(rule.Headers)
+	return rule, matcher, nil
+}
+
+// globToRegexp converts a Playwright URL glob into an anchored regexp.
+// `*` matches within a path segment, `**` matches across segments, `?` matches
+// one character, and `{a,b}` matches either alternative.
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	inGroup := false
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString(".")
+		case '{':
+			if inGroup {
+				return nil, fmt.Errorf("invalid url glob %q: nested braces", glob)
+			}
+			inGroup = true
+			sb.WriteString("(?:")
+		case '}':
+			if !inGroup {
+				return nil, fmt.Errorf("invalid url glob %q: unmatched brace", glob)
+			}
+			inGroup = false
+			sb.WriteString(")")
+		case ',':
+			if inGroup {
+				sb.WriteString("|")
+			} else {
+				sb.WriteString(",")
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if inGroup {
+		return nil, fmt.Errorf("invalid url glob %q: unmatched brace", glob)
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+func cloneRouteRule(rule RouteRule) RouteRule {
+	rule.Headers = cloneStringMap(rule.Headers)
+	return rule
+}
+
+func cloneStringMap(src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(src))
+	for k, v := range src {
+		out[k] = v
+	}
+	return out
+}
+
+func applyRouteRule(route playwright.Route, rule RouteRule) error {
+	if rule.DelayMs > 0 {
+		time.Sleep(time.Duration(rule.DelayMs) * time.Millisecond)
+	}
+	switch rule.Action {
+	case RouteActionAbort:
+		return route.Abort(rule.ErrorCode)
+	case RouteActionContinue:
+		if len(rule.Headers) == 0 {
+			return route.Fallback()
+		}
+		return route.Fallback(playwright.RouteFallbackOptions{Headers: mergeRouteHeaders(route.Request().Headers(), rule.Headers)})
+	default:
+		opts := playwright.RouteFulfillOptions{
+			Status:  playwright.Int(rule.Status),
+			Headers: cloneStringMap(rule.Headers),
+		}
+		if rule.ContentType != "" {
+			opts.ContentType = playwright.String(rule.ContentType)
+		}
+		if rule.BodyPath != "" {
+			opts.Path = playwright.String(rule.BodyPath)
+		} else {
+			opts.Body = rule.Body
+		}
+		return route.Fulfill(opts)
+	}
+}
+
+func mergeRouteHeaders(base map[string]string, overrides map[string]string) map[string]string {
+	out := make(map[string]string, len(base)+len(overrides))
+	for k, v := range base {
+		out[strings.ToLower(k)] = v
+	}
+	for k, v := range overrides {
+		key := strings.ToLower(strings.TrimSpace(k))
+		if key == "" {
+			continue
+		}
+		if v == "" {
+			delete(out, key)
+			continue
+		}
+		out[key] = v
+	}
+	return out
+}
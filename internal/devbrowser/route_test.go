@@ -0,0 +1,131 @@
+package devbrowser
+
+import "testing"
+
+func TestGlobToRegexp(t *testing.T) {
+	cases := []struct {
+		glob  string
+		url   string
+		match bool
+	}{
+		{"**/api/users", "https://example.com/api/users", true},
+		{"**/api/users", "https://example.com/api/users/1", false},
+		{"**/api/**", "https://example.com/api/users/1", true},
+		{"https://example.com/*.js", "https://example.com/app.js", true},
+		{"https://example.com/*.js", "https://example.com/static/app.js", false},
+		{"**/*.{png,jpg}", "https://cdn.example.com/a/b.jpg", true},
+		{"**/*.{png,jpg}", "https://cdn.example.com/a/b.gif", false},
+		{"**/item?", "https://example.com/item1", true},
+		{"**/a.b", "https://example.com/aXb", false},
+	}
+	for _, tc := range cases {
+		re, err := globToRegexp(tc.glob)
+		if err != nil {
+			t.Fatalf("glob %q: unexpected error: %v", tc.glob, err)
+		}
+		if got := re.MatchString(tc.url); got != tc.match {
+			t.Fatalf("glob %q url %q: expected match=%v, got %v", tc.glob, tc.url, tc.match, got)
+		}
+	}
+
+	for _, bad := range []string{"**/{a,b", "**/a}", "**/{a,{b}}"} {
+		if _, err := globToRegexp(bad); err == nil {
+			t.Fatalf("glob %q: expected error", bad)
+		}
+	}
+}
+
+func TestNormalizeRouteRule_Defaults(t *testing.T) {
+	rule, _, err := normalizeRouteRule(RouteRule{URL: " **/api ", Method: "post"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule.Action != RouteActionFulfill || rule.Status != 200 {
+		t.Fatalf("expected fulfill/200 defaults, got %+v", rule)
+	}
+	if rule.URL != "**/api" || rule.Method != "POST" {
+		t.Fatalf("expected trimmed url and upper-case method, got %+v", rule)
+	}
+
+	rule, _, err = normalizeRouteRule(RouteRule{URL: "**/x", Action: "abort"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule.ErrorCode != "failed" {
+		t.Fatalf("expected default error code failed, got %q", rule.ErrorCode)
+	}
+}
+
+func TestNormalizeRouteRule_Invalid(t *testing.T) {
+	cases := []RouteRule{
+		{},
+		{URL: "**/x", Regex: "x"},
+		{Regex: "("},
+		{URL: "**/x", DelayMs: -1},
+		{URL: "**/x", Status: 700},
+		{URL: "**/x", Action: "bogus"},
+		{URL: "**/x", Action: "abort", ErrorCode: "nope"},
+		{URL: "**/x", Action: "abort", Status: 500},
+		{URL: "**/x", Action: "continue", Body: "x"},
+		{URL: "**/x", Body: "x", BodyPath: "/tmp/x"},
+		{URL: "**/x", BodyPath: "/definitely/missing/body.json"},
+		{URL: "**/x", BodyPath: "route_test.go"}, // relative, though it exists here
+	}
+	for _, rule := range cases {
+		if _, _, err := normalizeRouteRule(rule); err == nil {
+			t.Fatalf("expected error for %+v", rule)
+		}
+	}
+}
+
+func TestRouteStore_MatchNewestFirstAndHits(t *testing.T) {
+	s := newRouteStore()
+	first, err := s.add(RouteRule{URL: "**/api/**", Status: 500})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := s.add(RouteRule{URL: "**/api/users", Method: "GET", Status: 404})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.ID != 1 || second.ID != 2 {
+		t.Fatalf("expected sequential ids, got %d and %d", first.ID, second.ID)
+	}
+
+	got, ok := s.match("GET", "https://example.com/api/users")
+	if !ok || got.ID != second.ID {
+		t.Fatalf("expected newest rule to win, got %+v ok=%v", got, ok)
+	}
+	got, ok = s.match("POST", "https://example.com/api/users")
+	if !ok || got.ID != first.ID {
+		t.Fatalf("expected method mismatch to fall through to first rule, got %+v ok=%v", got, ok)
+	}
+	if _, ok := s.match("GET", "https://example.com/other"); ok {
+		t.Fatalf("expected no match")
+	}
+
+	list := s.list()
+	if len(list) != 2 || list[0].Hits != 1 || list[1].Hits != 1 {
+		t.Fatalf("unexpected hit counts: %+v", list)
+	}
+
+	if !s.remove(second.ID) || s.remove(second.ID) {
+		t.Fatalf("expected remove to succeed once")
+	}
+	if n := s.clear(); n != 1 || s.len() != 0 {
+		t.Fatalf("expected clear to drop 1 rule, got %d (len=%d)", n, s.len())
+	}
+}
+
+func TestMergeRouteHeaders(t *testing.T) {
+	got := mergeRouteHeaders(
+		map[string]string{"Accept": "text/html", "Cookie": "a=1"},
+		map[string]string{"X-Test": "1", "cookie": ""},
+	)
+	if got["accept"] != "text/html" || got["x-test"] != "1" {
+		t.Fatalf("unexpected merged headers: %+v", got)
+	}
+	if _, ok := got["cookie"]; ok {
+		t.Fatalf("expected empty override to remove header: %+v", got)
+	}
+}
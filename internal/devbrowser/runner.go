@@ -1,6 +1,7 @@
 package devbrowser
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"image"
@@ -45,50 +46,8 @@ func RunCall(page playwright.Page, name string, args map[string]interface{}, art
 		return RunResult{"url": page.URL(), "title": safeTitle(page)}, nil
 
 	case "snapshot":
-		engine, err := optionalString(args, "engine", "simple")
-		if err != nil {
-			return nil, err
-		}
-		format, err := optionalString(args, "format", "list")
-		if err != nil {
-			return nil, err
-		}
-		interactiveOnly, err := optionalBool(args, "interactive_only", true)
-		if err != nil {
-			return nil, err
-		}
-		includeHeadings, err := optionalBool(args, "include_headings", true)
-		if err != nil {
-			return nil, err
-		}
-		maxItems, err := optionalInt(args, "max_items", 80)
-		if err != nil {
-			return nil, err
-		}
-		maxChars, err := optionalInt(args, "max_chars", 8000)
-		if err != nil {
-			return nil, err
-		}
-
-		snap, err := GetSnapshot(page, SnapshotOptions{
-			Engine:          engine,
-			Format:          format,
-			InteractiveOnly: interactiveOnly,
-			IncludeHeadings: includeHeadings,
-			MaxItems:        maxItems,
-			MaxChars:        maxChars,
-		})
-		if err != nil {
-			return nil, err
-		}
-		return RunResult{
-			"url":      page.URL(),
-			"title":    safeTitle(page),
-			"engine":   engine,
-			"format":   format,
-			"snapshot": snap.Yaml,
-			"items":    snap.Items,
-		}, nil
+		res, _, err := runSnapshot(page, args, nil)
+		return res, err
 
 	case "diagnose":
 		url, err := optionalString(args, "url", "")
@@ -135,6 +94,31 @@ func RunCall(page playwright.Page, name string, args map[string]interface{}, art
 		if err != nil {
 			return nil, err
 		}
+		runID, err := optionalString(args, "run_id", "")
+		if err != nil {
+			return nil, err
+		}
+		modeArg, err := optionalString(args, "artifact_mode", string(ArtifactModeMinimal))
+		if err != nil {
+			return nil, err
+		}
+		mode := ArtifactMode(modeArg)
+		if !mode.Valid() {
+			return nil, errors.New("artifact_mode must be none|minimal|full")
+		}
+		runDirArg, err := optionalString(args, "artifact_dir", "")
+		if err != nil {
+			return nil, err
+		}
+		runDir := ""
+		if mode != ArtifactModeNone {
+			runDir = artifactDir
+			if strings.TrimSpace(runDirArg) != "" {
+				if runDir, err = SafeArtifactPath(artifactDir, runDirArg, ""); err != nil {
+					return nil, err
+				}
+			}
+		}
 
 		report, err := Diagnose(page, DiagnoseOptions{
 			URL:             url,
@@ -143,8 +127,9 @@ func RunCall(page playwright.Page, name string, args map[string]interface{}, art
 			MinWaitMs:       minWaitMs,
 			PageName:        pageName,
 			Profile:         profile,
-			ArtifactDir:     artifactDir,
-			Artifacts:       ArtifactModeMinimal,
+			RunID:           runID,
+			ArtifactDir:     runDir,
+			Artifacts:       mode,
 			SnapshotEngine:  snapshotEngine,
 			NetBodies:       netBodies,
 			NetMaxBodyBytes: netMaxBodyBytes,
@@ -200,6 +185,10 @@ func RunCall(page playwright.Page, name string, args map[string]interface{}, art
 		}
 		return RunResult{"ref": ref, "filled": true}, nil
 
+	case "hover_ref", "dblclick_ref", "rightclick_ref", "check_ref", "uncheck_ref", "select_ref",
+		"focus_ref", "scroll_into_view_ref", "drag_ref", "type_ref", "upload_ref":
+		return runRefAction(page, name, args)
+
 	case "press":
 		key, err := requireString(args, "key")
 		if err != nil {
@@ -838,6 +827,9 @@ func RunCall(page playwright.Page, name string, args map[string]interface{}, art
 	case "diff_images":
 		return runDiffImages(page, args, artifactDir)
 
+	case "cookies_list", "cookies_get", "cookies_set", "cookies_delete", "cookies_clear":
+		return runCookies(page, name, args)
+
 	case "save_dom_baseline":
 		pathArg, err := requireString(args, "path")
 		if err != nil {
@@ -1054,7 +1046,99 @@ func RunCall(page playwright.Page, name string, args map[string]interface{}, art
 	return nil, fmt.Errorf("unknown call '%s'", name)
 }
 
+// runSnapshot is the snapshot tool. previous is passed on as
+// SnapshotOptions.Previous; besides the result it returns every element the
+// snapshot scanned, which a token budget may have cut from the result.
+func runSnapshot(page playwright.Page, args map[string]interface{}, previous []map[string]interface{}) (RunResult, []map[string]interface{}, error) {
+	engine, err := optionalString(args, "engine", "simple")
+	if err != nil {
+		return nil, nil, err
+	}
+	format, err := optionalString(args, "format", "list")
+	if err != nil {
+		return nil, nil, err
+	}
+	interactiveOnly, err := optionalBool(args, "interactive_only", true)
+	if err != nil {
+		return nil, nil, err
+	}
+	includeHeadings, err := optionalBool(args, "include_headings", true)
+	if err != nil {
+		return nil, nil, err
+	}
+	maxItems, err := optionalInt(args, "max_items", 80)
+	if err != nil {
+		return nil, nil, err
+	}
+	maxChars, err := optionalInt(args, "max_chars", 8000)
+	if err != nil {
+		return nil, nil, err
+	}
+	within, err := optionalString(args, "within", "")
+	if err != nil {
+		return nil, nil, err
+	}
+	maxDepth, err := optionalInt(args, "max_depth", 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	if maxDepth < 0 {
+		return nil, nil, errors.New("max_depth must be >= 0")
+	}
+	tokenBudget, err := optionalInt(args, "token_budget", 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tokenBudget < 0 || (tokenBudget > 0 && tokenBudget < MinTokenBudget) {
+		return nil, nil, fmt.Errorf("token_budget must be 0 (off) or at least %d", MinTokenBudget)
+	}
+	if diff, _ := optionalBool(args, "diff", false); diff && tokenBudget > 0 {
+		return nil, nil, errors.New("token_budget cannot be combined with diff")
+	}
+
+	snap, err := GetSnapshot(page, SnapshotOptions{
+		Engine:          engine,
+		Format:          format,
+		InteractiveOnly: interactiveOnly,
+		IncludeHeadings: includeHeadings,
+		MaxItems:        maxItems,
+		MaxChars:        maxChars,
+		Within:          strings.TrimSpace(within),
+		MaxDepth:        maxDepth,
+		Frames:          true,
+		TokenBudget:     tokenBudget,
+		Previous:        previous,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	res := RunResult{
+		"url":      page.URL(),
+		"title":    safeTitle(page),
+		"engine":   engine,
+		"format":   format,
+		"snapshot": snap.Yaml,
+		"items":    snap.Items,
+		// For the daemon's diff baseline: which document the refs belong to
+		// and whether max_items cut the list short.
+		"document":  snap.Document,
+		"truncated": snap.Truncated,
+	}
+	scanned := snap.Items
+	if snap.Budget != nil {
+		res["budget"] = snap.Budget
+		scanned = snap.Scanned
+	}
+	return res, scanned, nil
+}
+
 func RunActions(page playwright.Page, calls []map[string]interface{}, artifactDir string) (ActionsResult, error) {
+	return RunActionsContext(context.Background(), page, calls, artifactDir)
+}
+
+// RunActionsContext is RunActions that stops before the next call once ctx
+// is done; the call already running finishes.
+func RunActionsContext(ctx context.Context, page playwright.Page, calls []map[string]interface{}, artifactDir string) (ActionsResult, error) {
 	results := []map[string]interface{}{}
 	snapshotText := ""
 
@@ -1076,6 +1160,9 @@ func RunActions(page playwright.Page, calls []map[string]interface{}, artifactDi
 			return ActionsResult{}, errors.New("call 'arguments' must be an object")
 		}
 
+		if err := ctx.Err(); err != nil {
+			return ActionsResult{}, err
+		}
 		res, err := RunCall(page, name, args, artifactDir)
 		if err != nil {
 			return ActionsResult{}, err
@@ -0,0 +1,214 @@
+package devbrowser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// runRefAction handles the ref-based interactions beyond click_ref/fill_ref.
+// Every tool takes "ref" (resolved through SelectRef) and "timeout_ms".
+func runRefAction(page playwright.Page, name string, args map[string]interface{}) (RunResult, error) {
+	ref, err := requireString(args, "ref")
+	if err != nil {
+		return nil, err
+	}
+	timeoutMs, err := optionalInt(args, "timeout_ms", 15_000)
+	if err != nil {
+		return nil, err
+	}
+	timeout := playwright.Float(float64(timeoutMs))
+
+	// Validate tool-specific args before resolving the ref so bad input never
+	// leaks an element handle.
+	var text string
+	var delayMs int
+	var selectValues playwright.SelectOptionValues
+	var targetRef string
+	var files []string
+	switch name {
+	case "type_ref":
+		if _, ok := args["text"]; !ok {
+			return nil, errors.New("expected string 'text'")
+		}
+		if text, err = optionalStringAllowEmpty(args, "text", ""); err != nil {
+			return nil, err
+		}
+		if delayMs, err = optionalInt(args, "delay_ms", 0); err != nil {
+			return nil, err
+		}
+		if delayMs < 0 {
+			return nil, errors.New("delay_ms must be >= 0")
+		}
+	case "select_ref":
+		if selectValues, err = selectOptionValuesFromArgs(args); err != nil {
+			return nil, err
+		}
+	case "drag_ref":
+		if targetRef, err = requireString(args, "target"); err != nil {
+			return nil, err
+		}
+	case "upload_ref":
+		if files, err = uploadFilesFromArgs(args); err != nil {
+			return nil, err
+		}
+	}
+
+	el, err := SelectRef(page, ref, "simple")
+	if err != nil {
+		return nil, err
+	}
+	defer el.Dispose()
+
+	switch name {
+	case "hover_ref":
+		if err := el.Hover(playwright.ElementHandleHoverOptions{Timeout: timeout}); err != nil {
+			return nil, err
+		}
+		return RunResult{"ref": ref, "hovered": true}, nil
+
+	case "dblclick_ref":
+		if err := el.Dblclick(playwright.ElementHandleDblclickOptions{Timeout: timeout}); err != nil {
+			return nil, err
+		}
+		return RunResult{"ref": ref, "double_clicked": true}, nil
+
+	case "rightclick_ref":
+		if err := el.Click(playwright.ElementHandleClickOptions{Button: playwright.MouseButtonRight, Timeout: timeout}); err != nil {
+			return nil, err
+		}
+		return RunResult{"ref": ref, "right_clicked": true}, nil
+
+	case "check_ref":
+		if err := el.Check(playwright.ElementHandleCheckOptions{Timeout: timeout}); err != nil {
+			return nil, err
+		}
+		return RunResult{"ref": ref, "checked": true}, nil
+
+	case "uncheck_ref":
+		if err := el.Uncheck(playwright.ElementHandleUncheckOptions{Timeout: timeout}); err != nil {
+			return nil, err
+		}
+		return RunResult{"ref": ref, "checked": false}, nil
+
+	case "select_ref":
+		selected, err := el.SelectOption(selectValues, playwright.ElementHandleSelectOptionOptions{Timeout: timeout})
+		if err != nil {
+			return nil, err
+		}
+		return RunResult{"ref": ref, "selected": selected}, nil
+
+	case "focus_ref":
+		if err := el.Focus(); err != nil {
+			return nil, err
+		}
+		return RunResult{"ref": ref, "focused": true}, nil
+
+	case "scroll_into_view_ref":
+		if err := el.ScrollIntoViewIfNeeded(playwright.ElementHandleScrollIntoViewIfNeededOptions{Timeout: timeout}); err != nil {
+			return nil, err
+		}
+		res := RunResult{"ref": ref, "scrolled": true}
+		if box, err := el.BoundingBox(); err == nil && box != nil {
+			res["bbox"] = map[string]float64{"x": box.X, "y": box.Y, "width": box.Width, "height": box.Height}
+		}
+		return res, nil
+
+	case "type_ref":
+		opts := playwright.ElementHandleTypeOptions{Timeout: timeout}
+		if delayMs > 0 {
+			opts.Delay = playwright.Float(float64(delayMs))
+		}
+		if err := el.Type(text, opts); err != nil {
+			return nil, err
+		}
+		return RunResult{"ref": ref, "typed": len([]rune(text)), "delay_ms": delayMs}, nil
+
+	case "drag_ref":
+		target, err := SelectRef(page, targetRef, "simple")
+		if err != nil {
+			return nil, err
+		}
+		defer target.Dispose()
+		if err := dragElement(page, el, target, timeout); err != nil {
+			return nil, err
+		}
+		return RunResult{"ref": ref, "target": targetRef, "dragged": true}, nil
+
+	case "upload_ref":
+		if err := el.SetInputFiles(files, playwright.ElementHandleSetInputFilesOptions{Timeout: timeout}); err != nil {
+			return nil, err
+		}
+		return RunResult{"ref": ref, "files": files, "uploaded": len(files)}, nil
+	}
+
+	return nil, fmt.Errorf("unknown call '%s'", name)
+}
+
+// dragElement mirrors Locator.DragTo for element handles: hover the source,
+// press, hover the target, release. Chromium turns this into HTML5 drag
+// events as well as plain mouse events.
+func dragElement(page playwright.Page, source, target playwright.ElementHandle, timeout *float64) error {
+	if err := source.Hover(playwright.ElementHandleHoverOptions{Timeout: timeout}); err != nil {
+		return fmt.Errorf("hover drag source: %w", err)
+	}
+	if err := page.Mouse().Down(); err != nil {
+		return err
+	}
+	if err := target.Hover(playwright.ElementHandleHoverOptions{Timeout: timeout}); err != nil {
+		_ = page.Mouse().Up()
+		return fmt.Errorf("hover drag target: %w", err)
+	}
+	return page.Mouse().Up()
+}
+
+// selectOptionValuesFromArgs reads "values" (string or array) matched by
+// "by": value, label, or auto (either; the default).
+func selectOptionValuesFromArgs(args map[string]interface{}) (playwright.SelectOptionValues, error) {
+	values, err := optionalStringSlice(args, "values")
+	if err != nil {
+		return playwright.SelectOptionValues{}, err
+	}
+	if len(values) == 0 {
+		return playwright.SelectOptionValues{}, errors.New("expected non-empty 'values'")
+	}
+	by, err := optionalString(args, "by", "auto")
+	if err != nil {
+		return playwright.SelectOptionValues{}, err
+	}
+	switch strings.ToLower(by) {
+	case "auto":
+		return playwright.SelectOptionValues{ValuesOrLabels: &values}, nil
+	case "value":
+		return playwright.SelectOptionValues{Values: &values}, nil
+	case "label":
+		return playwright.SelectOptionValues{Labels: &values}, nil
+	default:
+		return playwright.SelectOptionValues{}, fmt.Errorf("invalid by '%s' (expected auto, value or label)", by)
+	}
+}
+
+// uploadFilesFromArgs reads "files" (string or array). Paths are resolved by
+// the process running the tool, so callers should send absolute paths.
+func uploadFilesFromArgs(args map[string]interface{}) ([]string, error) {
+	files, err := optionalStringSlice(args, "files")
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errors.New("expected non-empty 'files'")
+	}
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return nil, fmt.Errorf("upload file: %w", err)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("upload file %s is a directory", f)
+		}
+	}
+	return files, nil
+}
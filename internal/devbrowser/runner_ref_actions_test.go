@@ -0,0 +1,63 @@
+package devbrowser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelectOptionValuesFromArgs(t *testing.T) {
+	values, err := selectOptionValuesFromArgs(map[string]interface{}{"values": []interface{}{"us", "ca"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if values.ValuesOrLabels == nil || len(*values.ValuesOrLabels) != 2 || values.Values != nil || values.Labels != nil {
+		t.Fatalf("expected auto matching, got %+v", values)
+	}
+
+	values, err = selectOptionValuesFromArgs(map[string]interface{}{"values": "Canada", "by": "label"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if values.Labels == nil || (*values.Labels)[0] != "Canada" {
+		t.Fatalf("expected label matching, got %+v", values)
+	}
+
+	values, err = selectOptionValuesFromArgs(map[string]interface{}{"values": []string{"ca"}, "by": "value"})
+	if err != nil || values.Values == nil {
+		t.Fatalf("expected value matching, got %+v, %v", values, err)
+	}
+
+	for _, bad := range []map[string]interface{}{
+		{},
+		{"values": []interface{}{}},
+		{"values": []interface{}{1}},
+		{"values": "ca", "by": "index"},
+	} {
+		if _, err := selectOptionValuesFromArgs(bad); err == nil {
+			t.Fatalf("expected error for %+v", bad)
+		}
+	}
+}
+
+func TestRunRefAction_ValidatesArgsBeforeResolvingRef(t *testing.T) {
+	cases := []struct {
+		name string
+		args map[string]interface{}
+		want string
+	}{
+		{"hover_ref", map[string]interface{}{}, "'ref'"},
+		{"type_ref", map[string]interface{}{"ref": "e1"}, "'text'"},
+		{"type_ref", map[string]interface{}{"ref": "e1", "text": "hi", "delay_ms": -1}, "delay_ms"},
+		{"select_ref", map[string]interface{}{"ref": "e1"}, "'values'"},
+		{"drag_ref", map[string]interface{}{"ref": "e1"}, "'target'"},
+		{"upload_ref", map[string]interface{}{"ref": "e1"}, "'files'"},
+		{"upload_ref", map[string]interface{}{"ref": "e1", "files": "/nonexistent/dev-browser-upload.csv"}, "upload file"},
+	}
+	for _, tc := range cases {
+		// A nil page is never touched because validation fails first.
+		_, err := runRefAction(nil, tc.name, tc.args)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s %+v: expected error mentioning %s, got %v", tc.name, tc.args, tc.want, err)
+		}
+	}
+}
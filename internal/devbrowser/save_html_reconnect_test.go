@@ -63,7 +63,7 @@ func TestSaveHTMLReconnectWaitsForNonEmptyBody(t *testing.T) {
 	defer server.Close()
 
 	pageName := "save-html-regression"
-	first, err := EnsurePageInfo(profile, true, pageName, nil, "")
+	first, err := EnsurePageInfo(profile, "", true, pageName, nil, "")
 	if err != nil {
 		skipIfBrowserUnavailable(t, err)
 		t.Fatalf("ensure page for goto: %v", err)
@@ -85,7 +85,7 @@ func TestSaveHTMLReconnectWaitsForNonEmptyBody(t *testing.T) {
 	_ = browser.Close()
 	_ = pw.Stop()
 
-	second, err := EnsurePageInfo(profile, true, pageName, nil, "")
+	second, err := EnsurePageInfo(profile, "", true, pageName, nil, "")
 	if err != nil {
 		t.Fatalf("ensure page for save_html: %v", err)
 	}
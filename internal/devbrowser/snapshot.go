@@ -1,7 +1,9 @@
 package devbrowser
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/playwright-community/playwright-go"
 )
@@ -13,14 +15,38 @@ type SnapshotOptions struct {
 	IncludeHeadings bool
 	MaxItems        int
 	MaxChars        int
+	// Within starts the snapshot at one element (a ref or CSS selector)
+	// instead of the document; MaxDepth, when > 0, stops that many element
+	// levels below the start.
+	Within   string
+	MaxDepth int
+	// Frames adds a section per visible iframe below the document, with
+	// refs like "f1:e3". Only the snapshot tool sets it; Within naming a
+	// frame works either way.
+	Frames bool
+	// TokenBudget, when > 0, replaces MaxItems and MaxChars: the snapshot
+	// scans up to budgetScanItems elements and ApplyTokenBudget picks what
+	// fits. Previous, when not nil, holds the elements of the page's last
+	// snapshot, so ones added or changed since then are kept first.
+	TokenBudget int
+	Previous    []map[string]interface{}
 }
 
 type SnapshotResult struct {
 	Yaml  string
 	Items []map[string]interface{}
+	// Truncated is set when MaxItems stopped the scan.
+	Truncated bool
+	// Document identifies the document the refs belong to; it changes on
+	// navigation and reload but not on pushState or hash changes.
+	Document string
+	// Budget reports what a token-budgeted snapshot left out, and Scanned
+	// holds every element it looked at.
+	Budget  *SnapshotBudget
+	Scanned []map[string]interface{}
 }
 
-func ensureInjected(page playwright.Page, engine string) error {
+func ensureInjected(page jsEvaluator, engine string) error {
 	present := false
 	if val, err := page.Evaluate("() => Boolean(globalThis.__devBrowser_getAISnapshot)"); err == nil {
 		if b, ok := val.(bool); ok {
@@ -49,7 +75,39 @@ func ensureInjected(page playwright.Page, engine string) error {
 	return nil
 }
 
+// GetSnapshot snapshots the page and, with Frames and unless scoped with
+// Within, each visible iframe below it, whose refs are qualified like
+// "f1:e3". Within may also name a frame ("f1") or an element in one
+// ("f1:e3"). With TokenBudget the result is cut down to fit by
+// ApplyTokenBudget.
 func GetSnapshot(page playwright.Page, opts SnapshotOptions) (*SnapshotResult, error) {
+	if opts.TokenBudget > 0 {
+		opts.MaxItems, opts.MaxChars = budgetScanItems, budgetScanChars
+	}
+	snap, err := collectSnapshot(page, opts)
+	if err != nil || opts.TokenBudget <= 0 {
+		return snap, err
+	}
+	if opts.Previous != nil {
+		markChanged(snap.Items, opts.Previous)
+	}
+	text, items, report := ApplyTokenBudget(snap.Yaml, snap.Items, snap.Truncated, opts.TokenBudget)
+	return &SnapshotResult{Yaml: text, Items: items, Truncated: snap.Truncated, Document: snap.Document, Budget: &report, Scanned: snap.Items}, nil
+}
+
+func collectSnapshot(page playwright.Page, opts SnapshotOptions) (*SnapshotResult, error) {
+	if snap, ok, err := frameScopedSnapshot(page, opts.Within, opts); ok {
+		return snap, err
+	}
+	snap, err := snapshotIn(page, opts)
+	if err != nil || !opts.Frames || strings.TrimSpace(opts.Within) != "" {
+		return snap, err
+	}
+	return appendFrameSnapshots(page, snap, opts), nil
+}
+
+// snapshotIn runs the snapshot script in one document.
+func snapshotIn(page jsEvaluator, opts SnapshotOptions) (*SnapshotResult, error) {
 	if err := ensureInjected(page, opts.Engine); err != nil {
 		return nil, err
 	}
@@ -61,6 +119,11 @@ func GetSnapshot(page playwright.Page, opts SnapshotOptions) (*SnapshotResult, e
 		"includeHeadings": opts.IncludeHeadings,
 		"maxItems":        opts.MaxItems,
 		"maxChars":        opts.MaxChars,
+
+		"within":   opts.Within,
+		"maxDepth": opts.MaxDepth,
+
+		"signals": opts.TokenBudget > 0,
 	}
 
 	raw, err := page.Evaluate("(opts) => globalThis.__devBrowser_getAISnapshot(opts)", payload)
@@ -83,14 +146,69 @@ func GetSnapshot(page playwright.Page, opts SnapshotOptions) (*SnapshotResult, e
 		}
 	}
 
-	return &SnapshotResult{Yaml: yaml, Items: items}, nil
+	truncated, _ := m["truncated"].(bool)
+	document, _ := m["document"].(string)
+	return &SnapshotResult{Yaml: yaml, Items: items, Truncated: truncated, Document: document}, nil
 }
 
+// ErrStaleRef matches a *StaleRefError with errors.Is.
+var ErrStaleRef = errors.New("stale ref")
+
+// StaleRefError is returned by SelectRef when a ref's element has left the
+// page and no re-rendered node took its place. Suggestion is the ref of the
+// element that most likely replaced it, or "".
+type StaleRefError struct {
+	Ref        string
+	Role       string
+	Name       string
+	Suggestion string
+	message    string
+}
+
+func (e *StaleRefError) Error() string {
+	return e.message
+}
+
+func (e *StaleRefError) Unwrap() error { return ErrStaleRef }
+
+// SelectRef resolves a snapshot ref to its element. Refs stay bound to their
+// DOM node; a node re-rendered in place keeps its ref, and a ref whose
+// element is gone fails with a *StaleRefError instead of matching another
+// element.
 func SelectRef(page playwright.Page, ref string, engine string) (playwright.ElementHandle, error) {
-	if err := ensureInjected(page, engine); err != nil {
+	target, local, frame, err := resolveRefTarget(page, ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureInjected(target, engine); err != nil {
 		return nil, err
 	}
-	handle, err := page.EvaluateHandle("(ref) => globalThis.__devBrowser_selectSnapshotRef(ref)", ref)
+	raw, err := target.Evaluate("(ref) => globalThis.__devBrowser_refStatus(ref)", local)
+	if err != nil {
+		return nil, err
+	}
+	if st, ok := raw.(map[string]interface{}); ok {
+		switch status, _ := st["status"].(string); status {
+		case "stale":
+			stale := &StaleRefError{Ref: ref}
+			stale.Role, _ = st["role"].(string)
+			stale.Name, _ = st["name"].(string)
+			suggestion, _ := st["suggestion"].(string)
+			stale.Suggestion = qualifyRef(frame, suggestion)
+			stale.message, _ = st["message"].(string)
+			if frame != "" {
+				stale.message = strings.Replace(stale.message, "stale ref "+local, "stale ref "+ref, 1)
+				if suggestion != "" {
+					stale.message = strings.Replace(stale.message, "replacement: "+suggestion, "replacement: "+stale.Suggestion, 1)
+				}
+			}
+			return nil, stale
+		case "missing":
+			msg, _ := st["message"].(string)
+			return nil, errors.New(msg)
+		}
+	}
+	handle, err := target.EvaluateHandle("(ref) => globalThis.__devBrowser_selectSnapshotRef(ref)", local)
 	if err != nil {
 		return nil, err
 	}
@@ -105,12 +223,35 @@ func SelectRef(page playwright.Page, ref string, engine string) (playwright.Elem
 	return element, nil
 }
 
+// DrawRefOverlay boxes and labels up to maxRefs refs of the last snapshot:
+// the page's first, then those of each iframe the snapshot covered, labelled
+// with the frame id ("f1:e3").
 func DrawRefOverlay(page playwright.Page, maxRefs int, engine string) error {
 	if err := ensureInjected(page, engine); err != nil {
 		return err
 	}
-	_, err := page.Evaluate("(opts) => globalThis.__devBrowser_drawRefOverlay(opts)", map[string]interface{}{"maxRefs": maxRefs})
-	return err
+	raw, err := page.Evaluate("(opts) => globalThis.__devBrowser_drawRefOverlay(opts)", map[string]interface{}{"maxRefs": maxRefs})
+	if err != nil {
+		return err
+	}
+	drawn := overlayCount(raw)
+	for _, f := range snapshotFrames(page) {
+		if drawn >= maxRefs {
+			break
+		}
+		raw, err := f.frame.Evaluate(`(opts) => globalThis.__devBrowser_drawRefOverlay && globalThis.__devBrowserLastSnapshot ? globalThis.__devBrowser_drawRefOverlay(opts) : null`,
+			map[string]interface{}{"maxRefs": maxRefs - drawn, "prefix": f.id + ":"})
+		if err == nil {
+			drawn += overlayCount(raw)
+		}
+	}
+	return nil
+}
+
+func overlayCount(raw interface{}) int {
+	m, _ := raw.(map[string]interface{})
+	n, _ := m["refs"].(float64)
+	return int(n)
 }
 
 func ClearRefOverlay(page playwright.Page, engine string) error {
@@ -118,5 +259,17 @@ func ClearRefOverlay(page playwright.Page, engine string) error {
 		return err
 	}
 	_, err := page.Evaluate("() => globalThis.__devBrowser_clearRefOverlay()")
+	for _, f := range snapshotFrames(page) {
+		_, _ = f.frame.Evaluate("() => globalThis.__devBrowser_clearRefOverlay && globalThis.__devBrowser_clearRefOverlay()")
+	}
 	return err
 }
+
+// truncateSnapshotText caps snapshot text the way the snapshot script does.
+func truncateSnapshotText(text string, maxChars int) string {
+	if maxChars <= 0 || len([]rune(text)) <= maxChars {
+		return text
+	}
+	body, _ := TruncateStringRunes(text, max(0, maxChars-40))
+	return body + fmt.Sprintf("\n- [...] truncated (max_chars=%d)", maxChars)
+}
@@ -40,27 +40,47 @@ func ariaScript() string {
 	sb.WriteString("(() => {\n")
 	sb.WriteString("  if (globalThis.__devBrowser_getAISnapshotAria) return;\n\n")
 	sb.WriteString(vendorCode)
-	sb.WriteString("\n\n  function __devBrowser_ariaSnapshot(userOpts) {\n")
+	sb.WriteString("\n\n  // Give aria nodes the base script's refs, which stay bound to their element\n")
+	sb.WriteString("  // and are shared with the simple engine; the vendored code is left as is.\n")
+	sb.WriteString("  const __devBrowser_vendorComputeAriaRef = computeAriaRef;\n")
+	sb.WriteString("  computeAriaRef = function (ariaNode, options) {\n")
+	sb.WriteString("    __devBrowser_vendorComputeAriaRef(ariaNode, options);\n")
+	sb.WriteString("    if (ariaNode.ref && globalThis.__devBrowser_ensureRef) {\n")
+	sb.WriteString("      ariaNode.ref = globalThis.__devBrowser_ensureRef(ariaNode.element, ariaNode.role, ariaNode.name);\n")
+	sb.WriteString("    }\n")
+	sb.WriteString("  };\n")
+	sb.WriteString("\n  function __devBrowser_ariaSnapshot(userOpts) {\n")
 	sb.WriteString("    const opts = userOpts || {};\n")
 	sb.WriteString("    const format = String(opts.format || \"list\").toLowerCase();\n")
 	sb.WriteString("    const maxItems = typeof opts.maxItems === \"number\" && opts.maxItems > 0 ? opts.maxItems : 80;\n")
 	sb.WriteString("    const maxChars = typeof opts.maxChars === \"number\" && opts.maxChars > 0 ? opts.maxChars : 8000;\n")
 	sb.WriteString("    const interactiveOnly = opts.interactiveOnly !== false;\n")
-	sb.WriteString("    const includeHeadings = opts.includeHeadings !== false;\n\n")
-	sb.WriteString("    const snap = generateAriaTree(document.body);\n")
+	sb.WriteString("    const includeHeadings = opts.includeHeadings !== false;\n")
+	sb.WriteString("    const maxDepth = typeof opts.maxDepth === \"number\" && opts.maxDepth > 0 ? opts.maxDepth : 0;\n\n")
+	sb.WriteString("    const scope = globalThis.__devBrowser_resolveScope(opts.within) || document.body;\n")
+	sb.WriteString("    globalThis.__devBrowser_pruneRefs();\n")
+	sb.WriteString("    let snap;\n")
+	sb.WriteString("    try { snap = generateAriaTree(scope); }\n")
+	sb.WriteString("    finally { globalThis.__devBrowser_releaseOrphanRefs(); }\n")
 	sb.WriteString("    const nodesToWalk = snap.root && snap.root.role === \"fragment\" ? (snap.root.children || []) : [snap.root];\n\n")
+	sb.WriteString("    // Top-level nodes are depth 0; with maxDepth, nodes below it are cut.\n")
+	sb.WriteString("    let depthLimited = false;\n")
+	sb.WriteString("    function limitDepth(node, depth) {\n")
+	sb.WriteString("      if (!node || typeof node === \"string\" || !node.children) return;\n")
+	sb.WriteString("      if (depth >= maxDepth) {\n")
+	sb.WriteString("        if (node.children.some((c) => typeof c !== \"string\")) depthLimited = true;\n")
+	sb.WriteString("        node.children = node.children.filter((c) => typeof c === \"string\");\n")
+	sb.WriteString("        return;\n")
+	sb.WriteString("      }\n")
+	sb.WriteString("      for (const child of node.children) limitDepth(child, depth + 1);\n")
+	sb.WriteString("    }\n")
+	sb.WriteString("    if (maxDepth > 0) for (const node of nodesToWalk) limitDepth(node, 0);\n\n")
 	sb.WriteString("    function truncate(text) {\n")
 	sb.WriteString("      if (typeof text !== \"string\") return \"\";\n")
 	sb.WriteString("      if (text.length <= maxChars) return text;\n")
 	sb.WriteString("      const suffix = `\\n- [...] truncated (max_chars=${maxChars})`;\n")
 	sb.WriteString("      return text.slice(0, Math.max(0, maxChars - suffix.length)) + suffix;\n")
 	sb.WriteString("    }\n\n")
-	sb.WriteString("    const refsObject = {};\n")
-	sb.WriteString("    try {\n")
-	sb.WriteString("      for (const [ref, element] of snap.elements) refsObject[ref] = element;\n")
-	sb.WriteString("    } catch {\n")
-	sb.WriteString("    }\n")
-	sb.WriteString("    globalThis.__devBrowserRefs = refsObject;\n\n")
 	sb.WriteString("    const items = [];\n")
 	sb.WriteString("    let currentHeading = null;\n")
 	sb.WriteString("    const stack = [];\n")
@@ -91,10 +111,10 @@ func ariaScript() string {
 	sb.WriteString("      for (let i = children.length - 1; i >= 0; i--) stack.push(children[i]);\n")
 	sb.WriteString("    }\n\n")
 	sb.WriteString("    const truncated = items.length >= maxItems;\n")
-	sb.WriteString("    const listYaml = globalThis.__devBrowser_buildYaml(items, { maxItems, maxChars, truncated });\n\n")
-	sb.WriteString("    if (format === \"list\") return { yaml: listYaml, items };\n")
+	sb.WriteString("    const listYaml = globalThis.__devBrowser_buildYaml(items, { maxItems, maxChars, truncated, maxDepth, depthLimited });\n\n")
+	sb.WriteString("    if (format === \"list\") return { yaml: listYaml, items, truncated };\n")
 	sb.WriteString("    if (format === \"tree\") {\n")
-	sb.WriteString("      if (!interactiveOnly) return { yaml: truncate(renderAriaTree(snap)), items };\n\n")
+	sb.WriteString("      if (!interactiveOnly) return { yaml: truncate(renderAriaTree(snap)), items, truncated };\n\n")
 	sb.WriteString("      function prune(node) {\n")
 	sb.WriteString("        if (!node || typeof node === \"string\") return null;\n")
 	sb.WriteString("        const kids = node.children || [];\n")
@@ -109,7 +129,7 @@ func ariaScript() string {
 	sb.WriteString("      }\n")
 	sb.WriteString("      const prunedRoot = prune(snap.root);\n")
 	sb.WriteString("      const pruned = { ...snap, root: prunedRoot || { role: \"fragment\", name: \"\", children: [] } };\n")
-	sb.WriteString("      return { yaml: truncate(renderAriaTree(pruned)), items };\n")
+	sb.WriteString("      return { yaml: truncate(renderAriaTree(pruned)), items, truncated };\n")
 	sb.WriteString("    }\n")
 	sb.WriteString("    throw new Error(`Unknown snapshot format: ${format}`);\n")
 	sb.WriteString("  }\n\n")
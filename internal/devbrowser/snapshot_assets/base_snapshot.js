@@ -54,7 +54,8 @@
     const parts = ids.split(/\s+/).filter(Boolean);
     const texts = [];
     for (const id of parts) {
-      const n = el.ownerDocument ? el.ownerDocument.getElementById(id) : null;
+      const root = el.getRootNode ? el.getRootNode() : el.ownerDocument;
+      const n = root && root.getElementById ? root.getElementById(id) : null;
       if (n) {
         const t = norm(n.innerText || n.textContent || "");
         if (t) texts.push(t);
@@ -113,16 +114,199 @@
     return (el.getAttribute && el.getAttribute("role") || "").toLowerCase() === "heading";
   }
 
-  function ensureRef(el) {
-    if (!globalThis.__devBrowserRefCounter) globalThis.__devBrowserRefCounter = 0;
+  // Refs name a DOM node for as long as it lives, whichever engine or
+  // snapshot saw it first. The registry also keeps each ref's fingerprint
+  // (role, name, structural path), so a node that a framework re-rendered in
+  // place takes over the ref of the detached node it replaced. Detached
+  // entries are dropped when the next snapshot starts; that snapshot may
+  // still hand their refs to re-rendered nodes (orphans).
+  function refRegistry() {
+    let reg = globalThis.__devBrowserRefRegistry;
+    if (!reg) {
+      // id tells documents apart: refs are only comparable within one.
+      const id = Date.now().toString(36) + "-" + Math.random().toString(36).slice(2, 8);
+      reg = { id, counter: 0, byRef: new Map(), byFingerprint: new Map(), orphans: null };
+      globalThis.__devBrowserRefRegistry = reg;
+    }
+    return reg;
+  }
+
+  // pruneRefs drops entries whose element was collected or detached, keeping
+  // their fingerprints as orphans until releaseOrphanRefs.
+  function pruneRefs() {
+    const reg = refRegistry();
+    const orphans = new Map();
+    for (const [ref, entry] of reg.byRef) {
+      if (refIsLive(entry)) continue;
+      reg.byRef.delete(ref);
+      if (reg.byFingerprint.get(entry.fingerprint) === ref) orphans.set(entry.fingerprint, ref);
+    }
+    for (const [fingerprint, ref] of reg.byFingerprint) {
+      const entry = reg.byRef.get(ref);
+      if (!entry || entry.fingerprint !== fingerprint) reg.byFingerprint.delete(fingerprint);
+    }
+    reg.orphans = orphans;
+  }
+
+  function releaseOrphanRefs() {
+    refRegistry().orphans = null;
+  }
+
+  function structuralPath(el) {
+    const parts = [];
+    let node = el;
+    while (node && node.nodeType === 1 && node !== node.ownerDocument.documentElement) {
+      let idx = 1;
+      for (let sib = node.previousElementSibling; sib; sib = sib.previousElementSibling) {
+        if (sib.tagName === node.tagName) idx++;
+      }
+      parts.unshift(`${node.tagName.toLowerCase()}[${idx}]`);
+      node = node.parentElement || (node.parentNode && node.parentNode.host) || null;
+    }
+    return parts.join("/");
+  }
+
+  function refElement(entry) {
+    if (!entry) return null;
+    const el = typeof WeakRef === "function" && entry.el instanceof WeakRef ? entry.el.deref() : entry.el;
+    return el || null;
+  }
+
+  function refIsLive(entry) {
+    const el = refElement(entry);
+    return !!el && el.isConnected;
+  }
+
+  function registerRef(reg, ref, el, role, name) {
+    const path = structuralPath(el);
+    const fingerprint = [role || "", name || "", path].join("|");
+    const old = reg.byRef.get(ref);
+    if (old && old.fingerprint !== fingerprint && reg.byFingerprint.get(old.fingerprint) === ref) {
+      reg.byFingerprint.delete(old.fingerprint);
+    }
+    el.__devBrowserRef = ref;
+    reg.byRef.set(ref, {
+      el: typeof WeakRef === "function" ? new WeakRef(el) : el,
+      role: role || "",
+      name: name || "",
+      path,
+      fingerprint
+    });
+    reg.byFingerprint.set(fingerprint, ref);
+  }
+
+  function ensureRef(el, role, name) {
+    const reg = refRegistry();
     let ref = el.__devBrowserRef;
-    if (!ref) {
-      ref = "e" + String(++globalThis.__devBrowserRefCounter);
-      el.__devBrowserRef = ref;
+    if (!ref || refElement(reg.byRef.get(ref)) !== el) {
+      const fingerprint = [role || "", name || "", structuralPath(el)].join("|");
+      const orphan = reg.orphans && reg.orphans.get(fingerprint);
+      const previous = orphan || reg.byFingerprint.get(fingerprint);
+      if (previous && !refIsLive(reg.byRef.get(previous))) {
+        ref = previous;
+        if (orphan) reg.orphans.delete(fingerprint);
+      } else {
+        ref = "e" + String(++reg.counter);
+      }
     }
+    registerRef(reg, ref, el, role, name);
     return ref;
   }
 
+  // elementsAtPath resolves a structuralPath; a step below a shadow host may
+  // be in its light DOM or its shadow root, so there can be two matches.
+  function elementsAtPath(path) {
+    let nodes = [document.documentElement];
+    for (const part of path ? path.split("/") : []) {
+      const m = /^(.+)\[(\d+)\]$/.exec(part);
+      if (!m) return [];
+      const next = [];
+      for (const node of nodes) {
+        for (const root of [node, node.shadowRoot]) {
+          if (!root) continue;
+          let idx = 0;
+          for (const child of Array.from(root.children || [])) {
+            if (child.tagName.toLowerCase() === m[1] && ++idx === Number(m[2])) {
+              next.push(child);
+              break;
+            }
+          }
+        }
+      }
+      nodes = next;
+    }
+    return nodes;
+  }
+
+  // deepElements lists the document's elements, including those in open
+  // shadow roots, in tree order.
+  function deepElements(root) {
+    const out = [];
+    const visit = (node) => {
+      for (const el of Array.from(node.children || [])) {
+        out.push(el);
+        if (el.shadowRoot) visit(el.shadowRoot);
+        visit(el);
+      }
+    };
+    visit(root || document);
+    return out;
+  }
+
+  function describeRef(role, name) {
+    return name ? `${role} ${JSON.stringify(name)}` : role;
+  }
+
+  // replaceRef looks for the element that replaced a stale ref's, without
+  // giving out refs unless it finds the re-render: a node at the same role,
+  // name and path with no ref of its own takes the stale ref over. Anything
+  // looser is only suggested: a live ref with the same role and name (same
+  // path first), or else a matching visible element, described without a ref.
+  // The document is scanned only when nothing else matched.
+  function replaceRef(ref, entry) {
+    const reg = refRegistry();
+    const owned = (el) => !!el.__devBrowserRef && refElement(reg.byRef.get(el.__devBrowserRef)) === el;
+    const matches = (el) => getRole(el) === entry.role && getLabel(el) === entry.name && !isHidden(el);
+    for (const el of elementsAtPath(entry.path)) {
+      if (!owned(el) && matches(el)) {
+        registerRef(reg, ref, el, entry.role, entry.name);
+        return { adopted: true };
+      }
+    }
+    let live = null;
+    for (const [other, o] of reg.byRef) {
+      if (o === entry || o.role !== entry.role || o.name !== entry.name || !refIsLive(o)) continue;
+      if (o.fingerprint === entry.fingerprint) return { suggestion: other };
+      if (!live) live = other;
+    }
+    if (live) return { suggestion: live };
+    for (const el of deepElements(document)) {
+      if (!owned(el) && matches(el)) return { unreffed: true };
+    }
+    return {};
+  }
+
+  // refStatus reports whether a ref still names an element on the page; a
+  // stale ref comes with a message naming a likely replacement.
+  function refStatus(ref) {
+    const reg = refRegistry();
+    if (reg.byRef.size === 0) return { status: "missing", message: "No snapshot refs found. Call snapshot first." };
+    const entry = reg.byRef.get(ref);
+    if (!entry) return { status: "missing", message: `Ref "${ref}" not found. Call snapshot again.` };
+    if (refIsLive(entry)) return { status: "ok" };
+    const found = replaceRef(ref, entry);
+    if (found.adopted) return { status: "ok" };
+    let message = `stale ref ${ref}: ${describeRef(entry.role, entry.name)} is no longer on the page`;
+    if (found.suggestion) {
+      const other = reg.byRef.get(found.suggestion);
+      message += `; likely replacement: ${found.suggestion} (${describeRef(other.role, other.name)})`;
+    } else if (found.unreffed) {
+      message += `; likely replacement: ${describeRef(entry.role, entry.name)} elsewhere on the page, not in a snapshot yet`;
+    }
+    message += "; call snapshot again for current refs";
+    return { status: "stale", role: entry.role, name: entry.name, suggestion: found.suggestion || "", message };
+  }
+
   function triStateAttr(value) {
     const v = (value || "").toLowerCase();
     if (v === "mixed") return "mixed";
@@ -154,10 +338,14 @@
     return { disabled, checked, expanded, selected, pressed, active };
   }
 
-  function walk(root, state, items, opts) {
+  function walk(root, state, items, opts, depth) {
     if (!root || items.length >= opts.maxItems) return;
 
     const children = root.children ? Array.from(root.children) : [];
+    if (opts.maxDepth > 0 && depth > opts.maxDepth) {
+      if (children.length) state.depthLimited = true;
+      return;
+    }
     for (const el of children) {
       if (items.length >= opts.maxItems) return;
       const tag = (el.tagName || "").toLowerCase();
@@ -171,9 +359,8 @@
       const role = getRole(el);
       if ((!opts.interactiveOnly || isInteractive(el, role)) && !isHidden(el)) {
         const name = getLabel(el);
-        const ref = ensureRef(el);
+        const ref = ensureRef(el, role, name);
         const st = getStates(el);
-        globalThis.__devBrowserRefs[ref] = el;
         items.push({
           ref,
           role,
@@ -188,8 +375,8 @@
         });
       }
 
-      if (el.shadowRoot) walk(el.shadowRoot, state, items, opts);
-      walk(el, state, items, opts);
+      if (el.shadowRoot) walk(el.shadowRoot, state, items, opts, depth + 1);
+      walk(el, state, items, opts, depth + 1);
     }
   }
 
@@ -216,6 +403,7 @@
       lines.push(`${indent}- ${item.role}${name}${suffix}`);
     }
     if (opts.truncated) lines.push(`- [...] truncated (max_items=${opts.maxItems})`);
+    if (opts.depthLimited) lines.push(`- [...] deeper elements omitted (max_depth=${opts.maxDepth})`);
     let text = lines.join("\n");
     if (text.length > opts.maxChars) {
       text = text.slice(0, Math.max(0, opts.maxChars - 40)) + `\n- [...] truncated (max_chars=${opts.maxChars})`;
@@ -228,27 +416,54 @@
     const maxItems = typeof opts.maxItems === "number" && opts.maxItems > 0 ? opts.maxItems : 80;
     const maxChars = typeof opts.maxChars === "number" && opts.maxChars > 0 ? opts.maxChars : 8000;
     const interactiveOnly = opts.interactiveOnly !== false;
+    const maxDepth = typeof opts.maxDepth === "number" && opts.maxDepth > 0 ? opts.maxDepth : 0;
+    const scope = resolveScope(opts.within);
+    pruneRefs();
 
-    globalThis.__devBrowserRefs = {};
     const items = [];
-    const state = { heading: null };
-    walk(document.documentElement, state, items, { maxItems, maxChars, interactiveOnly });
+    const state = { heading: null, depthLimited: false };
+    const walkOpts = { maxItems, maxChars, interactiveOnly, maxDepth };
+    // A scope element is listed like its descendants, at depth 0; otherwise
+    // depth counts from <html>.
+    if (scope) walk({ children: [scope] }, state, items, walkOpts, 0);
+    else walk(document.documentElement, state, items, walkOpts, 1);
+    releaseOrphanRefs();
     const truncated = items.length >= maxItems;
 
-    const yaml = buildYaml(items, { maxItems, maxChars, truncated });
-    globalThis.__devBrowserLastSnapshot = { yaml, items };
+    const yaml = buildYaml(items, { maxItems, maxChars, truncated, maxDepth, depthLimited: state.depthLimited });
+    globalThis.__devBrowserLastSnapshot = { yaml, items, truncated };
     return globalThis.__devBrowserLastSnapshot;
   }
 
-  function selectSnapshotRef(ref) {
-    const refs = globalThis.__devBrowserRefs;
-    if (!refs) throw new Error("No snapshot refs found. Call snapshot first.");
-    const el = refs[ref];
-    if (!el) throw new Error(`Ref "${ref}" not found. Call snapshot again.`);
-    if (!el.isConnected) throw new Error(`Ref "${ref}" is stale (element detached). Call snapshot again.`);
+  // resolveScope returns the element a snapshot starts from, given a ref or
+  // a CSS selector, or null for the whole document.
+  function resolveScope(within) {
+    const w = String(within || "").trim();
+    if (!w) return null;
+    if (/^e\d+$/.test(w)) return selectSnapshotRef(w);
+    let el = null;
+    try {
+      el = document.querySelector(w);
+      // Selectors do not cross shadow boundaries; look inside open shadow
+      // roots when the light DOM has no match.
+      if (!el) {
+        for (const host of deepElements(document)) {
+          if (host.shadowRoot && (el = host.shadowRoot.querySelector(w))) break;
+        }
+      }
+    } catch (e) {
+      throw new Error(`within: invalid selector "${w}": ${e.message}`);
+    }
+    if (!el) throw new Error(`within: "${w}" matched no element`);
     return el;
   }
 
+  function selectSnapshotRef(ref) {
+    const st = refStatus(ref);
+    if (st.status !== "ok") throw new Error(st.message);
+    return refElement(refRegistry().byRef.get(ref));
+  }
+
   function clearRefOverlay() {
     const root = globalThis.__devBrowserRefOverlayRoot;
     if (root && root.parentNode) root.parentNode.removeChild(root);
@@ -277,7 +492,7 @@
     for (const item of snap.items) {
       if (!item || !item.ref) continue;
       if (count >= maxRefs) break;
-      const el = globalThis.__devBrowserRefs ? globalThis.__devBrowserRefs[item.ref] : null;
+      const el = refElement(refRegistry().byRef.get(item.ref));
       if (!el || !el.getBoundingClientRect) continue;
       const r = el.getBoundingClientRect();
       if (!r || r.width <= 0 || r.height <= 0) continue;
@@ -298,7 +513,7 @@
       box.style.background = "rgba(255,59,48,0.04)";
 
       const label = document.createElement("div");
-      label.textContent = String(item.ref);
+      label.textContent = (opts.prefix || "") + String(item.ref);
       label.style.position = "absolute";
       label.style.left = "0px";
       label.style.top = "0px";
@@ -319,17 +534,42 @@
     return { ok: true, refs: count };
   }
 
+  // addSignals marks what a token budget prioritizes besides focus: elements
+  // on screen and interactive ones. What changed since the last snapshot is
+  // worked out by the daemon from the snapshot it kept.
+  function addSignals(items) {
+    const reg = refRegistry();
+    const vw = window.innerWidth || 0;
+    const vh = window.innerHeight || 0;
+    for (const item of items || []) {
+      const el = refElement(reg.byRef.get(item.ref));
+      if (!el) continue;
+      let r = null;
+      try {
+        r = el.getBoundingClientRect();
+      } catch {
+      }
+      item.inViewport = !!r && r.width > 0 && r.height > 0 && r.bottom > 0 && r.right > 0 && r.top < vh && r.left < vw;
+      item.interactive = isInteractive(el, item.role);
+    }
+  }
+
   function getAISnapshot(userOpts) {
     const opts = userOpts || {};
     const engine = (opts.engine || "simple").toLowerCase();
-    if (engine === "simple") return simpleSnapshot(opts);
-    if (engine === "aria") {
+    let result;
+    if (engine === "simple") {
+      result = simpleSnapshot(opts);
+    } else if (engine === "aria") {
       if (!globalThis.__devBrowser_getAISnapshotAria) throw new Error("ARIA snapshot engine not installed");
-      const result = globalThis.__devBrowser_getAISnapshotAria(opts);
+      result = globalThis.__devBrowser_getAISnapshotAria(opts);
       globalThis.__devBrowserLastSnapshot = result;
-      return result;
+    } else {
+      throw new Error(`Unknown snapshot engine: ${engine}`);
     }
-    throw new Error(`Unknown snapshot engine: ${engine}`);
+    if (opts.signals) addSignals(result.items);
+    result.document = refRegistry().id;
+    return result;
   }
 
   function cssSelectorFor(el) {
@@ -575,6 +815,11 @@
   globalThis.__devBrowser_buildYaml = buildYaml;
   globalThis.__devBrowser_getAISnapshot = getAISnapshot;
   globalThis.__devBrowser_selectSnapshotRef = selectSnapshotRef;
+  globalThis.__devBrowser_refStatus = refStatus;
+  globalThis.__devBrowser_ensureRef = ensureRef;
+  globalThis.__devBrowser_pruneRefs = pruneRefs;
+  globalThis.__devBrowser_releaseOrphanRefs = releaseOrphanRefs;
+  globalThis.__devBrowser_resolveScope = resolveScope;
   globalThis.__devBrowser_drawRefOverlay = drawRefOverlay;
   globalThis.__devBrowser_clearRefOverlay = clearRefOverlay;
   globalThis.__devBrowser_inspectRef = inspectRef;
@@ -0,0 +1,325 @@
+package devbrowser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// budgetScanItems and budgetScanChars cap what a token-budgeted snapshot
+// collects before choosing what to show.
+const (
+	budgetScanItems = 1000
+	budgetScanChars = 200_000
+)
+
+// budgetNoteTokens is kept free for the closing "elided" line, and
+// MinTokenBudget leaves room for a few elements next to it.
+const (
+	budgetNoteTokens = 40
+	MinTokenBudget   = 60
+)
+
+// Rows of the same shape repeated at least repeatMinRows times are folded
+// into one summary line; a row is up to repeatMaxPeriod elements.
+const (
+	repeatMinRows   = 4
+	repeatMaxPeriod = 4
+)
+
+// Priorities of a budgeted snapshot: focused, then changed since the last
+// snapshot, then on screen, then interactive. A folded run ranks above a
+// lone on-screen element, since one line stands for many rows.
+const (
+	scoreActive      = 16
+	scoreChanged     = 8
+	scoreInViewport  = 4
+	scoreInteractive = 2
+	scoreRepeatRun   = 5
+)
+
+// SnapshotBudget reports what a token-budgeted snapshot left out. Token
+// counts are estimates (about four characters per token).
+type SnapshotBudget struct {
+	Budget     int             `json:"budget"`
+	Estimated  int             `json:"estimated_tokens"`
+	Full       int             `json:"full_tokens"`
+	Shown      int             `json:"shown"`
+	Elided     int             `json:"elided"`
+	ElidedRefs []string        `json:"elided_refs"`
+	Collapsed  []CollapsedRows `json:"collapsed,omitempty"`
+	// Truncated is set when the page had more than budgetScanItems
+	// elements; those past the scan are in neither Elided nor ElidedRefs.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// CollapsedRows is a run of repeated rows shown as one example row and a
+// summary line.
+type CollapsedRows struct {
+	Like  string `json:"like"`
+	Rows  int    `json:"rows"`
+	Items int    `json:"items"`
+}
+
+func estimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
+}
+
+// budgetUnit is what the budget keeps or drops as a whole: one element, or
+// a run's example row with its summary line.
+type budgetUnit struct {
+	pos    int
+	items  []map[string]interface{}
+	folded int // rows behind the summary line
+	foldN  int // elements behind the summary line
+	score  int
+	tokens int
+}
+
+// ApplyTokenBudget picks the elements to show within budget tokens when the
+// full snapshot does not fit: repeated rows are folded into summaries, then
+// elements are kept by priority and rendered in document order with a line
+// saying what was left out. fullText is the snapshot as the engine rendered
+// it, returned unchanged when it fits. truncated says the scan stopped at
+// budgetScanItems, so there were more elements than items.
+func ApplyTokenBudget(fullText string, items []map[string]interface{}, truncated bool, budget int) (string, []map[string]interface{}, SnapshotBudget) {
+	report := SnapshotBudget{Budget: budget, Full: estimateTokens(fullText), ElidedRefs: []string{}, Truncated: truncated}
+	if report.Full <= budget {
+		report.Estimated, report.Shown = report.Full, len(items)
+		return fullText, items, report
+	}
+
+	units := budgetUnits(items)
+	available := max(budget-budgetNoteTokens, 0)
+	used := 0
+	headings := map[string]bool{}
+	order := make([]int, len(units))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return units[order[a]].score > units[order[b]].score })
+	chosen := make([]bool, len(units))
+	for _, i := range order {
+		u := units[i]
+		cost := u.tokens
+		key := budgetHeadingKey(u.items[0])
+		if !headings[key] {
+			cost += budgetHeadingTokens(u.items[0])
+		}
+		if used+cost > available {
+			continue
+		}
+		used += cost
+		headings[key] = true
+		chosen[i] = true
+	}
+
+	kept := []map[string]interface{}{}
+	shown := []budgetUnit{}
+	offscreen := 0
+	for i, u := range units {
+		if !chosen[i] {
+			for _, item := range u.items {
+				report.ElidedRefs = append(report.ElidedRefs, snapshotRef(item))
+				if !truthy(item["inViewport"]) {
+					offscreen++
+				}
+			}
+			report.Elided += len(u.items) + u.foldN
+			continue
+		}
+		shown = append(shown, u)
+		kept = append(kept, u.items...)
+		if u.folded > 0 {
+			report.Collapsed = append(report.Collapsed, CollapsedRows{Like: snapshotRef(u.items[0]), Rows: u.folded, Items: u.foldN})
+		}
+	}
+	report.Shown = len(kept)
+
+	text := renderBudgetUnits(shown)
+	if report.Elided > 0 || truncated {
+		total := fmt.Sprintf("%d", len(items))
+		if truncated {
+			total += "+"
+		}
+		note := fmt.Sprintf("- [...] elided %d of %s elements to fit token_budget=%d", report.Elided, total, budget)
+		if offscreen > 0 {
+			note += fmt.Sprintf(" (%d off-screen)", offscreen)
+		}
+		if truncated {
+			note += fmt.Sprintf("; stopped scanning at %d", len(items))
+		}
+		note += "; use --within or a larger --token-budget"
+		if text != "" {
+			text += "\n"
+		}
+		text += note
+	}
+	report.Estimated = estimateTokens(text)
+	return text, kept, report
+}
+
+// markChanged flags items that are new or differ from the page's previous
+// snapshot, matched by ref.
+func markChanged(items, previous []map[string]interface{}) {
+	before := make(map[string]map[string]interface{}, len(previous))
+	for _, item := range previous {
+		before[snapshotRef(item)] = item
+	}
+	for _, item := range items {
+		prev, ok := before[snapshotRef(item)]
+		item["changed"] = !ok || len(compareDomFields(prev, item)) > 0
+	}
+}
+
+// budgetUnits turns items into units, folding runs of repeated rows. Rows
+// holding a focused or changed element stay out of the fold.
+func budgetUnits(items []map[string]interface{}) []budgetUnit {
+	units := []budgetUnit{}
+	for i := 0; i < len(items); {
+		period, rows := repeatRun(items, i)
+		if rows < repeatMinRows {
+			units = append(units, newBudgetUnit(i, items[i:i+1]))
+			i++
+			continue
+		}
+		run := newBudgetUnit(i, items[i:i+period])
+		for r := 1; r < rows; r++ {
+			row := items[i+r*period : i+(r+1)*period]
+			if rowIsNotable(row) {
+				for j := range row {
+					units = append(units, newBudgetUnit(i+r*period+j, row[j:j+1]))
+				}
+				continue
+			}
+			run.folded++
+			run.foldN += len(row)
+		}
+		run.score = max(run.score, scoreRepeatRun)
+		run.tokens += estimateTokens(repeatSummary(run))
+		units = append(units, run)
+		i += rows * period
+	}
+	sort.SliceStable(units, func(a, b int) bool { return units[a].pos < units[b].pos })
+	return units
+}
+
+// repeatRun finds the shortest row length at start that repeats, returning
+// it and how many rows in a row share its shape.
+func repeatRun(items []map[string]interface{}, start int) (int, int) {
+	for period := 1; period <= repeatMaxPeriod; period++ {
+		rows := 1
+		for next := start + period; next+period <= len(items); next += period {
+			same := true
+			for j := 0; j < period; j++ {
+				if budgetShape(items[start+j]) != budgetShape(items[next+j]) {
+					same = false
+					break
+				}
+			}
+			if !same {
+				break
+			}
+			rows++
+		}
+		if rows >= repeatMinRows {
+			return period, rows
+		}
+	}
+	return 1, 1
+}
+
+func budgetShape(item map[string]interface{}) string {
+	role, _ := item["role"].(string)
+	return role + "|" + budgetHeadingKey(item)
+}
+
+func rowIsNotable(row []map[string]interface{}) bool {
+	for _, item := range row {
+		if truthy(item["active"]) || truthy(item["changed"]) {
+			return true
+		}
+	}
+	return false
+}
+
+func newBudgetUnit(pos int, items []map[string]interface{}) budgetUnit {
+	u := budgetUnit{pos: pos, items: items}
+	for _, item := range items {
+		score := 0
+		if truthy(item["active"]) {
+			score += scoreActive
+		}
+		if truthy(item["changed"]) {
+			score += scoreChanged
+		}
+		if truthy(item["inViewport"]) {
+			score += scoreInViewport
+		}
+		if truthy(item["interactive"]) {
+			score += scoreInteractive
+		}
+		u.score = max(u.score, score)
+		u.tokens += estimateTokens("    - "+formatSnapshotItem(item)) + 1
+	}
+	return u
+}
+
+func repeatSummary(u budgetUnit) string {
+	return fmt.Sprintf("- [...] %d more rows like %s", u.folded, snapshotRef(u.items[0]))
+}
+
+func budgetHeadingKey(item map[string]interface{}) string {
+	frame, _ := item["frame"].(string)
+	heading, _ := item["heading"].(string)
+	return frame + "|" + heading
+}
+
+func budgetHeadingTokens(item map[string]interface{}) int {
+	heading, _ := item["heading"].(string)
+	frame, _ := item["frame"].(string)
+	tokens := 0
+	if heading != "" {
+		tokens += estimateTokens("  - heading "+jsonQuote(heading)) + 1
+	}
+	if frame != "" {
+		tokens += estimateTokens("- iframe [frame="+frame+"]") + 1
+	}
+	return tokens
+}
+
+// renderBudgetUnits writes units in the snapshot list style, grouped under
+// their iframe and heading.
+func renderBudgetUnits(units []budgetUnit) string {
+	lines := []string{}
+	frame, heading := "", ""
+	for _, u := range units {
+		for n, item := range u.items {
+			itemFrame, _ := item["frame"].(string)
+			itemHeading, _ := item["heading"].(string)
+			if itemFrame != frame {
+				if itemFrame != "" {
+					lines = append(lines, "- iframe [frame="+itemFrame+"]")
+				}
+				frame, heading = itemFrame, ""
+			}
+			indent := ""
+			if frame != "" {
+				indent = "  "
+			}
+			if itemHeading != "" && itemHeading != heading {
+				lines = append(lines, indent+"- heading "+jsonQuote(itemHeading))
+			}
+			heading = itemHeading
+			if heading != "" {
+				indent += "  "
+			}
+			lines = append(lines, indent+"- "+formatSnapshotItem(item))
+			if n == len(u.items)-1 && u.folded > 0 {
+				lines = append(lines, indent+repeatSummary(u))
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
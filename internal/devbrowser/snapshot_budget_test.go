@@ -0,0 +1,121 @@
+package devbrowser
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func budgetItem(ref, role, name, heading string, signals ...string) map[string]interface{} {
+	item := snapshotItem(ref, role, name, heading)
+	item["interactive"] = true
+	for _, s := range signals {
+		item[s] = true
+	}
+	return item
+}
+
+func TestApplyTokenBudget(t *testing.T) {
+	items := []map[string]interface{}{budgetItem("e1", "textbox", "Search", "", "active", "inViewport")}
+	for row := 0; row < 50; row++ {
+		var signals []string
+		if row < 3 {
+			signals = append(signals, "inViewport")
+		}
+		button := budgetItem(fmt.Sprintf("e%d", 3+2*row), "button", "Add", "Results", signals...)
+		if row == 30 {
+			button["changed"] = true
+		}
+		items = append(items, budgetItem(fmt.Sprintf("e%d", 2+2*row), "link", fmt.Sprintf("Item %d", row), "Results", signals...), button)
+	}
+	items = append(items, budgetItem("e102", "link", "Terms of service", "Footer"))
+	lines := make([]string, len(items))
+	for i, item := range items {
+		lines[i] = "- " + formatSnapshotItem(item)
+	}
+	full := strings.Join(lines, "\n")
+
+	if text, kept, report := ApplyTokenBudget(full, items, false, 10_000); text != full || len(kept) != len(items) || report.Elided != 0 {
+		t.Fatalf("a snapshot within budget should be unchanged: %+v", report)
+	}
+
+	text, kept, report := ApplyTokenBudget(full, items, false, 110)
+	want := `- textbox name="Search" [ref=e1] [active]
+- heading "Results"
+  - link name="Item 0" [ref=e2]
+  - button name="Add" [ref=e3]
+  - [...] 48 more rows like e2
+  - link name="Item 30" [ref=e62]
+  - button name="Add" [ref=e63]
+- [...] elided 1 of 102 elements to fit token_budget=110 (1 off-screen); use --within or a larger --token-budget`
+	if text != want {
+		t.Fatalf("budgeted text:\n%s\nwant:\n%s", text, want)
+	}
+	if len(kept) != 5 || report.Shown != 5 || report.Elided != 1 || !reflect.DeepEqual(report.ElidedRefs, []string{"e102"}) {
+		t.Fatalf("kept %d, report %+v", len(kept), report)
+	}
+	if !reflect.DeepEqual(report.Collapsed, []CollapsedRows{{Like: "e2", Rows: 48, Items: 96}}) {
+		t.Fatalf("collapsed = %+v", report.Collapsed)
+	}
+	if report.Estimated > report.Budget || report.Full <= report.Budget {
+		t.Fatalf("estimates: %+v", report)
+	}
+
+	// Squeezed further, the focused and changed elements outlast the rows.
+	text, _, report = ApplyTokenBudget(full, items, false, 70)
+	if !strings.Contains(text, "[ref=e1]") || !strings.Contains(text, "[ref=e63]") || strings.Contains(text, "more rows like") {
+		t.Fatalf("tight budget kept the wrong elements:\n%s", text)
+	}
+	if report.Elided != 100 {
+		t.Fatalf("elided = %d", report.Elided)
+	}
+}
+
+func TestApplyTokenBudgetReportsTruncatedScan(t *testing.T) {
+	items := []map[string]interface{}{}
+	lines := []string{}
+	for i := 1; i <= 30; i++ {
+		item := budgetItem(fmt.Sprintf("e%d", i), "link", fmt.Sprintf("Link %d", i), "")
+		items = append(items, item)
+		lines = append(lines, "- "+formatSnapshotItem(item))
+	}
+	text, _, report := ApplyTokenBudget(strings.Join(lines, "\n"), items, true, MinTokenBudget)
+	if !report.Truncated || !strings.Contains(text, "of 30+ elements") || !strings.Contains(text, "stopped scanning at 30") {
+		t.Fatalf("truncated scan not reported:\n%s\n%+v", text, report)
+	}
+	if report.Estimated > report.Budget {
+		t.Fatalf("estimated %d over budget %d", report.Estimated, report.Budget)
+	}
+}
+
+func TestMarkChanged(t *testing.T) {
+	previous := []map[string]interface{}{
+		budgetItem("e1", "button", "Save", ""),
+		budgetItem("e2", "checkbox", "Remember me", ""),
+	}
+	items := []map[string]interface{}{
+		budgetItem("e1", "button", "Save", ""),
+		budgetItem("e2", "checkbox", "Remember me", ""),
+		budgetItem("e3", "button", "Undo", ""),
+	}
+	items[1]["checked"] = true
+	markChanged(items, previous)
+	got := []bool{truthy(items[0]["changed"]), truthy(items[1]["changed"]), truthy(items[2]["changed"])}
+	if !reflect.DeepEqual(got, []bool{false, true, true}) {
+		t.Fatalf("changed = %v, want unchanged, changed, new", got)
+	}
+}
+
+func TestRepeatRun(t *testing.T) {
+	items := []map[string]interface{}{}
+	for i := 0; i < 5; i++ {
+		items = append(items, snapshotItem("", "cell", "", "Orders"), snapshotItem("", "cell", "", "Orders"), snapshotItem("", "button", "", "Orders"))
+	}
+	if period, rows := repeatRun(items, 0); period != 3 || rows != 5 {
+		t.Fatalf("period=%d rows=%d", period, rows)
+	}
+	if _, rows := repeatRun(items[:9], 0); rows >= repeatMinRows {
+		t.Fatalf("three rows should not fold, got %d", rows)
+	}
+}
@@ -0,0 +1,382 @@
+package devbrowser
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// SnapshotDiff is what `snapshot --diff` returns in place of the full item
+// list: refs added, removed and changed since the page's previous snapshot.
+// Baseline is set when there was nothing comparable to diff against and the
+// full snapshot was returned instead. When max_items cut the current list
+// short, refs missing from it are Unlisted, not Removed: they may still be on
+// the page. When it cut the previous list short, some Added refs may only
+// have been past it.
+type SnapshotDiff struct {
+	Baseline       bool             `json:"baseline,omitempty"`
+	Reason         string           `json:"reason,omitempty"`
+	Added          []string         `json:"added"`
+	Removed        []string         `json:"removed"`
+	Changed        []SnapshotChange `json:"changed"`
+	Unlisted       []string         `json:"unlisted,omitempty"`
+	PreviousCapped bool             `json:"previous_capped,omitempty"`
+	CurrentCapped  bool             `json:"current_capped,omitempty"`
+}
+
+type SnapshotChange struct {
+	Ref    string                 `json:"ref"`
+	Fields []string               `json:"fields"`
+	Before map[string]interface{} `json:"before"`
+}
+
+// snapshotBaseline is the last snapshot taken of a page. Refs only identify
+// the same element within one document, so a diff needs the same document
+// and the same options that decide which elements are listed.
+type snapshotBaseline struct {
+	url      string
+	document string
+	options  string
+	items    []map[string]interface{}
+	capped   bool
+}
+
+// sameDocument reports whether refs of a and b name the same elements. The
+// snapshot script's document id changes on navigation and reload but not on
+// pushState or hash changes; a document not snapshotted yet has none. Only
+// when neither side has an id (results recorded without one) are origin and
+// path compared instead.
+func sameDocument(a, b snapshotBaseline) bool {
+	if a.document != "" || b.document != "" {
+		return a.document == b.document
+	}
+	return documentURL(a.url) == documentURL(b.url)
+}
+
+// documentURL drops the query and fragment from a page URL.
+func documentURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.RawQuery, u.Fragment, u.RawFragment = "", "", ""
+	return u.String()
+}
+
+// snapshotStore keeps the last snapshot per page key.
+type snapshotStore struct {
+	mu   sync.Mutex
+	last map[string]snapshotBaseline
+}
+
+func newSnapshotStore() *snapshotStore {
+	return &snapshotStore{last: make(map[string]snapshotBaseline)}
+}
+
+// swap records next as the page's snapshot and returns the previous one.
+func (s *snapshotStore) swap(key string, next snapshotBaseline) (snapshotBaseline, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.last[key]
+	s.last[key] = next
+	return prev, ok
+}
+
+func (s *snapshotStore) get(key string) (snapshotBaseline, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.last[key]
+	return prev, ok
+}
+
+func (s *snapshotStore) clear(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.last, key)
+}
+
+func (s *snapshotStore) clearAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last = make(map[string]snapshotBaseline)
+}
+
+// SnapshotPage runs the snapshot tool on one of the daemon's pages and
+// records the result like RecordSnapshot. A token-budgeted snapshot ranks
+// elements added or changed since the page's previous snapshot (same document
+// and options) first.
+func (b *BrowserHost) SnapshotPage(contextName, name string, page playwright.Page, args map[string]interface{}) (RunResult, error) {
+	key := pageLogKey(contextName, name)
+	var previous []map[string]interface{}
+	current := snapshotBaseline{url: page.URL(), document: currentDocument(page), options: snapshotDiffOptions(args)}
+	if prev, ok := b.snapshots.get(key); ok && sameDocument(prev, current) && prev.options == current.options {
+		previous = prev.items
+		if previous == nil {
+			previous = []map[string]interface{}{}
+		}
+	}
+	res, scanned, err := runSnapshot(page, args, previous)
+	if err != nil {
+		return nil, err
+	}
+	return b.recordSnapshot(key, args, res, scanned)
+}
+
+// currentDocument returns the document id of the page's ref registry, or ""
+// before any snapshot of this document.
+func currentDocument(page playwright.Page) string {
+	raw, err := page.Evaluate(`() => (globalThis.__devBrowserRefRegistry && globalThis.__devBrowserRefRegistry.id) || ""`)
+	if err != nil {
+		return ""
+	}
+	id, _ := raw.(string)
+	return id
+}
+
+// RecordSnapshot remembers a snapshot result as the page's latest. When args
+// ask for "diff" it returns the result rewritten to the changes since the
+// previous snapshot; otherwise res is returned unchanged.
+func (b *BrowserHost) RecordSnapshot(contextName, name string, args map[string]interface{}, res RunResult) (RunResult, error) {
+	items, _ := res["items"].([]map[string]interface{})
+	return b.recordSnapshot(pageLogKey(contextName, name), args, res, items)
+}
+
+// recordSnapshot keeps scanned, every element the snapshot looked at, as the
+// baseline for the next one.
+func (b *BrowserHost) recordSnapshot(key string, args map[string]interface{}, res RunResult, scanned []map[string]interface{}) (RunResult, error) {
+	diff, err := optionalBool(args, "diff", false)
+	if err != nil {
+		return nil, err
+	}
+	maxChars, err := optionalInt(args, "max_chars", 8000)
+	if err != nil {
+		return nil, err
+	}
+	items, _ := res["items"].([]map[string]interface{})
+	pageURL, _ := res["url"].(string)
+	document, _ := res["document"].(string)
+	capped, _ := res["truncated"].(bool)
+	next := snapshotBaseline{url: pageURL, document: document, options: snapshotDiffOptions(args), items: scanned, capped: capped}
+	prev, ok := b.snapshots.swap(key, next)
+	if !diff {
+		return res, nil
+	}
+
+	reason := ""
+	switch {
+	case !ok:
+		reason = "no previous snapshot"
+	case !sameDocument(prev, next):
+		reason = "navigated to another document"
+	case prev.options != next.options:
+		reason = "snapshot options changed"
+	}
+	if reason != "" {
+		res["diff"] = SnapshotDiff{Baseline: true, Reason: reason, Added: []string{}, Removed: []string{}, Changed: []SnapshotChange{}}
+		return res, nil
+	}
+
+	d, current := DiffSnapshotItems(prev.items, items, next.capped)
+	d.PreviousCapped = prev.capped
+	res["snapshot"] = FormatSnapshotDiff(prev.items, items, d, maxChars)
+	res["items"] = current
+	res["diff"] = d
+	return res, nil
+}
+
+// snapshotDiffOptions identifies the arguments that decide which elements a
+// snapshot lists, with RunCall's defaults; "format" and "max_chars" only
+// change the rendering.
+func snapshotDiffOptions(args map[string]interface{}) string {
+	engine, _ := optionalString(args, "engine", "simple")
+	interactiveOnly, _ := optionalBool(args, "interactive_only", true)
+	includeHeadings, _ := optionalBool(args, "include_headings", true)
+	maxItems, _ := optionalInt(args, "max_items", 80)
+	within, _ := optionalString(args, "within", "")
+	maxDepth, _ := optionalInt(args, "max_depth", 0)
+	tokenBudget, _ := optionalInt(args, "token_budget", 0)
+	return fmt.Sprintf("engine=%s interactive_only=%t include_headings=%t max_items=%d within=%q max_depth=%d token_budget=%d",
+		engine, interactiveOnly, includeHeadings, maxItems, strings.TrimSpace(within), maxDepth, tokenBudget)
+}
+
+// DiffSnapshotItems compares two snapshots by ref. It also returns the
+// current items that were added or changed, in document order. With
+// afterCapped, refs missing from after are Unlisted rather than Removed.
+func DiffSnapshotItems(before, after []map[string]interface{}, afterCapped bool) (SnapshotDiff, []map[string]interface{}) {
+	beforeByRef := make(map[string]map[string]interface{}, len(before))
+	for _, item := range before {
+		beforeByRef[snapshotRef(item)] = item
+	}
+	afterRefs := make(map[string]bool, len(after))
+
+	d := SnapshotDiff{Added: []string{}, Removed: []string{}, Changed: []SnapshotChange{}, CurrentCapped: afterCapped}
+	current := []map[string]interface{}{}
+	for _, item := range after {
+		ref := snapshotRef(item)
+		afterRefs[ref] = true
+		prev, ok := beforeByRef[ref]
+		if !ok {
+			d.Added = append(d.Added, ref)
+			current = append(current, item)
+			continue
+		}
+		if fields := compareDomFields(prev, item); len(fields) > 0 {
+			was := make(map[string]interface{}, len(fields))
+			for _, f := range fields {
+				was[f] = prev[f]
+			}
+			d.Changed = append(d.Changed, SnapshotChange{Ref: ref, Fields: fields, Before: was})
+			current = append(current, item)
+		}
+	}
+	for _, item := range before {
+		ref := snapshotRef(item)
+		switch {
+		case afterRefs[ref]:
+		case afterCapped:
+			d.Unlisted = append(d.Unlisted, ref)
+		default:
+			d.Removed = append(d.Removed, ref)
+		}
+	}
+	return d, current
+}
+
+// FormatSnapshotDiff renders a diff in the list style of the snapshot itself:
+// sections of added, removed and changed refs, grouped under their headings.
+// Changed lines end with the previous values of the fields that changed.
+func FormatSnapshotDiff(before, after []map[string]interface{}, d SnapshotDiff, maxChars int) string {
+	notes := []string{}
+	if d.CurrentCapped {
+		notes = append(notes, "- [...] this snapshot hit max_items; refs past it are not compared")
+	}
+	if d.PreviousCapped {
+		notes = append(notes, "- [...] the previous snapshot hit max_items; some added refs may only have been past it")
+	}
+	if len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 && len(d.Unlisted) == 0 {
+		return strings.Join(append([]string{"no changes"}, notes...), "\n")
+	}
+	afterByRef := make(map[string]map[string]interface{}, len(after))
+	for _, item := range after {
+		afterByRef[snapshotRef(item)] = item
+	}
+	beforeByRef := make(map[string]map[string]interface{}, len(before))
+	for _, item := range before {
+		beforeByRef[snapshotRef(item)] = item
+	}
+
+	lines := []string{}
+	section := func(label string, refs []string, byRef map[string]map[string]interface{}, note func(i int) string) {
+		if len(refs) == 0 {
+			return
+		}
+		lines = append(lines, label+":")
+		heading := ""
+		for i, ref := range refs {
+			item := byRef[ref]
+			h, _ := item["heading"].(string)
+			if h != "" && h != heading {
+				lines = append(lines, "- heading "+jsonQuote(h))
+			}
+			heading = h
+			indent := ""
+			if heading != "" {
+				indent = "  "
+			}
+			lines = append(lines, indent+"- "+formatSnapshotItem(item)+note(i))
+		}
+	}
+	none := func(int) string { return "" }
+	section("added", d.Added, afterByRef, none)
+	section("removed", d.Removed, beforeByRef, none)
+	changed := make([]string, len(d.Changed))
+	for i, c := range d.Changed {
+		changed[i] = c.Ref
+	}
+	section("changed", changed, afterByRef, func(i int) string {
+		c := d.Changed[i]
+		was := make([]string, 0, len(c.Fields))
+		for _, f := range c.Fields {
+			was = append(was, f+"="+formatSnapshotValue(c.Before[f]))
+		}
+		return " (was " + strings.Join(was, " ") + ")"
+	})
+	section("not listed (past max_items, may still be on the page)", d.Unlisted, beforeByRef, none)
+	lines = append(lines, notes...)
+
+	return truncateSnapshotText(strings.Join(lines, "\n"), maxChars)
+}
+
+// formatSnapshotItem renders one item the way buildYaml in base_snapshot.js
+// does, without the leading "- " and indent.
+func formatSnapshotItem(item map[string]interface{}) string {
+	var sb strings.Builder
+	role, _ := item["role"].(string)
+	sb.WriteString(role)
+	if name, _ := item["name"].(string); name != "" {
+		sb.WriteString(" name=" + jsonQuote(name))
+	}
+	sb.WriteString(" [ref=" + snapshotRef(item) + "]")
+	if truthy(item["disabled"]) {
+		sb.WriteString(" [disabled]")
+	}
+	switch item["checked"] {
+	case "mixed":
+		sb.WriteString(" [checked=mixed]")
+	case true:
+		sb.WriteString(" [checked]")
+	}
+	if truthy(item["expanded"]) {
+		sb.WriteString(" [expanded]")
+	}
+	if truthy(item["selected"]) {
+		sb.WriteString(" [selected]")
+	}
+	switch item["pressed"] {
+	case "mixed":
+		sb.WriteString(" [pressed=mixed]")
+	case true:
+		sb.WriteString(" [pressed]")
+	}
+	if truthy(item["active"]) {
+		sb.WriteString(" [active]")
+	}
+	if truthy(item["cursorPointer"]) {
+		sb.WriteString(" [cursor=pointer]")
+	}
+	return sb.String()
+}
+
+func formatSnapshotValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "none"
+	case string:
+		return jsonQuote(val)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+func snapshotRef(item map[string]interface{}) string {
+	ref, _ := item["ref"].(string)
+	return ref
+}
+
+func truthy(v interface{}) bool {
+	b, _ := v.(bool)
+	return b
+}
+
+// jsonQuote matches JSON.stringify, which leaves <, > and & alone.
+func jsonQuote(s string) string {
+	var sb strings.Builder
+	enc := json.NewEncoder(&sb)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(sb.String(), "\n")
+}
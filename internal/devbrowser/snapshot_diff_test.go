@@ -0,0 +1,151 @@
+package devbrowser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func snapshotItem(ref, role, name, heading string) map[string]interface{} {
+	return map[string]interface{}{
+		"ref": ref, "role": role, "name": name, "heading": heading,
+		"disabled": false, "checked": nil, "expanded": false, "selected": false, "pressed": nil, "active": false,
+	}
+}
+
+func TestDiffSnapshotItems(t *testing.T) {
+	checked := snapshotItem("e4", "checkbox", "Gift wrap", "Cart")
+	checked["checked"] = true
+	before := []map[string]interface{}{
+		snapshotItem("e3", "button", "Add to cart", ""),
+		snapshotItem("e4", "checkbox", "Gift wrap", "Cart"),
+		snapshotItem("e5", "link", "Home", "Cart"),
+	}
+	after := []map[string]interface{}{
+		checked,
+		snapshotItem("e5", "link", "Home", "Cart"),
+		snapshotItem("e9", "button", "Checkout", "Cart"),
+	}
+
+	d, current := DiffSnapshotItems(before, after, false)
+	if !reflect.DeepEqual(d.Added, []string{"e9"}) || !reflect.DeepEqual(d.Removed, []string{"e3"}) {
+		t.Fatalf("added=%v removed=%v", d.Added, d.Removed)
+	}
+	if len(d.Changed) != 1 || d.Changed[0].Ref != "e4" || !reflect.DeepEqual(d.Changed[0].Fields, []string{"checked"}) {
+		t.Fatalf("changed=%+v", d.Changed)
+	}
+	if len(current) != 2 || current[0]["ref"] != "e4" || current[1]["ref"] != "e9" {
+		t.Fatalf("current items not in document order: %v", current)
+	}
+
+	want := `added:
+- heading "Cart"
+  - button name="Checkout" [ref=e9]
+removed:
+- button name="Add to cart" [ref=e3]
+changed:
+- heading "Cart"
+  - checkbox name="Gift wrap" [ref=e4] [checked] (was checked=none)`
+	if got := FormatSnapshotDiff(before, after, d, 8000); got != want {
+		t.Fatalf("diff text:\n%s\nwant:\n%s", got, want)
+	}
+	if got := FormatSnapshotDiff(after, after, SnapshotDiff{}, 8000); got != "no changes" {
+		t.Fatalf("unchanged = %q", got)
+	}
+
+	// A capped current list cannot tell removed refs from ones past the cap.
+	d, _ = DiffSnapshotItems(before, after, true)
+	if len(d.Removed) != 0 || !reflect.DeepEqual(d.Unlisted, []string{"e3"}) || !d.CurrentCapped {
+		t.Fatalf("capped diff = %+v", d)
+	}
+	d.PreviousCapped = true
+	got := FormatSnapshotDiff(before, after, d, 8000)
+	for _, want := range []string{
+		"not listed (past max_items, may still be on the page):\n- button name=\"Add to cart\" [ref=e3]",
+		"- [...] this snapshot hit max_items",
+		"- [...] the previous snapshot hit max_items",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("capped diff text missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "removed:") {
+		t.Fatalf("capped diff should not report removals:\n%s", got)
+	}
+}
+
+func TestRecordSnapshotDiff(t *testing.T) {
+	host := NewBrowserHost("default", BrowserChromium, true, 0, nil, "")
+	snapshot := func(url string, args map[string]interface{}, items ...map[string]interface{}) RunResult {
+		res, err := host.RecordSnapshot("", "main", args, RunResult{"url": url, "snapshot": "full", "items": items})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	diffArgs := map[string]interface{}{"diff": true}
+
+	res := snapshot("http://x/", diffArgs, snapshotItem("e1", "button", "A", ""))
+	if d := res["diff"].(SnapshotDiff); !d.Baseline || d.Reason != "no previous snapshot" || res["snapshot"] != "full" {
+		t.Fatalf("first diff should be a baseline: %v", res)
+	}
+
+	// A plain snapshot updates the baseline too.
+	snapshot("http://x/", nil, snapshotItem("e1", "button", "A", ""), snapshotItem("e2", "link", "B", ""))
+	res = snapshot("http://x/", diffArgs, snapshotItem("e1", "button", "A", ""))
+	if d := res["diff"].(SnapshotDiff); d.Baseline || !reflect.DeepEqual(d.Removed, []string{"e2"}) {
+		t.Fatalf("diff = %+v", res["diff"])
+	}
+	if res["snapshot"] != "removed:\n- link name=\"B\" [ref=e2]" {
+		t.Fatalf("snapshot = %q", res["snapshot"])
+	}
+
+	// Without document ids, a query or fragment change is the same document.
+	res = snapshot("http://x/?tab=2#top", diffArgs, snapshotItem("e1", "button", "A", ""))
+	if d := res["diff"].(SnapshotDiff); d.Baseline {
+		t.Fatalf("a same-document URL change should keep the baseline: %+v", d)
+	}
+	res = snapshot("http://x/other", diffArgs, snapshotItem("e1", "button", "A", ""))
+	if d := res["diff"].(SnapshotDiff); !d.Baseline || d.Reason != "navigated to another document" {
+		t.Fatalf("navigation should reset the baseline: %+v", d)
+	}
+	res = snapshot("http://x/other", map[string]interface{}{"diff": true, "engine": "aria"}, snapshotItem("e1", "button", "A", ""))
+	if d := res["diff"].(SnapshotDiff); !d.Baseline || d.Reason != "snapshot options changed" {
+		t.Fatalf("option change should reset the baseline: %+v", d)
+	}
+
+	res = snapshot("http://x/other", map[string]interface{}{"diff": true, "engine": "aria", "within": "#dialog"}, snapshotItem("e1", "button", "A", ""))
+	if d := res["diff"].(SnapshotDiff); !d.Baseline || d.Reason != "snapshot options changed" {
+		t.Fatalf("a different scope should reset the baseline: %+v", d)
+	}
+
+	host.clearPageLogs(pageLogKey("", "main"))
+	res = snapshot("http://x/other", map[string]interface{}{"diff": true, "engine": "aria", "within": "#dialog"}, snapshotItem("e1", "button", "A", ""))
+	if d := res["diff"].(SnapshotDiff); !d.Baseline {
+		t.Fatalf("closing the page should forget its snapshot: %+v", d)
+	}
+}
+
+func TestRecordSnapshotDiffFollowsDocument(t *testing.T) {
+	host := NewBrowserHost("default", BrowserChromium, true, 0, nil, "")
+	snapshot := func(url, document string, capped bool, items ...map[string]interface{}) SnapshotDiff {
+		res, err := host.RecordSnapshot("", "main", map[string]interface{}{"diff": true}, RunResult{"url": url, "document": document, "truncated": capped, "snapshot": "full", "items": items})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res["diff"].(SnapshotDiff)
+	}
+
+	snapshot("http://x/", "doc1", false, snapshotItem("e1", "button", "A", ""))
+	// pushState to another path keeps the document and its refs.
+	if d := snapshot("http://x/settings", "doc1", false, snapshotItem("e1", "button", "A", "")); d.Baseline {
+		t.Fatalf("a route change in the same document should diff: %+v", d)
+	}
+	// A reload at the same URL is a new document.
+	if d := snapshot("http://x/settings", "doc2", true, snapshotItem("e1", "button", "A", "")); !d.Baseline || d.Reason != "navigated to another document" {
+		t.Fatalf("a reload should reset the baseline: %+v", d)
+	}
+	if d := snapshot("http://x/settings", "doc2", false); !d.PreviousCapped || d.CurrentCapped || !reflect.DeepEqual(d.Removed, []string{"e1"}) {
+		t.Fatalf("diff after a capped snapshot = %+v", d)
+	}
+}
@@ -0,0 +1,233 @@
+package devbrowser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Refs inside iframes are qualified with the frame's id, as in "f1:e3". Each
+// frame document keeps its own refs (see base_snapshot.js) and its id in
+// __devBrowserFrameId, so a frame that navigates gets a new id and refs into
+// its old document stop resolving instead of hitting the new one.
+
+// jsEvaluator is what the snapshot helpers need from a page or a frame.
+type jsEvaluator interface {
+	Evaluate(expression string, arg ...interface{}) (interface{}, error)
+	EvaluateHandle(expression string, arg ...interface{}) (playwright.JSHandle, error)
+}
+
+var (
+	frameRefRe = regexp.MustCompile(`^(f\d+):(.+)$`)
+	frameIDRe  = regexp.MustCompile(`^f\d+$`)
+	yamlRefRe  = regexp.MustCompile(`\[ref=([^\]]+)\]`)
+)
+
+// Defaults of the snapshot script, for budgets enforced on the Go side.
+const (
+	defaultSnapshotItems = 80
+	defaultSnapshotChars = 8000
+)
+
+// splitFrameRef splits "f1:e3" into ("f1", "e3"). Refs in the top document
+// come back unchanged with ok false.
+func splitFrameRef(ref string) (frameID, local string, ok bool) {
+	m := frameRefRe.FindStringSubmatch(strings.TrimSpace(ref))
+	if m == nil {
+		return "", ref, false
+	}
+	return m[1], m[2], true
+}
+
+// resolveRefTarget returns the page or frame a ref lives in and the ref
+// local to that document.
+func resolveRefTarget(page playwright.Page, ref string) (jsEvaluator, string, string, error) {
+	id, local, ok := splitFrameRef(ref)
+	if !ok {
+		return page, ref, "", nil
+	}
+	frame, err := findFrame(page, id)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("ref %s: %w", ref, err)
+	}
+	return frame, local, id, nil
+}
+
+// qualifyRef turns a frame-local ref back into the page-wide form.
+func qualifyRef(frameID, ref string) string {
+	if frameID == "" || ref == "" {
+		return ref
+	}
+	return frameID + ":" + ref
+}
+
+func findFrame(page playwright.Page, id string) (playwright.Frame, error) {
+	for _, f := range snapshotFrames(page) {
+		if f.id == id {
+			return f.frame, nil
+		}
+	}
+	return nil, fmt.Errorf("frame %s not found (it navigated or was removed); call snapshot again", id)
+}
+
+type snapshotFrame struct {
+	frame playwright.Frame
+	id    string
+}
+
+// snapshotFrames lists the page's iframes that a snapshot has given an id.
+func snapshotFrames(page playwright.Page) []snapshotFrame {
+	main := page.MainFrame()
+	out := []snapshotFrame{}
+	for _, frame := range page.Frames() {
+		if frame == main || frame.IsDetached() {
+			continue
+		}
+		raw, err := frame.Evaluate(`() => globalThis.__devBrowserFrameId || ""`)
+		if err != nil {
+			continue
+		}
+		if id, _ := raw.(string); id != "" {
+			out = append(out, snapshotFrame{frame: frame, id: id})
+		}
+	}
+	return out
+}
+
+// frameID returns a child frame's id, taking the next one from a counter in
+// the top document when the frame has none yet.
+func frameID(page playwright.Page, frame playwright.Frame) (string, error) {
+	raw, err := frame.Evaluate(`() => globalThis.__devBrowserFrameId || ""`)
+	if err != nil {
+		return "", err
+	}
+	if id, _ := raw.(string); id != "" {
+		return id, nil
+	}
+	raw, err = page.Evaluate(`() => "f" + (globalThis.__devBrowserFrameCounter = (globalThis.__devBrowserFrameCounter || 0) + 1)`)
+	if err != nil {
+		return "", err
+	}
+	next, _ := raw.(string)
+	raw, err = frame.Evaluate(`(id) => (globalThis.__devBrowserFrameId = globalThis.__devBrowserFrameId || id)`, next)
+	if err != nil {
+		return "", err
+	}
+	id, _ := raw.(string)
+	return id, nil
+}
+
+// frameVisible skips iframes nobody can see (trackers, hidden widgets). A
+// frame whose element cannot be checked counts as visible.
+func frameVisible(frame playwright.Frame) bool {
+	el, err := frame.FrameElement()
+	if err != nil {
+		return true
+	}
+	defer el.Dispose()
+	visible, err := el.IsVisible()
+	return err != nil || visible
+}
+
+// frameScopedSnapshot serves --within f1 (a whole frame) and --within f1:e3
+// (an element inside one).
+func frameScopedSnapshot(page playwright.Page, within string, opts SnapshotOptions) (*SnapshotResult, bool, error) {
+	id, local := strings.TrimSpace(within), ""
+	if !frameIDRe.MatchString(id) {
+		var ok bool
+		if id, local, ok = splitFrameRef(within); !ok {
+			return nil, false, nil
+		}
+	}
+	frame, err := findFrame(page, id)
+	if err != nil {
+		return nil, true, err
+	}
+	opts.Within = local
+	snap, err := snapshotIn(frame, opts)
+	if err != nil {
+		return nil, true, err
+	}
+	qualifySnapshot(snap, id)
+	return snap, true, nil
+}
+
+// appendFrameSnapshots adds a section per visible iframe below the top
+// document's snapshot, nested like the frames and sharing its item and char
+// budget.
+func appendFrameSnapshots(page playwright.Page, top *SnapshotResult, opts SnapshotOptions) *SnapshotResult {
+	maxItems, maxChars := opts.MaxItems, opts.MaxChars
+	if maxItems <= 0 {
+		maxItems = defaultSnapshotItems
+	}
+	if maxChars <= 0 {
+		maxChars = defaultSnapshotChars
+	}
+	lines := []string{}
+	if top.Yaml != "" {
+		lines = append(lines, top.Yaml)
+	}
+	items := top.Items
+	truncated := top.Truncated
+	skipped := 0
+
+	var walk func(parent playwright.Frame, depth int)
+	walk = func(parent playwright.Frame, depth int) {
+		for _, frame := range parent.ChildFrames() {
+			if frame.IsDetached() || !frameVisible(frame) {
+				continue
+			}
+			remaining := maxItems - len(items)
+			if remaining <= 0 {
+				truncated = true
+				skipped++
+				continue
+			}
+			indent := strings.Repeat("  ", depth)
+			header := indent + "- iframe"
+			if name := frame.Name(); name != "" {
+				header += " name=" + jsonQuote(name)
+			}
+			id, err := frameID(page, frame)
+			var sub *SnapshotResult
+			if err == nil {
+				frameOpts := opts
+				frameOpts.MaxItems, frameOpts.MaxChars, frameOpts.Within = remaining, maxChars, ""
+				sub, err = snapshotIn(frame, frameOpts)
+			}
+			if err != nil {
+				lines = append(lines, header+" [url="+frame.URL()+"] [unavailable]")
+				continue
+			}
+			qualifySnapshot(sub, id)
+			lines = append(lines, header+" [frame="+id+"] [url="+frame.URL()+"]")
+			if sub.Yaml != "" {
+				for _, line := range strings.Split(sub.Yaml, "\n") {
+					lines = append(lines, indent+"  "+line)
+				}
+			}
+			items = append(items, sub.Items...)
+			truncated = truncated || sub.Truncated
+			walk(frame, depth+1)
+		}
+	}
+	walk(page.MainFrame(), 0)
+	if skipped > 0 {
+		lines = append(lines, fmt.Sprintf("- [...] %d iframes skipped (max_items=%d)", skipped, maxItems))
+	}
+	return &SnapshotResult{Yaml: truncateSnapshotText(strings.Join(lines, "\n"), maxChars), Items: items, Truncated: truncated, Document: top.Document}
+}
+
+// qualifySnapshot rewrites a frame's refs to the "f1:e3" form and tags its
+// items with the frame id.
+func qualifySnapshot(snap *SnapshotResult, id string) {
+	for _, item := range snap.Items {
+		if ref, _ := item["ref"].(string); ref != "" {
+			item["ref"] = qualifyRef(id, ref)
+		}
+		item["frame"] = id
+	}
+	snap.Yaml = yamlRefRe.ReplaceAllString(snap.Yaml, "[ref="+id+":$1]")
+}
@@ -0,0 +1,83 @@
+package devbrowser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStaleRefError(t *testing.T) {
+	stale := &StaleRefError{Ref: "e5", Role: "button", Name: "Save", Suggestion: "e12", message: `stale ref e5: button "Save" is no longer on the page; likely replacement: e12 (button "Save"); call snapshot again for current refs`}
+	err := fmt.Errorf("click_ref: %w", stale)
+	if !errors.Is(err, ErrStaleRef) {
+		t.Fatal("expected errors.Is(err, ErrStaleRef)")
+	}
+	var got *StaleRefError
+	if !errors.As(err, &got) || got.Suggestion != "e12" {
+		t.Fatalf("errors.As = %+v", got)
+	}
+	if !strings.Contains(err.Error(), "likely replacement: e12") {
+		t.Fatalf("message = %q", err.Error())
+	}
+}
+
+func TestAriaEngineSharesRefRegistry(t *testing.T) {
+	// Both engines must hand out refs from one registry, or the same ref
+	// would name different elements depending on the engine.
+	if !strings.Contains(baseScript(), "globalThis.__devBrowser_ensureRef = ensureRef") {
+		t.Fatal("base snapshot script does not export ensureRef")
+	}
+	if !strings.Contains(ariaScript(), "globalThis.__devBrowser_ensureRef(ariaNode.element") {
+		t.Fatal("aria engine does not assign refs through __devBrowser_ensureRef")
+	}
+	// Both prune detached refs before walking, so neither lets them pile up.
+	if !strings.Contains(ariaScript(), "globalThis.__devBrowser_pruneRefs();") {
+		t.Fatal("aria engine does not prune the ref registry")
+	}
+}
+
+func TestSplitFrameRef(t *testing.T) {
+	cases := []struct {
+		ref, frame, local string
+		ok                bool
+	}{
+		{"f1:e3", "f1", "e3", true},
+		{" f12:e40 ", "f12", "e40", true},
+		{"e3", "", "e3", false},
+		{"f1", "", "f1", false},
+		{"x1:e3", "", "x1:e3", false},
+	}
+	for _, tc := range cases {
+		frame, local, ok := splitFrameRef(tc.ref)
+		if frame != tc.frame || local != tc.local || ok != tc.ok {
+			t.Fatalf("splitFrameRef(%q) = %q, %q, %v", tc.ref, frame, local, ok)
+		}
+	}
+}
+
+func TestQualifySnapshot(t *testing.T) {
+	snap := &SnapshotResult{
+		Yaml:  "- heading \"Pay\"\n  - textbox name=\"Card number\" [ref=e1]\n  - button name=\"Pay\" [ref=e2] [disabled]",
+		Items: []map[string]interface{}{{"ref": "e1"}, {"ref": "e2"}},
+	}
+	qualifySnapshot(snap, "f2")
+	want := "- heading \"Pay\"\n  - textbox name=\"Card number\" [ref=f2:e1]\n  - button name=\"Pay\" [ref=f2:e2] [disabled]"
+	if snap.Yaml != want {
+		t.Fatalf("yaml = %q", snap.Yaml)
+	}
+	if snap.Items[1]["ref"] != "f2:e2" || snap.Items[1]["frame"] != "f2" {
+		t.Fatalf("items = %v", snap.Items)
+	}
+}
+
+func TestTruncateSnapshotText(t *testing.T) {
+	if got := truncateSnapshotText("short", 100); got != "short" {
+		t.Fatalf("got %q", got)
+	}
+	long := strings.Repeat("é", 100)
+	got := truncateSnapshotText(long, 50)
+	if !strings.HasPrefix(got, strings.Repeat("é", 10)+"\n") || !strings.HasSuffix(got, "- [...] truncated (max_chars=50)") {
+		t.Fatalf("got %q", got)
+	}
+}
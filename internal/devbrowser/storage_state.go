@@ -0,0 +1,277 @@
+package devbrowser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const storageStateVersion = 1
+
+// StorageStateFile is the on-disk format for `state save/load`. Cookies and
+// origins use Playwright's storageState shape, so the file also works with
+// `browser.newContext({ storageState })`; sessionStorage is an extension
+// keyed by page name because it is scoped to a tab.
+type StorageStateFile struct {
+	Version        int                      `json:"version"`
+	SavedMS        int64                    `json:"saved_ms,omitempty"`
+	Cookies        []playwright.Cookie      `json:"cookies"`
+	Origins        []playwright.Origin      `json:"origins"`
+	SessionStorage []SessionStorageSnapshot `json:"sessionStorage,omitempty"`
+}
+
+type SessionStorageSnapshot struct {
+	Page   string                 `json:"page"`
+	Origin string                 `json:"origin"`
+	Items  []playwright.NameValue `json:"items"`
+}
+
+type StorageStateSummary struct {
+	Path           string   `json:"path,omitempty"`
+	Cookies        int      `json:"cookies"`
+	Origins        int      `json:"origins"`
+	SessionStorage int      `json:"session_storage"`
+	Pending        []string `json:"pending,omitempty"`
+	Skipped        []string `json:"skipped,omitempty"`
+}
+
+func (s StorageStateFile) Summary() StorageStateSummary {
+	return StorageStateSummary{
+		Cookies:        len(s.Cookies),
+		Origins:        len(s.Origins),
+		SessionStorage: len(s.SessionStorage),
+	}
+}
+
+const sessionStorageReadJS = `() => {
+  try {
+    const items = [];
+    for (let i = 0; i < sessionStorage.length; i++) {
+      const name = sessionStorage.key(i);
+      if (name.startsWith("__devBrowserStateLoaded:")) continue;
+      items.push({ name, value: sessionStorage.getItem(name) });
+    }
+    return { origin: location.origin, items };
+  } catch (e) {
+    return { origin: "null", items: [] };
+  }
+}`
+
+const storageWriteJS = `([kind, items]) => {
+  const store = kind === "session" ? sessionStorage : localStorage;
+  for (const { name, value } of items) store.setItem(name, value);
+  return items.length;
+}`
+
+// sessionSeedJS is an init script that writes saved sessionStorage the first
+// time the tab loads the saved origin. The marker key keeps later reloads
+// from writing over what the site changed since.
+const sessionSeedJS = `(([origin, marker, items]) => {
+  try {
+    if (location.origin !== origin || sessionStorage.getItem(marker) !== null) return;
+    sessionStorage.setItem(marker, "1");
+    for (const { name, value } of items) sessionStorage.setItem(name, value);
+  } catch (e) {}
+})(%s);`
+
+// stubOriginHTML is served for origins visited while restoring storage, so
+// loading state never touches the network.
+const stubOriginHTML = "<!doctype html><title>dev-browser-go state</title>"
+
+func sortStorageState(state *StorageStateFile) {
+	sort.Slice(state.Cookies, func(i, j int) bool {
+		a, b := state.Cookies[i], state.Cookies[j]
+		if a.Domain != b.Domain {
+			return a.Domain < b.Domain
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Name < b.Name
+	})
+	sort.Slice(state.Origins, func(i, j int) bool { return state.Origins[i].Origin < state.Origins[j].Origin })
+	for i := range state.Origins {
+		sortNameValues(state.Origins[i].LocalStorage)
+	}
+	sort.Slice(state.SessionStorage, func(i, j int) bool {
+		if state.SessionStorage[i].Page != state.SessionStorage[j].Page {
+			return state.SessionStorage[i].Page < state.SessionStorage[j].Page
+		}
+		return state.SessionStorage[i].Origin < state.SessionStorage[j].Origin
+	})
+	for i := range state.SessionStorage {
+		sortNameValues(state.SessionStorage[i].Items)
+	}
+}
+
+func sortNameValues(items []playwright.NameValue) {
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+}
+
+func validateStorageState(state StorageStateFile) error {
+	if state.Version > storageStateVersion {
+		return fmt.Errorf("unsupported state version %d", state.Version)
+	}
+	for _, o := range state.Origins {
+		if _, err := storageOriginURL(o.Origin); err != nil {
+			return err
+		}
+	}
+	for _, s := range state.SessionStorage {
+		if strings.TrimSpace(s.Page) == "" {
+			return errors.New("sessionStorage entry missing page")
+		}
+		if _, err := storageOriginURL(s.Origin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storageOriginURL turns an origin ("https://example.com") into a navigable URL.
+func storageOriginURL(origin string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(origin))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid origin %q", origin)
+	}
+	return u.Scheme + "://" + u.Host + "/", nil
+}
+
+func cookiesForAdd(cookies []playwright.Cookie) []playwright.OptionalCookie {
+	out := make([]playwright.OptionalCookie, 0, len(cookies))
+	for _, c := range cookies {
+		oc := playwright.OptionalCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   playwright.String(c.Domain),
+			Path:     playwright.String(c.Path),
+			HttpOnly: playwright.Bool(c.HttpOnly),
+			Secure:   playwright.Bool(c.Secure),
+			SameSite: c.SameSite,
+		}
+		if c.Path == "" {
+			oc.Path = playwright.String("/")
+		}
+		// -1 marks a session cookie.
+		if c.Expires > 0 {
+			oc.Expires = playwright.Float(c.Expires)
+		}
+		out = append(out, oc)
+	}
+	return out
+}
+
+func WriteStorageStateFile(path string, state StorageStateFile) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// The file holds session credentials; keep it private.
+	return os.WriteFile(path, data, 0o600)
+}
+
+func ReadStorageStateFile(path string) (StorageStateFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return StorageStateFile{}, err
+	}
+	var state StorageStateFile
+	if err := json.Unmarshal(data, &state); err != nil {
+		return StorageStateFile{}, fmt.Errorf("parse state file: %w", err)
+	}
+	if err := validateStorageState(state); err != nil {
+		return StorageStateFile{}, err
+	}
+	return state, nil
+}
+
+func readSessionStorage(page playwright.Page) (SessionStorageSnapshot, bool) {
+	raw, err := page.Evaluate(sessionStorageReadJS)
+	if err != nil {
+		return SessionStorageSnapshot{}, false
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return SessionStorageSnapshot{}, false
+	}
+	var snap SessionStorageSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return SessionStorageSnapshot{}, false
+	}
+	if _, err := storageOriginURL(snap.Origin); err != nil || len(snap.Items) == 0 {
+		return SessionStorageSnapshot{}, false
+	}
+	return snap, true
+}
+
+// writeOriginStorage navigates page to origin (served from a stub, not the
+// network) and writes items into local or session storage.
+func writeOriginStorage(page playwright.Page, origin, kind string, items []playwright.NameValue) error {
+	target, err := storageOriginURL(origin)
+	if err != nil {
+		return err
+	}
+	handler := func(route playwright.Route) {
+		_ = route.Fulfill(playwright.RouteFulfillOptions{
+			Status:      playwright.Int(200),
+			ContentType: playwright.String("text/html"),
+			Body:        stubOriginHTML,
+		})
+	}
+	if err := page.Route(target, handler); err != nil {
+		return err
+	}
+	defer func() { _ = page.Unroute(target, handler) }()
+	if _, err := page.Goto(target, playwright.PageGotoOptions{WaitUntil: playwright.WaitUntilStateDomcontentloaded}); err != nil {
+		return fmt.Errorf("open %s: %w", origin, err)
+	}
+	if _, err := page.Evaluate(storageWriteJS, []any{kind, storagePayload(items)}); err != nil {
+		return fmt.Errorf("write %s storage for %s: %w", kind, origin, err)
+	}
+	return nil
+}
+
+// restoreSessionStorage writes snap into a tab without navigating it: right
+// away when the tab is on the saved origin, otherwise from an init script
+// the first time the tab loads it. It reports whether the items were written
+// now.
+func restoreSessionStorage(page playwright.Page, snap SessionStorageSnapshot, marker string) (bool, error) {
+	target, err := storageOriginURL(snap.Origin)
+	if err != nil {
+		return false, err
+	}
+	origin := strings.TrimSuffix(target, "/")
+	payload := storagePayload(snap.Items)
+	if current, err := page.Evaluate("() => location.origin"); err == nil && current == origin {
+		if _, err := page.Evaluate(storageWriteJS, []any{"session", payload}); err != nil {
+			return false, fmt.Errorf("write session storage for %s: %w", snap.Origin, err)
+		}
+		return true, nil
+	}
+	args, err := json.Marshal([]any{origin, marker, payload})
+	if err != nil {
+		return false, err
+	}
+	if err := page.AddInitScript(playwright.Script{Content: playwright.String(fmt.Sprintf(sessionSeedJS, args))}); err != nil {
+		return false, fmt.Errorf("queue session storage for %s: %w", snap.Origin, err)
+	}
+	return false, nil
+}
+
+func storagePayload(items []playwright.NameValue) []map[string]string {
+	payload := make([]map[string]string, 0, len(items))
+	for _, item := range items {
+		payload = append(payload, map[string]string{"name": item.Name, "value": item.Value})
+	}
+	return payload
+}
@@ -0,0 +1,105 @@
+package devbrowser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+func TestStorageOriginURL(t *testing.T) {
+	got, err := storageOriginURL("https://app.example.com:8443")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "https://app.example.com:8443/" {
+		t.Fatalf("unexpected url %q", got)
+	}
+	for _, bad := range []string{"", "null", "file:///tmp", "about:blank", "example.com"} {
+		if _, err := storageOriginURL(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestValidateStorageState(t *testing.T) {
+	ok := StorageStateFile{
+		Version:        storageStateVersion,
+		Origins:        []playwright.Origin{{Origin: "https://example.com"}},
+		SessionStorage: []SessionStorageSnapshot{{Page: "main", Origin: "https://example.com"}},
+	}
+	if err := validateStorageState(ok); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []StorageStateFile{
+		{Version: storageStateVersion + 1},
+		{Origins: []playwright.Origin{{Origin: "null"}}},
+		{SessionStorage: []SessionStorageSnapshot{{Origin: "https://example.com"}}},
+	}
+	for _, state := range cases {
+		if err := validateStorageState(state); err == nil {
+			t.Fatalf("expected error for %+v", state)
+		}
+	}
+}
+
+func TestCookiesForAdd(t *testing.T) {
+	out := cookiesForAdd([]playwright.Cookie{
+		{Name: "sid", Value: "1", Domain: ".example.com", Expires: -1},
+		{Name: "pref", Value: "dark", Domain: "example.com", Path: "/app", Expires: 1900000000, Secure: true},
+	})
+	if len(out) != 2 {
+		t.Fatalf("expected 2 cookies, got %d", len(out))
+	}
+	if out[0].Expires != nil {
+		t.Fatalf("expected session cookie to omit expires, got %v", *out[0].Expires)
+	}
+	if out[0].Path == nil || *out[0].Path != "/" {
+		t.Fatalf("expected default path /")
+	}
+	if out[1].Expires == nil || *out[1].Expires != 1900000000 || !*out[1].Secure || *out[1].Path != "/app" {
+		t.Fatalf("unexpected persistent cookie: %+v", out[1])
+	}
+}
+
+func TestStorageStateFile_RoundTripSortedAndPrivate(t *testing.T) {
+	state := StorageStateFile{
+		Version: storageStateVersion,
+		Cookies: []playwright.Cookie{
+			{Name: "b", Domain: "b.example.com", Path: "/"},
+			{Name: "a", Domain: "a.example.com", Path: "/"},
+		},
+		Origins: []playwright.Origin{
+			{Origin: "https://b.example.com", LocalStorage: []playwright.NameValue{{Name: "z", Value: "1"}, {Name: "y", Value: "2"}}},
+			{Origin: "https://a.example.com"},
+		},
+	}
+	sortStorageState(&state)
+	if state.Cookies[0].Name != "a" || state.Origins[0].Origin != "https://a.example.com" {
+		t.Fatalf("expected sorted state, got %+v", state)
+	}
+	if state.Origins[1].LocalStorage[0].Name != "y" {
+		t.Fatalf("expected sorted localStorage, got %+v", state.Origins[1].LocalStorage)
+	}
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := WriteStorageStateFile(path, state); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("expected 0600 permissions, got %o", perm)
+	}
+	loaded, err := ReadStorageStateFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if s := loaded.Summary(); s.Cookies != 2 || s.Origins != 2 || s.SessionStorage != 0 {
+		t.Fatalf("unexpected summary: %+v", s)
+	}
+}
@@ -0,0 +1,267 @@
+package devbrowser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment read by EnsureDaemon and the daemon process it spawns. The CLI
+// sets them from the matching flags.
+const (
+	// EnvNoAutoRestart keeps a daemon started by another build running
+	// instead of restarting it (--no-auto-restart).
+	EnvNoAutoRestart = "DEV_BROWSER_NO_AUTO_RESTART"
+	EnvSocket        = "DEV_BROWSER_SOCKET"
+	EnvIdleTimeout   = "DEV_BROWSER_IDLE_TIMEOUT"
+	// EnvWaitTimeout is read by the CLI only (--wait-timeout), as a Go
+	// duration: a bare number is an error, not minutes.
+	EnvWaitTimeout = "DEV_BROWSER_WAIT_TIMEOUT"
+	EnvMaxPages    = "DEV_BROWSER_MAX_PAGES"
+	EnvMaxMemoryMB = "DEV_BROWSER_MAX_MEMORY_MB"
+)
+
+// daemonSnapshot is what an upgrade restart carries from the old daemon to
+// the new one: page URLs and named contexts with their storage, as
+// Reconfigure keeps them within one daemon, plus route rules and HAR replay.
+type daemonSnapshot struct {
+	Pages    []pageRestoreState
+	Contexts []contextSnapshot
+	// Routes and HARReplay are applied again in the new daemon. Dropped
+	// lists what could not be carried, for the upgrade warning.
+	Routes    []RouteRule
+	HARReplay *HARReplay
+	Dropped   []string
+}
+
+type contextSnapshot struct {
+	Name     string
+	Settings BrowserContextSettings
+	Storage  map[string]any
+	Pages    []pageRestoreState
+}
+
+// captureDaemonPages reads pages and contexts from a running daemon. It only
+// uses endpoints every daemon version serves, since the daemon is the old
+// build.
+func captureDaemonPages(baseURL string) (daemonSnapshot, error) {
+	snap := daemonSnapshot{}
+	data, err := HTTPJSON(http.MethodGet, baseURL+"/pages", nil, 10*time.Second)
+	if err != nil {
+		return snap, err
+	}
+	if msg, ok := data["error"]; ok {
+		return snap, fmt.Errorf("list pages: %v", msg)
+	}
+	names := []string{}
+	remarshalJSON(data["pages"], &names)
+	if snap.Pages, err = capturePageURLs(baseURL, "", names); err != nil {
+		return snap, err
+	}
+
+	contexts := []ContextInfo{}
+	remarshalJSON(data["contexts"], &contexts)
+	for _, info := range contexts {
+		if info.Name == "" || info.Name == DefaultContextName {
+			continue
+		}
+		ctx := contextSnapshot{Name: info.Name, Settings: info.Settings}
+		if ctx.Pages, err = capturePageURLs(baseURL, info.Name, info.Pages); err != nil {
+			return snap, err
+		}
+		// Best effort: cookies and localStorage survive when the old daemon
+		// can export them.
+		if state, err := HTTPJSON(http.MethodGet, baseURL+"/state?context="+url.QueryEscape(info.Name), nil, 30*time.Second); err == nil {
+			ctx.Storage, _ = state["state"].(map[string]any)
+		}
+		snap.Contexts = append(snap.Contexts, ctx)
+	}
+	captureDaemonInterception(baseURL, &snap)
+	return snap, nil
+}
+
+// captureDaemonInterception reads route rules and HAR state, best effort:
+// daemons from before those features have no such endpoints. A running HAR
+// recording is stopped, which writes it out, since the new daemon cannot
+// continue it.
+func captureDaemonInterception(baseURL string, snap *daemonSnapshot) {
+	if data, err := HTTPJSON(http.MethodGet, baseURL+"/routes", nil, 10*time.Second); err == nil && okError(data) == nil {
+		remarshalJSON(data["routes"], &snap.Routes)
+	}
+	data, err := HTTPJSON(http.MethodGet, baseURL+"/har", nil, 10*time.Second)
+	if err != nil || okError(data) != nil {
+		return
+	}
+	var st HARStatus
+	remarshalJSON(data["har"], &st)
+	snap.HARReplay = st.Replay
+	if !st.Recording {
+		return
+	}
+	stopped, err := HTTPJSON(http.MethodPost, baseURL+"/har/stop", map[string]any{}, 60*time.Second)
+	if err == nil {
+		err = okError(stopped)
+	}
+	if err != nil {
+		snap.Dropped = append(snap.Dropped, fmt.Sprintf("HAR recording to %s (not saved: %v)", st.RecordPath, err))
+		return
+	}
+	snap.Dropped = append(snap.Dropped, fmt.Sprintf("HAR recording (saved to %s; run `har record` again to continue)", st.RecordPath))
+}
+
+// upgradeDaemonArgs carries the old daemon's socket mode, idle timeout and
+// limits to its replacement, unless this process sets them itself.
+func upgradeDaemonArgs(health DaemonHealth) []string {
+	args := []string{}
+	if health.Socket != "" && os.Getenv(EnvSocket) == "" {
+		args = append(args, "--socket")
+	}
+	res := health.Resources
+	if res == nil {
+		return args
+	}
+	if res.IdleTimeoutMS > 0 && os.Getenv(EnvIdleTimeout) == "" {
+		args = append(args, "--idle-timeout", (time.Duration(res.IdleTimeoutMS) * time.Millisecond).String())
+	}
+	if res.MaxPages > 0 && os.Getenv(EnvMaxPages) == "" {
+		args = append(args, "--max-pages", strconv.Itoa(res.MaxPages))
+	}
+	if res.MaxMemoryMB > 0 && os.Getenv(EnvMaxMemoryMB) == "" {
+		args = append(args, "--max-memory-mb", strconv.Itoa(res.MaxMemoryMB))
+	}
+	return args
+}
+
+func capturePageURLs(baseURL, contextName string, names []string) ([]pageRestoreState, error) {
+	out := make([]pageRestoreState, 0, len(names))
+	for _, name := range names {
+		// POST /pages returns an existing page as-is, URL included.
+		data, err := HTTPJSON(http.MethodPost, baseURL+"/pages", pageBody(name, contextName), 10*time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("read page %q: %w", name, err)
+		}
+		pageURL, _ := data["url"].(string)
+		out = append(out, pageRestoreState{Name: name, URL: pageURL})
+	}
+	return out, nil
+}
+
+// restoreDaemonPages reopens a snapshot in a freshly started daemon. It keeps
+// going after a failure and reports every page it could not restore.
+func restoreDaemonPages(baseURL string, snap daemonSnapshot) error {
+	errs := []error{}
+	for _, page := range snap.Pages {
+		if err := restorePageOverHTTP(baseURL, "", page); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, ctx := range snap.Contexts {
+		window, device := cloneWindowSize(ctx.Settings.Window), strings.TrimSpace(ctx.Settings.Device)
+		if device != "" {
+			window = nil
+		}
+		if _, err := EnsureContext(baseURL, ctx.Name, window, device, false); err != nil {
+			errs = append(errs, fmt.Errorf("context %q: %w", ctx.Name, err))
+			continue
+		}
+		if ctx.Storage != nil {
+			data, err := HTTPJSON(http.MethodPost, baseURL+"/state?context="+url.QueryEscape(ctx.Name), ctx.Storage, 30*time.Second)
+			if err == nil {
+				err = okError(data)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("context %q storage: %w", ctx.Name, err))
+			}
+		}
+		for _, page := range ctx.Pages {
+			if err := restorePageOverHTTP(baseURL, ctx.Name, page); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	for _, rule := range snap.Routes {
+		rule.ID, rule.Hits, rule.CreatedMS = 0, 0, 0
+		body := map[string]any{}
+		remarshalJSON(rule, &body)
+		data, err := HTTPJSON(http.MethodPost, baseURL+"/routes", body, 10*time.Second)
+		if err == nil {
+			err = okError(data)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("route %s %s: %w", rule.Action, rule.URL+rule.Regex, err))
+		}
+	}
+	if snap.HARReplay != nil {
+		replay := *snap.HARReplay
+		replay.StartedMS = 0
+		body := map[string]any{}
+		remarshalJSON(replay, &body)
+		data, err := HTTPJSON(http.MethodPost, baseURL+"/har/replay", body, 60*time.Second)
+		if err == nil {
+			err = okError(data)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("har replay %s: %w", replay.Path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func restorePageOverHTTP(baseURL, contextName string, page pageRestoreState) error {
+	data, err := HTTPJSON(http.MethodPost, baseURL+"/pages", pageBody(page.Name, contextName), 10*time.Second)
+	if err == nil {
+		if msg, ok := data["error"]; ok {
+			err = fmt.Errorf("%v", msg)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("page %q: %w", page.Name, err)
+	}
+	if isBlankURL(page.URL) {
+		return nil
+	}
+	endpoint := baseURL + "/pages/" + url.PathEscape(page.Name) + "/call"
+	if contextName != "" {
+		endpoint += "?context=" + url.QueryEscape(contextName)
+	}
+	data, err = HTTPJSON(http.MethodPost, endpoint, map[string]any{"tool": "goto", "args": map[string]any{"url": page.URL}}, 60*time.Second)
+	if err == nil {
+		err = okError(data)
+	}
+	if err != nil {
+		return fmt.Errorf("page %q: goto %s: %w", page.Name, page.URL, err)
+	}
+	return nil
+}
+
+func pageBody(name, contextName string) map[string]any {
+	body := map[string]any{"name": name}
+	if contextName != "" {
+		body["context"] = contextName
+	}
+	return body
+}
+
+func okError(data map[string]any) error {
+	if ok, _ := data["ok"].(bool); ok {
+		return nil
+	}
+	return fmt.Errorf("%v", data["error"])
+}
+
+func isBlankURL(raw string) bool {
+	u := strings.TrimSpace(raw)
+	return u == "" || u == "about:blank"
+}
+
+func remarshalJSON(in any, out any) {
+	if b, err := json.Marshal(in); err == nil {
+		_ = json.Unmarshal(b, out)
+	}
+}
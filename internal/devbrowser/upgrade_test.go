@@ -0,0 +1,142 @@
+package devbrowser
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDaemonVersionMismatch(t *testing.T) {
+	defer SetBuildVersion(BuildVersion())
+	SetBuildVersion("0.3.0")
+
+	current := DaemonHealth{Version: DaemonVersion(), BuildVersion: "0.3.0", AssetHash: AssetHash()}
+	if got := daemonVersionMismatch(current); got != "" {
+		t.Fatalf("expected match, got %q", got)
+	}
+	if got := daemonVersionMismatch(DaemonHealth{Version: DaemonVersion(), BuildVersion: "0.2.3"}); got != "version=0.3.0 (was 0.2.3)" {
+		t.Fatalf("unexpected mismatch %q", got)
+	}
+	// Daemons from before the handshake only report the old asset stamp.
+	got := daemonVersionMismatch(DaemonHealth{Version: "go-dev-browser-daemon/0123"})
+	if !strings.Contains(got, "version=0.3.0 (was unknown)") || !strings.Contains(got, "assets="+AssetHash()+" (was unknown)") {
+		t.Fatalf("unexpected mismatch %q", got)
+	}
+
+	SetBuildVersion("")
+	if got := daemonVersionMismatch(DaemonHealth{Version: DaemonVersion(), BuildVersion: "0.2.3"}); got != "" {
+		t.Fatalf("expected builds without a version to compare assets only, got %q", got)
+	}
+}
+
+func TestCaptureAndRestoreDaemonPages(t *testing.T) {
+	old := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/pages":
+			_, _ = w.Write([]byte(`{"pages":["main","docs"],"contexts":[
+				{"name":"default","pages":["main","docs"]},
+				{"name":"alice","settings":{"device":"Pixel 5"},"pages":["main"]}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/pages":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			urls := map[string]string{"main": "http://app/", "docs": "about:blank", "alice/main": "http://app/alice"}
+			key := body["name"]
+			if body["context"] != "" {
+				key = body["context"] + "/" + key
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"name": body["name"], "url": urls[key]})
+		case r.URL.Path == "/state":
+			_, _ = w.Write([]byte(`{"ok":true,"state":{"cookies":[{"name":"sid"}]}}`))
+		case r.URL.Path == "/routes":
+			_, _ = w.Write([]byte(`{"ok":true,"routes":[{"id":3,"url":"**/api/*","action":"fulfill","status":503,"hits":7}]}`))
+		case r.URL.Path == "/har":
+			_, _ = w.Write([]byte(`{"ok":true,"har":{"recording":true,"record_path":"/tmp/s.har","replay":{"path":"/tmp/r.har","not_found":"fallback"}}}`))
+		case r.URL.Path == "/har/stop":
+			_, _ = w.Write([]byte(`{"ok":true,"har":{"recording":false}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer old.Close()
+
+	snap, err := captureDaemonPages(old.URL)
+	if err != nil {
+		t.Fatalf("capture: %v", err)
+	}
+	if len(snap.Pages) != 2 || snap.Pages[0].URL != "http://app/" || snap.Pages[1].Name != "docs" {
+		t.Fatalf("unexpected pages: %+v", snap.Pages)
+	}
+	if len(snap.Contexts) != 1 || snap.Contexts[0].Name != "alice" || snap.Contexts[0].Settings.Device != "Pixel 5" ||
+		snap.Contexts[0].Storage == nil || snap.Contexts[0].Pages[0].URL != "http://app/alice" {
+		t.Fatalf("unexpected contexts: %+v", snap.Contexts)
+	}
+	if len(snap.Routes) != 1 || snap.Routes[0].Status != 503 || snap.HARReplay == nil || snap.HARReplay.Path != "/tmp/r.har" {
+		t.Fatalf("unexpected interception: %+v %+v", snap.Routes, snap.HARReplay)
+	}
+	if len(snap.Dropped) != 1 || !strings.Contains(snap.Dropped[0], "saved to /tmp/s.har") {
+		t.Fatalf("a running recording should be saved and reported: %v", snap.Dropped)
+	}
+
+	var mu sync.Mutex
+	calls := []string{}
+	fresh := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		call := r.Method + " " + r.URL.RequestURI()
+		if tool, _ := body["tool"].(string); tool != "" {
+			call += " " + tool + " " + body["args"].(map[string]any)["url"].(string)
+		}
+		if r.URL.Path == "/routes" && body["id"] == float64(0) && body["hits"] == float64(0) {
+			call += " " + body["url"].(string)
+		}
+		mu.Lock()
+		calls = append(calls, call)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/contexts":
+			_, _ = w.Write([]byte(`{"ok":true,"action":"started","context":{"name":"alice"}}`))
+		default:
+			_, _ = w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	defer fresh.Close()
+
+	if err := restoreDaemonPages(fresh.URL, snap); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	want := []string{
+		"POST /pages",
+		"POST /pages/main/call goto http://app/",
+		"POST /pages",
+		"POST /contexts",
+		"POST /state?context=alice",
+		"POST /pages",
+		"POST /pages/main/call?context=alice goto http://app/alice",
+		"POST /routes **/api/*",
+		"POST /har/replay",
+	}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected restore calls:\n%s", strings.Join(calls, "\n"))
+	}
+}
+
+func TestUpgradeDaemonArgs(t *testing.T) {
+	t.Setenv(EnvSocket, "")
+	t.Setenv(EnvIdleTimeout, "")
+	t.Setenv(EnvMaxPages, "5")
+	t.Setenv(EnvMaxMemoryMB, "")
+	health := DaemonHealth{Socket: "/tmp/d.sock", Resources: &DaemonResources{IdleTimeoutMS: 1_800_000, MaxPages: 2, MaxMemoryMB: 512}}
+	got := strings.Join(upgradeDaemonArgs(health), " ")
+	// MaxPages comes from this process's environment instead.
+	if got != "--socket --idle-timeout 30m0s --max-memory-mb 512" {
+		t.Fatalf("unexpected args %q", got)
+	}
+	if got := upgradeDaemonArgs(DaemonHealth{}); len(got) != 0 {
+		t.Fatalf("unexpected args %q", got)
+	}
+}
@@ -3,13 +3,59 @@ package devbrowser
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
+	"strings"
 )
 
+// buildVersion is the release version of the binary (e.g. "0.2.3+gabc123"),
+// set by the CLI at startup.
+var buildVersion string
+
+// SetBuildVersion records the binary's release version so daemons report it
+// in /health and daemon.json.
+func SetBuildVersion(v string) {
+	buildVersion = strings.TrimSpace(v)
+}
+
+func BuildVersion() string {
+	return buildVersion
+}
+
+// AssetHash identifies the JS the daemon injects into pages (harness and
+// snapshot scripts).
+func AssetHash() string {
+	h := sha256.New()
+	for _, asset := range []string{harnessInitJS, baseSnapshotJS, ariaDomUtils, ariaYamlUtils, ariaRoleUtilsPart1, ariaRoleUtilsPart2, ariaSnapshotJS} {
+		h.Write([]byte(asset))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
 // DaemonVersion is a compatibility/version stamp used to decide whether an existing
 // daemon should be restarted. Keep it stable and purely derived from embedded assets
 // + schema-affecting behavior.
 func DaemonVersion() string {
-	// Include harness init JS content hash so changes force a restart.
-	sum := sha256.Sum256([]byte(harnessInitJS))
-	return "go-dev-browser-daemon/" + hex.EncodeToString(sum[:8])
+	return "go-dev-browser-daemon/" + AssetHash()
+}
+
+// daemonVersionMismatch explains why a running daemon does not match this
+// binary, or returns "" when it does. Daemons older than the handshake report
+// neither build version nor asset hash.
+func daemonVersionMismatch(health DaemonHealth) string {
+	diffs := []string{}
+	if want := BuildVersion(); want != "" && strings.TrimSpace(health.BuildVersion) != want {
+		diffs = append(diffs, fmt.Sprintf("version=%s (was %s)", want, orUnknown(health.BuildVersion)))
+	}
+	if strings.TrimSpace(health.Version) != DaemonVersion() {
+		diffs = append(diffs, fmt.Sprintf("assets=%s (was %s)", AssetHash(), orUnknown(health.AssetHash)))
+	}
+	return strings.Join(diffs, " ")
+}
+
+func orUnknown(v string) string {
+	if strings.TrimSpace(v) == "" {
+		return "unknown"
+	}
+	return v
 }